@@ -12,7 +12,15 @@ const App: FunctionComponent<Props> = () => {
   const nonce = params.get('nonce');
   const redirectUri = params.get('redirect_uri');
   const responseType = params.get("response_type");
+  const responseMode = params.get("response_mode");
   const scope = params.get("scope");
+  const codeChallenge = params.get("code_challenge");
+  const codeChallengeMethod = params.get("code_challenge_method");
+  const userCode = params.get("user_code");
+  const prompt = params.get("prompt");
+  const maxAge = params.get("max_age");
+  const loginHint = params.get("login_hint");
+  const idTokenHint = params.get("id_token_hint");
 
   return (
     <main className="form-login">
@@ -24,7 +32,15 @@ const App: FunctionComponent<Props> = () => {
         nonce={nonce}
         redirectUri={redirectUri}
         responseType={responseType}
+        responseMode={responseMode}
         scope={scope}
+        codeChallenge={codeChallenge}
+        codeChallengeMethod={codeChallengeMethod}
+        userCode={userCode}
+        prompt={prompt}
+        maxAge={maxAge}
+        loginHint={loginHint}
+        idTokenHint={idTokenHint}
       />
 
       <p className="mt-5 mb-3 text-muted">
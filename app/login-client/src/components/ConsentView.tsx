@@ -0,0 +1,55 @@
+import { FunctionComponent } from 'react';
+import { ConsentModel, ErrorModel } from '../models';
+
+export interface ConsentViewProps {
+  consent: ConsentModel;
+  loading: boolean;
+  error: ErrorModel | null;
+  onAnswer: (approved: boolean) => void;
+}
+
+const ConsentView: FunctionComponent<ConsentViewProps> = ({
+  consent,
+  loading,
+  error,
+  onAnswer,
+}) => (
+  <>
+    {error && (
+      <div className="alert alert-danger" role="alert">
+        {error.error_description || error.error}
+      </div>
+    )}
+
+    <p>
+      <strong>{consent.clientName}</strong> would like to access your account,
+      with the following scopes:
+    </p>
+    <ul className="list-group mb-3 text-start">
+      {consent.scopes.map(scope => (
+        <li key={scope} className="list-group-item">
+          {scope}
+        </li>
+      ))}
+    </ul>
+
+    <button
+      type="button"
+      className="w-100 btn btn-lg btn-primary mb-2"
+      disabled={loading}
+      onClick={() => onAnswer(true)}
+    >
+      Allow
+    </button>
+    <button
+      type="button"
+      className="w-100 btn btn-lg btn-outline-secondary"
+      disabled={loading}
+      onClick={() => onAnswer(false)}
+    >
+      Deny
+    </button>
+  </>
+);
+
+export default ConsentView;
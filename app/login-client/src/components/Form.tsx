@@ -2,10 +2,17 @@ import {
   ChangeEventHandler,
   FormEventHandler,
   FunctionComponent,
+  useEffect,
   useState,
 } from 'react';
 import { login } from '../api';
-import { ErrorModel, LoginModel, LoginResponseModel } from '../models';
+import {
+  ConsentModel,
+  ErrorModel,
+  LoginModel,
+  LoginResponseModel,
+} from '../models';
+import ConsentView from './ConsentView';
 import FormView from './FormView';
 
 export interface FormProps {
@@ -14,7 +21,15 @@ export interface FormProps {
   nonce: string | null;
   redirectUri: string | null;
   responseType: string | null;
+  responseMode: string | null;
   scope: string | null;
+  codeChallenge: string | null;
+  codeChallengeMethod: string | null;
+  userCode: string | null;
+  prompt: string | null;
+  maxAge: string | null;
+  loginHint: string | null;
+  idTokenHint: string | null;
 }
 
 const Form: FunctionComponent<FormProps> = ({
@@ -23,13 +38,93 @@ const Form: FunctionComponent<FormProps> = ({
   clientId,
   redirectUri,
   responseType,
+  responseMode,
   scope,
+  codeChallenge,
+  codeChallengeMethod,
+  userCode: initialUserCode,
+  prompt,
+  maxAge,
+  loginHint,
+  idTokenHint,
 }) => {
-  const [email, setEmail] = useState('');
+  // Without a client id, the user is approving a device, so must enter
+  // the code displayed on it, unless it was given in the link.
+  const isDevice = !clientId;
+
+  const [userCode, setUserCode] = useState(initialUserCode ?? '');
+  const [approved, setApproved] = useState(false);
+
+  // The client may know who is logging in, so the email is filled in for them.
+  const [email, setEmail] = useState(loginHint ?? '');
+
   const [password, setPassword] = useState('');
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState<ErrorModel | null>(null);
 
+  // Set when the user must consent to the request before being redirected.
+  const [consent, setConsent] = useState<ConsentModel | null>(null);
+
+  const buildModel = (
+    email: string,
+    password: string,
+    consent: boolean | null = null
+  ): LoginModel => ({
+    email,
+    password,
+    state,
+    nonce,
+    clientId,
+    redirectUri,
+    responseType,
+    responseMode,
+    scopes: scope?.split(" ") ?? [],
+    codeChallenge,
+    codeChallengeMethod,
+    userCode: isDevice ? userCode : null,
+    prompt,
+    maxAge: maxAge !== null ? Number(maxAge) : null,
+    loginHint,
+    idTokenHint,
+    consent,
+  });
+
+  const handleResponse = (data: LoginResponseModel) => {
+    if (data.consent) {
+      setError(null);
+      setConsent(data.consent);
+    } else if (data.form) {
+      // Using form_post, the response is posted to the client by
+      // an auto-submitting form, which replaces the login page.
+      document.open();
+      document.write(data.form);
+      document.close();
+    } else if (data.redirectUri) {
+      window.location.replace(data.redirectUri);
+    } else {
+      setApproved(true);
+    }
+  };
+
+  // If the user already has a session, the request is authorized without
+  // showing the form. Otherwise, the error is ignored and the user logs in.
+  // Using prompt=none, the server redirects with an error instead.
+  useEffect(() => {
+    if (isDevice) {
+      return;
+    }
+
+    setLoading(true);
+    login(buildModel('', '')).then(res => {
+      if (!(res as ErrorModel)?.error) {
+        handleResponse(res as LoginResponseModel);
+      }
+
+      setLoading(false);
+    });
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, []);
+
   const handleSubmit: FormEventHandler<HTMLFormElement> = async e => {
     e.preventDefault();
 
@@ -39,23 +134,33 @@ const Form: FunctionComponent<FormProps> = ({
 
     setLoading(true);
 
-    const data: LoginModel = {
-      email,
-      password,
-      state,
-      nonce,
-      clientId,
-      redirectUri,
-      responseType,
-      scopes: scope?.split(" ") ?? [],
-    };
-
-    const res = await login(data);
+    const res = await login(buildModel(email, password));
     if ((res as ErrorModel)?.error) {
       setError(res as ErrorModel);
     } else {
-      const data = res as LoginResponseModel;
-      window.location.replace(data.redirectUri);
+      handleResponse(res as LoginResponseModel);
+    }
+
+    setLoading(false);
+  };
+
+  // The request is sent again with the user's answer, along with their
+  // credentials, if they logged in rather than using their session. The
+  // email may have been filled in from the login hint, so isn't enough.
+  const handleConsent = async (approved: boolean) => {
+    if (loading) {
+      return;
+    }
+
+    setLoading(true);
+
+    const res = await login(
+      password ? buildModel(email, password, approved) : buildModel('', '', approved)
+    );
+    if ((res as ErrorModel)?.error) {
+      setError(res as ErrorModel);
+    } else {
+      handleResponse(res as LoginResponseModel);
     }
 
     setLoading(false);
@@ -65,6 +170,9 @@ const Form: FunctionComponent<FormProps> = ({
     const { name, value } = e.target;
 
     switch (name) {
+      case 'userCode':
+        setUserCode(value);
+        break;
       case 'email':
         setEmail(value);
         break;
@@ -74,8 +182,21 @@ const Form: FunctionComponent<FormProps> = ({
     }
   };
 
+  if (consent) {
+    return (
+      <ConsentView
+        consent={consent}
+        loading={loading}
+        error={error}
+        onAnswer={handleConsent}
+      />
+    );
+  }
+
   return (
     <FormView
+      userCode={isDevice ? userCode : null}
+      approved={approved}
       email={email}
       password={password}
       loading={loading}
@@ -2,6 +2,8 @@ import { ChangeEventHandler, FormEventHandler, FunctionComponent } from 'react';
 import { ErrorModel } from '../models';
 
 export interface FormViewProps {
+  userCode: string | null;
+  approved: boolean;
   email: string;
   password: string;
   loading: boolean;
@@ -11,21 +13,43 @@ export interface FormViewProps {
 }
 
 const FormView: FunctionComponent<FormViewProps> = ({
+  userCode,
+  approved,
   email,
   password,
   loading,
   error,
   onSubmit,
   onChange,
-}) => (
+}) => approved ? (
+  <div className="alert alert-success" role="alert">
+    Your device has been signed in. You can now return to it.
+  </div>
+) : (
   <>
     {error &&  (
       <div className="alert alert-danger" role="alert">
-        {error.error}
+        {error.error_description || error.error}
       </div>
     )}
 
     <form onSubmit={onSubmit}>
+      {userCode !== null && (
+        <div className="form-floating">
+          <input
+            type="text"
+            autoComplete="off"
+            className="form-control"
+            required
+            id="userCode"
+            name="userCode"
+            placeholder="XXXX-XXXX"
+            value={userCode}
+            onChange={onChange}
+          />
+          <label htmlFor="userCode">Device Code</label>
+        </div>
+      )}
       <div className="form-floating">
         <input
           type="email"
@@ -0,0 +1,4 @@
+export default interface ConsentModel {
+  clientName: string;
+  scopes: string[];
+}
@@ -1,3 +1,4 @@
 export default interface ErrorModel {
   error: string;
+  error_description?: string;
 }
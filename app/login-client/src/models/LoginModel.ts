@@ -4,7 +4,16 @@ export default interface LoginModel {
   nonce: string | null;
   redirectUri: string | null;
   responseType: string | null;
+  responseMode: string | null;
   scopes: string[];
+  codeChallenge: string | null;
+  codeChallengeMethod: string | null;
+  userCode: string | null;
+  prompt: string | null;
+  maxAge: number | null;
+  loginHint: string | null;
+  idTokenHint: string | null;
+  consent: boolean | null;
   email: string;
   password: string;
 }
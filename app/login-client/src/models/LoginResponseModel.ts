@@ -1,3 +1,7 @@
+import ConsentModel from './ConsentModel';
+
 export default interface LoginResponseModel {
   redirectUri: string;
+  form?: string;
+  consent?: ConsentModel;
 }
@@ -1,5 +1,6 @@
+import ConsentModel from './ConsentModel';
 import ErrorModel from './ErrorModel';
 import LoginModel from './LoginModel';
 import LoginResponseModel from './LoginResponseModel';
 
-export type { ErrorModel, LoginModel, LoginResponseModel };
+export type { ConsentModel, ErrorModel, LoginModel, LoginResponseModel };
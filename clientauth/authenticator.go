@@ -0,0 +1,135 @@
+package clientauth
+
+import (
+	"context"
+	"log"
+	"net/url"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/reecerussell/gojwt"
+
+	"github.com/reecerussell/goidc/dal"
+	"github.com/reecerussell/goidc/jwk"
+	"github.com/reecerussell/goidc/oauth"
+	"github.com/reecerussell/goidc/token"
+	"github.com/reecerussell/goidc/validator"
+)
+
+// Authenticator is used to authenticate the client which sent a request.
+type Authenticator interface {
+	// Authenticate reads the credentials from req, and ensures they were sent
+	// using the method the client is registered with. Client assertions are
+	// verified, however, secrets are not, as some grants may be used by public
+	// clients without one. Unless the client sent an assertion, callers must
+	// validate its secret.
+	Authenticate(ctx context.Context, req events.APIGatewayProxyRequest, data url.Values) (*dal.Client, *Credentials, error)
+}
+
+// authenticator is an implementation of Authenticator.
+type authenticator struct {
+	clients    dal.ClientProvider
+	validator  validator.ClientValidator
+	tokens     token.Service
+	keys       jwk.Fetcher
+	assertions dal.AssertionStore
+}
+
+// New returns a new instance of Authenticator.
+func New(clients dal.ClientProvider, validator validator.ClientValidator, tokens token.Service, keys jwk.Fetcher, assertions dal.AssertionStore) Authenticator {
+	return &authenticator{
+		clients:    clients,
+		validator:  validator,
+		tokens:     tokens,
+		keys:       keys,
+		assertions: assertions,
+	}
+}
+
+func (a *authenticator) Authenticate(ctx context.Context, req events.APIGatewayProxyRequest, data url.Values) (*dal.Client, *Credentials, error) {
+	creds, err := Read(req, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c, err := a.clients.Get(ctx, creds.ClientID)
+	if err != nil {
+		if err == dal.ErrClientNotFound {
+			return nil, nil, ErrInvalidClient
+		}
+
+		return nil, nil, err
+	}
+
+	if creds.Assertion != "" {
+		audience := oauth.Issuer(ctx, req) + oauth.TokenPath
+		err = a.authenticateAssertion(ctx, c, creds, audience)
+	} else {
+		err = a.validator.ValidateTokenEndpointAuthMethod(c, creds.Method)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return c, creds, nil
+}
+
+// authenticateAssertion verifies the client assertion in creds, using the
+// keys registered for c, and ensures the assertion hasn't been used before.
+// The assertion's audience must be the token endpoint.
+func (a *authenticator) authenticateAssertion(ctx context.Context, c *dal.Client, creds *Credentials, audience string) error {
+	var claims gojwt.Claims
+	var err error
+
+	switch c.TokenEndpointAuthMethod {
+	case oauth.TokenEndpointAuthMethodPrivateKeyJWT:
+		set := c.JWKS
+		if set == nil && c.JWKSUri != "" {
+			set, err = a.keys.Fetch(ctx, c.JWKSUri)
+			if err != nil {
+				return err
+			}
+		}
+
+		if set == nil {
+			return validator.ErrInvalidAuthMethod
+		}
+
+		claims, err = a.tokens.VerifyAssertionWithKeySet(set, creds.Assertion, c.ID, audience)
+	case oauth.TokenEndpointAuthMethodClientSecretJWT:
+		if c.SharedKey == "" {
+			return validator.ErrInvalidAuthMethod
+		}
+
+		alg := token.NewHMACAlgorithm([]byte(c.SharedKey))
+		claims, err = a.tokens.VerifyAssertion(alg, creds.Assertion, c.ID, audience)
+	default:
+		return validator.ErrInvalidAuthMethod
+	}
+	if err != nil {
+		log.Printf("Invalid client assertion: %v", err)
+		return validator.ErrInvalidAssertion
+	}
+
+	err = a.validator.ValidateClientAssertion(c, claims)
+	if err != nil {
+		return err
+	}
+
+	jti, _ := claims.String("jti")
+	exp, _ := claims.Expiry()
+	err = a.assertions.Use(ctx, &dal.UsedAssertion{
+		ID:      c.ID + "/" + jti,
+		Expires: exp.Unix(),
+	})
+	if err != nil {
+		if err == dal.ErrAssertionUsed {
+			return validator.ErrInvalidAssertion
+		}
+
+		return err
+	}
+
+	creds.Method = c.TokenEndpointAuthMethod
+
+	return nil
+}
@@ -0,0 +1,265 @@
+package clientauth
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/golang/mock/gomock"
+	"github.com/reecerussell/gojwt"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc"
+	"github.com/reecerussell/goidc/dal"
+	dalMock "github.com/reecerussell/goidc/dal/mock"
+	"github.com/reecerussell/goidc/jwk"
+	jwkMock "github.com/reecerussell/goidc/jwk/mock"
+	"github.com/reecerussell/goidc/oauth"
+	"github.com/reecerussell/goidc/token"
+	tokenMock "github.com/reecerussell/goidc/token/mock"
+	"github.com/reecerussell/goidc/validator"
+	valMock "github.com/reecerussell/goidc/validator/mock"
+)
+
+const testAudience = "https://example.com/prod/oauth/token"
+
+func buildRequest() events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		Headers: map[string]string{
+			"Host": "example.com",
+		},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Stage: "prod",
+		},
+	}
+}
+
+func buildContext(req events.APIGatewayProxyRequest) context.Context {
+	return goidc.NewContext(context.Background(), &req)
+}
+
+func assertionData(clientId string) url.Values {
+	return url.Values{
+		"client_id":             {clientId},
+		"client_assertion":      {"my.client.assertion"},
+		"client_assertion_type": {oauth.ClientAssertionTypeJWTBearer},
+	}
+}
+
+func TestAuthenticate_GivenSecret_ReturnsClient(t *testing.T) {
+	testClient := &dal.Client{ID: "3247023"}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClients := dalMock.NewMockClientProvider(ctrl)
+	mockClients.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(testClient, oauth.TokenEndpointAuthMethodClientSecretPost).Return(nil)
+
+	req := buildRequest()
+	data := url.Values{"client_id": {testClient.ID}, "client_secret": {"2934uldnf"}}
+
+	a := New(mockClients, mockValidator, nil, nil, nil)
+	c, creds, err := a.Authenticate(buildContext(req), req, data)
+	assert.NoError(t, err)
+	assert.Equal(t, testClient, c)
+	assert.Equal(t, "2934uldnf", creds.Secret)
+}
+
+func TestAuthenticate_GivenUnregisteredMethod_ReturnsError(t *testing.T) {
+	testClient := &dal.Client{
+		ID:                      "3247023",
+		TokenEndpointAuthMethod: oauth.TokenEndpointAuthMethodClientSecretBasic,
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClients := dalMock.NewMockClientProvider(ctrl)
+	mockClients.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(testClient, oauth.TokenEndpointAuthMethodClientSecretPost).
+		Return(validator.ErrInvalidAuthMethod)
+
+	req := buildRequest()
+	data := url.Values{"client_id": {testClient.ID}, "client_secret": {"2934uldnf"}}
+
+	a := New(mockClients, mockValidator, nil, nil, nil)
+	_, _, err := a.Authenticate(buildContext(req), req, data)
+	assert.Equal(t, validator.ErrInvalidAuthMethod, err)
+}
+
+func TestAuthenticate_GivenUnknownClient_ReturnsInvalidClient(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClients := dalMock.NewMockClientProvider(ctrl)
+	mockClients.EXPECT().Get(gomock.Any(), "unknown").Return(nil, dal.ErrClientNotFound)
+
+	req := buildRequest()
+
+	a := New(mockClients, nil, nil, nil, nil)
+	_, _, err := a.Authenticate(buildContext(req), req, url.Values{"client_id": {"unknown"}})
+	assert.Equal(t, ErrInvalidClient, err)
+}
+
+func TestAuthenticate_WhereClientProviderFails_ReturnsError(t *testing.T) {
+	testError := errors.New("an error occured")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClients := dalMock.NewMockClientProvider(ctrl)
+	mockClients.EXPECT().Get(gomock.Any(), "3247023").Return(nil, testError)
+
+	req := buildRequest()
+
+	a := New(mockClients, nil, nil, nil, nil)
+	_, _, err := a.Authenticate(buildContext(req), req, url.Values{"client_id": {"3247023"}})
+	assert.Equal(t, testError, err)
+}
+
+func TestAuthenticate_GivenPrivateKeyJWT_VerifiesAssertion(t *testing.T) {
+	testKeySet := &jwk.Set{}
+	testClient := &dal.Client{
+		ID:                      "3247023",
+		TokenEndpointAuthMethod: oauth.TokenEndpointAuthMethodPrivateKeyJWT,
+		JWKSUri:                 "https://client.example.com/jwks",
+	}
+	testClaims := gojwt.Claims{"jti": "29384", "exp": float64(1622505600)}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClients := dalMock.NewMockClientProvider(ctrl)
+	mockClients.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockKeys := jwkMock.NewMockFetcher(ctrl)
+	mockKeys.EXPECT().Fetch(gomock.Any(), testClient.JWKSUri).Return(testKeySet, nil)
+
+	mockTokens := tokenMock.NewMockService(ctrl)
+	mockTokens.EXPECT().VerifyAssertionWithKeySet(testKeySet, "my.client.assertion", testClient.ID, testAudience).Return(testClaims, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateClientAssertion(testClient, testClaims).Return(nil)
+
+	mockAssertions := dalMock.NewMockAssertionStore(ctrl)
+	mockAssertions.EXPECT().Use(gomock.Any(), &dal.UsedAssertion{ID: "3247023/29384", Expires: 1622505600}).Return(nil)
+
+	req := buildRequest()
+
+	a := New(mockClients, mockValidator, mockTokens, mockKeys, mockAssertions)
+	c, creds, err := a.Authenticate(buildContext(req), req, assertionData(testClient.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, testClient, c)
+	assert.Equal(t, oauth.TokenEndpointAuthMethodPrivateKeyJWT, creds.Method)
+}
+
+func TestAuthenticate_GivenHS256Assertion_VerifiesWithSharedKey(t *testing.T) {
+	testClient := &dal.Client{
+		ID:                      "3247023",
+		TokenEndpointAuthMethod: oauth.TokenEndpointAuthMethodClientSecretJWT,
+		SharedKey:               "my shared key",
+	}
+
+	// The assertion is signed by the client, so it's the issuer.
+	alg := token.NewHMACAlgorithm([]byte(testClient.SharedKey))
+	assertion, _ := token.New(testClient.ID).GenerateToken(alg, map[string]interface{}{"sub": testClient.ID}, 60, testAudience)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClients := dalMock.NewMockClientProvider(ctrl)
+	mockClients.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockAssertions := dalMock.NewMockAssertionStore(ctrl)
+	mockAssertions.EXPECT().Use(gomock.Any(), gomock.Any()).Return(nil)
+
+	req := buildRequest()
+	data := assertionData(testClient.ID)
+	data.Set("client_assertion", assertion.AccessToken)
+
+	a := New(mockClients, validator.NewClientValidator(), token.New("https://example.com/prod"), nil, mockAssertions)
+	c, creds, err := a.Authenticate(buildContext(req), req, data)
+	assert.NoError(t, err)
+	assert.Equal(t, testClient, c)
+	assert.Equal(t, oauth.TokenEndpointAuthMethodClientSecretJWT, creds.Method)
+}
+
+func TestAuthenticate_GivenInvalidAssertion_ReturnsError(t *testing.T) {
+	testClient := &dal.Client{
+		ID:                      "3247023",
+		TokenEndpointAuthMethod: oauth.TokenEndpointAuthMethodClientSecretJWT,
+		SharedKey:               "my shared key",
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClients := dalMock.NewMockClientProvider(ctrl)
+	mockClients.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockTokens := tokenMock.NewMockService(ctrl)
+	mockTokens.EXPECT().VerifyAssertion(gomock.Any(), "my.client.assertion", testClient.ID, testAudience).
+		Return(nil, errors.New("invalid signature"))
+
+	req := buildRequest()
+
+	a := New(mockClients, nil, mockTokens, nil, nil)
+	_, _, err := a.Authenticate(buildContext(req), req, assertionData(testClient.ID))
+	assert.Equal(t, validator.ErrInvalidAssertion, err)
+}
+
+func TestAuthenticate_GivenUsedAssertion_ReturnsError(t *testing.T) {
+	testClient := &dal.Client{
+		ID:                      "3247023",
+		TokenEndpointAuthMethod: oauth.TokenEndpointAuthMethodClientSecretJWT,
+		SharedKey:               "my shared key",
+	}
+	testClaims := gojwt.Claims{"jti": "29384", "exp": float64(1622505600)}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClients := dalMock.NewMockClientProvider(ctrl)
+	mockClients.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockTokens := tokenMock.NewMockService(ctrl)
+	mockTokens.EXPECT().VerifyAssertion(gomock.Any(), "my.client.assertion", testClient.ID, testAudience).Return(testClaims, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateClientAssertion(testClient, testClaims).Return(nil)
+
+	mockAssertions := dalMock.NewMockAssertionStore(ctrl)
+	mockAssertions.EXPECT().Use(gomock.Any(), gomock.Any()).Return(dal.ErrAssertionUsed)
+
+	req := buildRequest()
+
+	a := New(mockClients, mockValidator, mockTokens, nil, mockAssertions)
+	_, _, err := a.Authenticate(buildContext(req), req, assertionData(testClient.ID))
+	assert.Equal(t, validator.ErrInvalidAssertion, err)
+}
+
+func TestAuthenticate_GivenAssertionForClientWithoutKeys_ReturnsError(t *testing.T) {
+	testClient := &dal.Client{
+		ID:                      "3247023",
+		TokenEndpointAuthMethod: oauth.TokenEndpointAuthMethodPrivateKeyJWT,
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClients := dalMock.NewMockClientProvider(ctrl)
+	mockClients.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	req := buildRequest()
+
+	a := New(mockClients, nil, nil, nil, nil)
+	_, _, err := a.Authenticate(buildContext(req), req, assertionData(testClient.ID))
+	assert.Equal(t, validator.ErrInvalidAuthMethod, err)
+}
@@ -0,0 +1,104 @@
+// Package clientauth is used to authenticate clients at the endpoints which
+// require client authentication, such as the token, introspection and
+// revocation endpoints, as defined by RFC 6749 and RFC 7523.
+package clientauth
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/reecerussell/goidc/oauth"
+	"github.com/reecerussell/goidc/token"
+	"github.com/reecerussell/goidc/util"
+	"github.com/reecerussell/goidc/validator"
+)
+
+// Client authentication errors, returned when the client's credentials
+// cannot be read, or the client doesn't exist.
+var (
+	ErrInvalidClient        = util.NewOAuthError(http.StatusUnauthorized, util.ErrorCodeInvalidClient, "invalid client id")
+	ErrMultipleAuthMethods  = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidRequest, "multiple client authentication methods")
+	ErrInvalidAssertionType = util.NewOAuthError(http.StatusUnauthorized, util.ErrorCodeInvalidClient, "invalid client assertion type")
+)
+
+// Credentials holds the credentials a client authenticated with.
+type Credentials struct {
+	ClientID  string
+	Secret    string
+	Method    string
+	Assertion string
+}
+
+// Read returns the client's credentials from req, along with the
+// authentication method used to send them. Credentials are read from the
+// Authorization header, using client_secret_basic, or from the form data,
+// using client_secret_post. Public clients send only their client id.
+//
+// Clients may instead authenticate with a JWT client assertion, as defined by
+// RFC 7523, in which case the method is determined by the client's registration.
+func Read(req events.APIGatewayProxyRequest, data url.Values) (*Credentials, error) {
+	assertion := data.Get("client_assertion")
+	assertionType := data.Get("client_assertion_type")
+
+	clientId, clientSecret, ok := util.BasicAuth(req)
+	if ok {
+		// The client id may also be sent in the body, but must match.
+		if data.Get("client_secret") != "" || assertion != "" || (data.Get("client_id") != "" && data.Get("client_id") != clientId) {
+			return nil, ErrMultipleAuthMethods
+		}
+
+		return &Credentials{
+			ClientID: clientId,
+			Secret:   clientSecret,
+			Method:   oauth.TokenEndpointAuthMethodClientSecretBasic,
+		}, nil
+	}
+
+	if assertion != "" || assertionType != "" {
+		if data.Get("client_secret") != "" {
+			return nil, ErrMultipleAuthMethods
+		}
+
+		if assertionType != oauth.ClientAssertionTypeJWTBearer {
+			return nil, ErrInvalidAssertionType
+		}
+
+		return readAssertion(data.Get("client_id"), assertion)
+	}
+
+	clientId = data.Get("client_id")
+	clientSecret = data.Get("client_secret")
+	if clientSecret == "" {
+		return &Credentials{
+			ClientID: clientId,
+			Method:   oauth.TokenEndpointAuthMethodNone,
+		}, nil
+	}
+
+	return &Credentials{
+		ClientID: clientId,
+		Secret:   clientSecret,
+		Method:   oauth.TokenEndpointAuthMethodClientSecretPost,
+	}, nil
+}
+
+// readAssertion returns the credentials for a client assertion. The
+// client_id parameter is optional, so the client may be identified using the
+// assertion's subject, which is verified once the client's keys are known.
+func readAssertion(clientId, assertion string) (*Credentials, error) {
+	if clientId == "" {
+		claims, err := token.UnverifiedClaims(assertion)
+		if err != nil {
+			return nil, validator.ErrInvalidAssertion
+		}
+
+		clientId, _ = claims.String("sub")
+	}
+
+	return &Credentials{
+		ClientID:  clientId,
+		Assertion: assertion,
+	}, nil
+}
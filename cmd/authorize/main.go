@@ -1,32 +1,90 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"errors"
-	"fmt"
+	"html/template"
 	"log"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/reecerussell/gojwt"
-	"github.com/reecerussell/gojwt/kms"
 
 	"github.com/reecerussell/goidc"
+	"github.com/reecerussell/goidc/cookie"
 	"github.com/reecerussell/goidc/dal"
 	"github.com/reecerussell/goidc/dal/dynamo"
+	"github.com/reecerussell/goidc/oauth"
 	"github.com/reecerussell/goidc/token"
 	"github.com/reecerussell/goidc/util"
 	"github.com/reecerussell/goidc/validator"
 )
 
+// codeExpiry is the lifetime of an authorization code, which
+// should be exchanged for tokens shortly after being issued.
+const codeExpiry = 5 * time.Minute
+
+// sessionExpiry is the lifetime of a user's single sign-on session,
+// after which they must enter their credentials again.
+const sessionExpiry = 24 * time.Hour
+
+// User codes are short enough to be typed, so could be guessed by repeatedly
+// entering codes. Each user may only enter maxUserCodeAttempts invalid codes,
+// until userCodeAttemptWindow has passed since the first.
+const (
+	maxUserCodeAttempts   = 5
+	userCodeAttemptWindow = 15 * time.Minute
+)
+
+// formPostTemplate is an auto-submitting form, used by the form_post response
+// mode to post the authorization response to the client's redirect uri.
+var formPostTemplate = template.Must(template.New("form_post").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Submit This Form</title></head>
+<body>
+<form method="post" action="{{.RedirectUri}}">
+{{range $name, $values := .Params}}{{range $values}}<input type="hidden" name="{{$name}}" value="{{.}}"/>
+{{end}}{{end}}<noscript><button type="submit">Continue</button></noscript>
+</form>
+<script>document.forms[0].submit();</script>
+</body>
+</html>
+`))
+
+// Login errors, which are shown to the user by the login page.
 var (
-	errInvalidCredentials      = errors.New("email and/or password is invalid")
-	errUnsupportedResponseType = errors.New("unsupported response type")
+	errInvalidCredentials = errors.New("email and/or password is invalid")
+	errInvalidUserCode    = errors.New("invalid or expired user code")
+	errTooManyAttempts    = errors.New("too many invalid user codes, try again later")
+)
+
+// Authorization errors. Once the redirect uri has been validated, these are
+// returned to the client by redirecting to it, as defined by RFC 6749.
+var (
+	errInvalidClient           = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidRequest, "invalid client id")
+	errUnsupportedResponseType = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeUnsupportedResponseType, "unsupported response type")
+	errMissingNonce            = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidRequest, "missing nonce")
+	errInvalidPrompt           = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidRequest, "prompt none cannot be combined with other values")
+	errInvalidMaxAge           = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidRequest, "invalid max age")
+	errInvalidIDTokenHint      = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidRequest, "invalid id token hint")
+
+	// errLoginRequired is returned when the user must login, as they don't have
+	// a session which satisfies the request. The login page shows the form on
+	// receiving it, unless the client asked for no prompt.
+	errLoginRequired = util.NewOAuthError(http.StatusUnauthorized, util.ErrorCodeLoginRequired, "login required")
+
+	// errConsentRequired is returned when the user must consent to the request,
+	// in which case the login page shows the consent screen, unless the client
+	// asked for no prompt. errAccessDenied is returned if the user refuses.
+	errConsentRequired = util.NewOAuthError(http.StatusForbidden, util.ErrorCodeConsentRequired, "consent required")
+	errAccessDenied    = util.NewOAuthError(http.StatusForbidden, util.ErrorCodeAccessDenied, "the user denied the request")
 )
 
 func main() {
@@ -36,11 +94,16 @@ func main() {
 
 	hdlr := &Handler{
 		sess:      sess,
-		tokens:    token.New("goidc"),
+		tokens:    token.New(""), // The issuer is set per request.
 		clients:   dynamo.NewClientProvider(sess),
 		clientVal: validator.NewClientValidator(),
 		users:     dynamo.NewUserProvider(sess),
 		userVal:   validator.NewUserValidator(),
+		codes:     dynamo.NewAuthorizationCodeStore(sess),
+		devices:   dynamo.NewDeviceCodeStore(sess),
+		sessions:  dynamo.NewSessionStore(sess),
+		consents:  dynamo.NewConsentStore(sess),
+		attempts:  dynamo.NewAttemptStore(sess),
 	}
 
 	lambda.Start(hdlr.Handle)
@@ -54,6 +117,11 @@ type Handler struct {
 	userVal   validator.UserValidator
 	clients   dal.ClientProvider
 	clientVal validator.ClientValidator
+	codes     dal.AuthorizationCodeStore
+	devices   dal.DeviceCodeStore
+	sessions  dal.SessionStore
+	consents  dal.ConsentStore
+	attempts  dal.AttemptStore
 }
 
 // LoginModel represents the body of the login request.
@@ -62,16 +130,49 @@ type LoginModel struct {
 	RedirectUri  string   `json:"redirectUri"`
 	Scopes       []string `json:"scopes"`
 	ResponseType string   `json:"responseType"`
+	ResponseMode string   `json:"responseMode"`
 	State        string   `json:"state"`
 	Nonce        string   `json:"nonce"`
 
+	CodeChallenge       string `json:"codeChallenge"`
+	CodeChallengeMethod string `json:"codeChallengeMethod"`
+
+	// Prompt, MaxAge, LoginHint and IDTokenHint restrict which sessions can
+	// be used to authorize the request, as defined by OpenID Connect Core.
+	// MaxAge is the number of seconds since the user last authenticated,
+	// after which they must login again.
+	Prompt      string `json:"prompt"`
+	MaxAge      *int64 `json:"maxAge"`
+	LoginHint   string `json:"loginHint"`
+	IDTokenHint string `json:"idTokenHint"`
+
+	// Consent is the user's answer to the consent screen, which is shown when
+	// they must consent to the request. It is nil until they have answered.
+	Consent *bool `json:"consent"`
+
+	// UserCode is set when the user is approving a device authorization
+	// request, in which case the client parameters are not required.
+	UserCode string `json:"userCode"`
+
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
-// ResponseModel represents a successfull request's response body.
+// ResponseModel represents a successfull request's response body. The
+// redirect uri is empty when a device authorization request is approved.
+// Using the form_post response mode, the form is given instead, which the
+// login page renders to post the response to the client. If the user must
+// consent to the request, Consent is given instead, for the consent screen.
 type ResponseModel struct {
-	RedirectUri string `json:"redirectUri"`
+	RedirectUri string        `json:"redirectUri"`
+	Form        string        `json:"form,omitempty"`
+	Consent     *ConsentModel `json:"consent,omitempty"`
+}
+
+// ConsentModel describes the request the user is asked to consent to.
+type ConsentModel struct {
+	ClientName string   `json:"clientName"`
+	Scopes     []string `json:"scopes"`
 }
 
 func (h *Handler) Handle(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
@@ -82,89 +183,679 @@ func (h *Handler) Handle(ctx context.Context, req events.APIGatewayProxyRequest)
 
 	if strings.Index(util.Header(req, "Content-Type"), "application/json") == -1 {
 		err := errors.New("invalid content type")
-		return util.RespondBadRequest(err), nil
+		return util.RespondOAuthError(err), nil
 	}
 
 	var model LoginModel
 	util.ReadJSON(req, &model)
 
 	ctx = goidc.NewContext(ctx, &req)
+	c := *h
+	c.tokens = oauth.IssuerTokens(ctx, req, h.tokens)
+	h = &c
+
+	if model.UserCode != "" {
+		return h.deviceResponse(ctx, &model)
+	}
+
 	client, err := h.clients.Get(ctx, model.ClientID)
 	if err != nil {
 		if err == dal.ErrClientNotFound {
-			err = errors.New("invalid client id")
-			return util.RespondBadRequest(err), nil
+			return util.RespondOAuthError(errInvalidClient), nil
 		}
 
 		return util.RespondError(err), nil
 	}
 
+	model.ResponseType = oauth.NormalizeResponseType(model.ResponseType)
+	if model.ResponseMode == "" {
+		model.ResponseMode = oauth.DefaultResponseMode(model.ResponseType)
+	}
+
 	err = h.clientVal.ValidateLoginRequest(client, model.RedirectUri, model.Scopes)
 	if err != nil {
-		return util.RespondBadRequest(err), nil
+		// The client cannot be trusted to receive errors at an invalid redirect uri.
+		if err == validator.ErrMissingRedirectUri || err == validator.ErrInvalidRedirectUri {
+			return util.RespondOAuthError(err), nil
+		}
+
+		return authorizationError(&model, err)
 	}
 
-	user, err := h.users.GetByEmail(ctx, model.Email)
+	if !isSupportedResponseType(model.ResponseType) {
+		return authorizationError(&model, errUnsupportedResponseType)
+	}
+
+	err = h.clientVal.ValidateResponseType(client, model.ResponseType)
 	if err != nil {
-		if err == dal.ErrUserNotFound {
-			return util.RespondBadRequest(errInvalidCredentials), nil
+		return authorizationError(&model, err)
+	}
+
+	err = h.clientVal.ValidateResponseMode(model.ResponseType, model.ResponseMode)
+	if err != nil {
+		model.ResponseMode = oauth.DefaultResponseMode(model.ResponseType)
+		return authorizationError(&model, err)
+	}
+
+	// A nonce is required whenever tokens are returned from the authorization
+	// endpoint, so the client can detect replayed ID tokens.
+	if model.ResponseType != oauth.ResponseTypeCode && model.Nonce == "" {
+		return authorizationError(&model, errMissingNonce)
+	}
+
+	prompts := strings.Fields(model.Prompt)
+	if hasValue(prompts, oauth.PromptNone) && len(prompts) > 1 {
+		return authorizationError(&model, errInvalidPrompt)
+	}
+
+	if model.MaxAge != nil && *model.MaxAge < 0 {
+		return authorizationError(&model, errInvalidMaxAge)
+	}
+
+	// Users with an existing session are authorized without entering their
+	// credentials again. The login page tries this before showing the form.
+	if model.Email == "" && model.Password == "" {
+		return h.authorizeSession(ctx, req, client, &model)
+	}
+
+	user, err := h.authenticate(ctx, model.Email, model.Password)
+	if err != nil {
+		if err == errInvalidCredentials {
+			return util.RespondBadRequest(err), nil
 		}
 
 		return util.RespondError(err), nil
 	}
 
-	err = h.userVal.ValidatePassword(user, model.Password)
+	// The user consents before their session is created, so they're asked
+	// to login again if they leave the consent screen without answering.
+	err = h.ensureConsent(ctx, client, user, &model)
 	if err != nil {
-		if err == validator.ErrInvalidPassword {
-			return util.RespondBadRequest(errInvalidCredentials), nil
+		return consentError(client, &model, err)
+	}
+
+	sess, sessionCookie, err := h.createSession(ctx, client, user)
+	if err != nil {
+		return util.RespondError(err), nil
+	}
+
+	resp, err := h.authorize(ctx, client, user, &model, sess)
+	resp.Headers["Set-Cookie"] = sessionCookie
+
+	return resp, err
+}
+
+// authorizeSession authorizes the request in m for the user of the session in
+// req's session cookie. Without a session which satisfies the request, the
+// user must login, or the client is told so when it asked for no prompt.
+func (h *Handler) authorizeSession(ctx context.Context, req events.APIGatewayProxyRequest, c *dal.Client, m *LoginModel) (events.APIGatewayProxyResponse, error) {
+	user, sess, err := h.sessionUser(ctx, req, c, m)
+	if err != nil {
+		if err == errInvalidIDTokenHint {
+			return authorizationError(m, err)
+		}
+
+		if err == errLoginRequired {
+			// The login page must not be shown, so the client is told instead.
+			if hasValue(strings.Fields(m.Prompt), oauth.PromptNone) {
+				return authorizationError(m, err)
+			}
+
+			return util.RespondOAuthError(err), nil
 		}
 
 		return util.RespondError(err), nil
 	}
 
-	switch model.ResponseType {
-	case "id_token token":
-		return h.idTokenTokenResponse(ctx, client, user, &model)
+	err = h.ensureConsent(ctx, c, user, m)
+	if err != nil {
+		return consentError(c, m, err)
+	}
+
+	// The client is notified when the user logs out, as part of the session.
+	if !hasValue(sess.ClientIDs, c.ID) {
+		err = h.sessions.AddClient(ctx, sess.ID, c.ID)
+		if err != nil {
+			return util.RespondError(err), nil
+		}
+	}
+
+	return h.authorize(ctx, c, user, m, sess)
+}
+
+// sessionUser returns the session in req's session cookie, and its user, if the
+// session satisfies the prompt, max age and hints in m. Otherwise,
+// errLoginRequired is returned as the error.
+func (h *Handler) sessionUser(ctx context.Context, req events.APIGatewayProxyRequest, c *dal.Client, m *LoginModel) (*dal.User, *dal.Session, error) {
+	// Only one session is kept at a time, so the user
+	// selects an account by logging in again.
+	prompts := strings.Fields(m.Prompt)
+	if hasValue(prompts, oauth.PromptLogin) || hasValue(prompts, oauth.PromptSelectAccount) {
+		return nil, nil, errLoginRequired
+	}
+
+	var hintSubject string
+	if m.IDTokenHint != "" {
+		sub, err := h.idTokenHintSubject(ctx, c, m.IDTokenHint)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		hintSubject = sub
+	}
+
+	sess, err := h.currentSession(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if m.MaxAge != nil && util.Time().Unix()-sess.AuthTime > *m.MaxAge {
+		return nil, nil, errLoginRequired
+	}
+
+	user, err := h.users.Get(ctx, sess.UserID)
+	if err != nil {
+		if err == dal.ErrUserNotFound {
+			return nil, nil, errLoginRequired
+		}
+
+		return nil, nil, err
+	}
+
+	// The client may expect a particular user, who isn't the one logged in.
+	if m.LoginHint != "" && !strings.EqualFold(m.LoginHint, user.Email) {
+		return nil, nil, errLoginRequired
+	}
+
+	if hintSubject != "" && hintSubject != user.ID {
+		return nil, nil, errLoginRequired
+	}
+
+	return user, sess, nil
+}
+
+// ensureConsent determines whether u has consented to c being authorized with
+// the scopes in m. Users must consent to third-party clients, or whenever the
+// client asks for them to be prompted, in which case errConsentRequired is
+// returned until they have answered the consent screen. Once they have, their
+// consent is recorded, so they're not asked again for the same scopes.
+func (h *Handler) ensureConsent(ctx context.Context, c *dal.Client, u *dal.User, m *LoginModel) error {
+	if m.Consent != nil {
+		if !*m.Consent {
+			return errAccessDenied
+		}
+
+		return h.saveConsent(ctx, c, u, m)
+	}
+
+	if hasValue(strings.Fields(m.Prompt), oauth.PromptConsent) {
+		return errConsentRequired
+	}
+
+	if !c.ThirdParty {
+		return nil
+	}
+
+	consent, err := h.consents.Get(ctx, u.ID, c.ID)
+	if err != nil {
+		if err == dal.ErrConsentNotFound {
+			return errConsentRequired
+		}
+
+		return err
+	}
+
+	if !hasValues(consent.Scopes, m.Scopes) {
+		return errConsentRequired
+	}
+
+	return nil
+}
+
+// saveConsent records that u has consented to c being authorized with the
+// scopes in m, in addition to those they have already consented to.
+func (h *Handler) saveConsent(ctx context.Context, c *dal.Client, u *dal.User, m *LoginModel) error {
+	consent := &dal.Consent{
+		UserID:   u.ID,
+		ClientID: c.ID,
+		Granted:  util.Time().Unix(),
+	}
+
+	existing, err := h.consents.Get(ctx, u.ID, c.ID)
+	if err != nil && err != dal.ErrConsentNotFound {
+		return err
+	}
+
+	if existing != nil {
+		consent.Scopes = existing.Scopes
+	}
+
+	for _, scope := range m.Scopes {
+		if !hasValue(consent.Scopes, scope) {
+			consent.Scopes = append(consent.Scopes, scope)
+		}
+	}
+
+	return h.consents.Save(ctx, consent)
+}
+
+// consentError returns the response for err, returned by ensureConsent. If the
+// user must consent, the login page is told to show the consent screen, unless
+// the client asked for no prompt, in which case the client is told instead.
+func consentError(c *dal.Client, m *LoginModel, err error) (events.APIGatewayProxyResponse, error) {
+	switch err {
+	case errConsentRequired:
+		if hasValue(strings.Fields(m.Prompt), oauth.PromptNone) {
+			return authorizationError(m, err)
+		}
+
+		return util.Respond(http.StatusOK, ResponseModel{
+			Consent: &ConsentModel{
+				ClientName: c.Name,
+				Scopes:     m.Scopes,
+			},
+		}), nil
+	case errAccessDenied:
+		return authorizationError(m, err)
 	default:
-		return util.RespondBadRequest(errUnsupportedResponseType), nil
+		return util.RespondError(err), nil
 	}
 }
 
-func (h *Handler) idTokenTokenResponse(ctx context.Context, c *dal.Client, u *dal.User, m *LoginModel) (events.APIGatewayProxyResponse, error) {
-	alg, _ := kms.New(h.sess, goidc.StageVariable(ctx, "JWT_KEY_ID"), kms.RSA_PKCS1_S256)
-	jwt, err := h.generateAccessToken(alg, u.Email)
+// idTokenHintSubject returns the subject of hint, which must be an ID token
+// issued to c. The hint is accepted after it has expired, as the client may
+// have held on to it since the user last logged in.
+func (h *Handler) idTokenHintSubject(ctx context.Context, c *dal.Client, hint string) (string, error) {
+	alg, _ := token.NewKMSAlgorithm(h.sess, goidc.StageVariable(ctx, "JWT_KEY_ID"))
+	claims, err := h.tokens.VerifyTokenIgnoringExpiry(alg, hint, c.ID)
 	if err != nil {
+		log.Printf("Invalid id token hint: %v\n", err)
+		return "", errInvalidIDTokenHint
+	}
+
+	sub, ok := claims.String("sub")
+	if !ok || sub == "" {
+		return "", errInvalidIDTokenHint
+	}
+
+	return sub, nil
+}
+
+// currentSession returns the session from req's session cookie. If there
+// is no cookie, or the session is invalid or has expired, errLoginRequired
+// will be returned as the error.
+func (h *Handler) currentSession(ctx context.Context, req events.APIGatewayProxyRequest) (*dal.Session, error) {
+	value := util.Cookie(req, cookie.SessionName)
+	if value == "" {
+		return nil, errLoginRequired
+	}
+
+	codec, err := cookie.NewSessionCodec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := codec.Decode(cookie.SessionName, value)
+	if err != nil {
+		return nil, errLoginRequired
+	}
+
+	sess, err := h.sessions.Get(ctx, id)
+	if err != nil {
+		if err == dal.ErrSessionNotFound {
+			return nil, errLoginRequired
+		}
+
+		return nil, err
+	}
+
+	// Expired sessions may not have been removed by the table's TTL yet.
+	if sess.Expires <= util.Time().Unix() {
+		return nil, errLoginRequired
+	}
+
+	return sess, nil
+}
+
+// createSession starts a new session for u, who has just logged in to be
+// authorized by c, returning the session and the Set-Cookie header value
+// for the session cookie.
+func (h *Handler) createSession(ctx context.Context, c *dal.Client, u *dal.User) (*dal.Session, string, error) {
+	codec, err := cookie.NewSessionCodec(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sess := &dal.Session{
+		ID:        util.RandomString(32),
+		UserID:    u.ID,
+		AuthTime:  util.Time().Unix(),
+		Expires:   util.Time().Add(sessionExpiry).Unix(),
+		ClientIDs: []string{c.ID},
+	}
+
+	err = h.sessions.Create(ctx, sess)
+	if err != nil {
+		return nil, "", err
+	}
+
+	value, err := codec.Encode(cookie.SessionName, sess.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sessionCookie := &http.Cookie{
+		Name:     cookie.SessionName,
+		Value:    value,
+		Path:     "/",
+		Expires:  time.Unix(sess.Expires, 0),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	}
+
+	return sess, sessionCookie.String(), nil
+}
+
+// isSupportedResponseType determines whether the normalized
+// responseType is supported by the authorization endpoint.
+func isSupportedResponseType(responseType string) bool {
+	for _, rt := range oauth.ResponseTypes {
+		if rt == responseType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// authenticate returns the user with the given email, if the password is
+// correct. Otherwise, errInvalidCredentials is returned as the error.
+func (h *Handler) authenticate(ctx context.Context, email, password string) (*dal.User, error) {
+	user, err := h.users.GetByEmail(ctx, email)
+	if err != nil {
+		if err == dal.ErrUserNotFound {
+			return nil, errInvalidCredentials
+		}
+
+		return nil, err
+	}
+
+	err = h.userVal.ValidatePassword(user, password)
+	if err != nil {
+		if err == validator.ErrInvalidPassword {
+			return nil, errInvalidCredentials
+		}
+
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// deviceResponse approves the device authorization request with the user code
+// in m, once the user has logged in. The device is then able to exchange its
+// device code for tokens, so there is nowhere to redirect the user.
+func (h *Handler) deviceResponse(ctx context.Context, m *LoginModel) (events.APIGatewayProxyResponse, error) {
+	user, err := h.authenticate(ctx, m.Email, m.Password)
+	if err != nil {
+		if err == errInvalidCredentials {
+			return util.RespondBadRequest(err), nil
+		}
+
+		return util.RespondError(err), nil
+	}
+
+	// The user is authenticated before the code is looked up, so that codes
+	// can't be probed anonymously, then limited to a number of invalid codes.
+	attemptKey := "user-code/" + user.ID
+	attempts, err := h.attempts.Count(ctx, attemptKey)
+	if err != nil {
+		return util.RespondError(err), nil
+	}
+
+	if attempts >= maxUserCodeAttempts {
+		return util.Respond(http.StatusTooManyRequests, util.Error{Error: errTooManyAttempts.Error()}), nil
+	}
+
+	code, err := h.devices.GetByUserCode(ctx, util.NormalizeUserCode(m.UserCode))
+	if err != nil && err != dal.ErrDeviceCodeNotFound {
+		return util.RespondError(err), nil
+	}
+
+	if err == dal.ErrDeviceCodeNotFound || code.Expires <= util.Time().Unix() {
+		expires := util.Time().Add(userCodeAttemptWindow).Unix()
+		err = h.attempts.Fail(ctx, attemptKey, expires)
+		if err != nil {
+			return util.RespondError(err), nil
+		}
+
+		return util.RespondBadRequest(errInvalidUserCode), nil
+	}
+
+	client, err := h.clients.Get(ctx, code.ClientID)
+	if err != nil {
+		if err == dal.ErrClientNotFound {
+			return util.RespondBadRequest(errInvalidUserCode), nil
+		}
+
 		return util.RespondError(err), nil
 	}
 
-	idToken, err := h.generateIdToken(alg, u.ID, m.State, &jwt.AccessToken)
+	// The user consents to the scopes the device requested, which it
+	// can't prompt for, in the same way as for any other request.
+	m.Scopes = code.Scopes
+	m.Prompt = ""
+	err = h.ensureConsent(ctx, client, user, m)
 	if err != nil {
+		// Devices have no redirect uri to return a denial to, so the login
+		// page is told instead, and the device code is left to expire.
+		if err == errAccessDenied {
+			return util.RespondOAuthError(err), nil
+		}
+
+		return consentError(client, m, err)
+	}
+
+	err = h.devices.Authorize(ctx, code.DeviceCode, user.ID)
+	if err != nil {
+		if err == dal.ErrDeviceCodeNotFound {
+			return util.RespondBadRequest(errInvalidUserCode), nil
+		}
+
 		return util.RespondError(err), nil
 	}
 
-	urlValues := url.Values{
-		"id_token":     {idToken},
-		"state":        {m.State},
-		"nonce":        {m.Nonce},
-		"access_token": {jwt.AccessToken},
-		"token_type":   {jwt.TokenType},
-		"expires_in":   {strconv.Itoa(int(jwt.Expires))},
+	return util.Respond(http.StatusOK, ResponseModel{}), nil
+}
+
+// authorize issues an authorization code and/or tokens to c, depending on the
+// response type in m, which may be any combination of "code", "id_token" and
+// "token", as defined by OpenID Connect Core. The user authenticated at the
+// start of sess, which is given to the client as the ID token's "sid" claim.
+func (h *Handler) authorize(ctx context.Context, c *dal.Client, u *dal.User, m *LoginModel, sess *dal.Session) (events.APIGatewayProxyResponse, error) {
+	params := url.Values{"state": {m.State}}
+	values := strings.Fields(m.ResponseType)
+
+	var code, accessToken string
+	if hasValue(values, oauth.ResponseTypeCode) {
+		err := h.clientVal.ValidateCodeChallenge(c, m.CodeChallenge, m.CodeChallengeMethod)
+		if err != nil {
+			return authorizationError(m, err)
+		}
+
+		code, err = h.createCode(ctx, c, u, m, sess)
+		if err != nil {
+			return util.RespondError(err), nil
+		}
+
+		params.Set("code", code)
 	}
 
-	redirectUri := fmt.Sprintf("%s?%s", m.RedirectUri, urlValues.Encode())
-	resp := ResponseModel{RedirectUri: redirectUri}
+	// Only the code can be returned without signing any tokens.
+	if m.ResponseType == oauth.ResponseTypeCode {
+		return authorizationResponse(m, params)
+	}
+
+	alg, _ := token.NewKMSAlgorithm(h.sess, goidc.StageVariable(ctx, "JWT_KEY_ID"))
+
+	if hasValue(values, oauth.ResponseTypeToken) {
+		jwt, err := h.generateAccessToken(alg, c, u, m.Scopes)
+		if err != nil {
+			return util.RespondError(err), nil
+		}
+
+		accessToken = jwt.AccessToken
+		params.Set("access_token", jwt.AccessToken)
+		params.Set("token_type", jwt.TokenType)
+		params.Set("expires_in", strconv.Itoa(int(jwt.Expires)))
+	}
+
+	if hasValue(values, oauth.ResponseTypeIDToken) {
+		idToken, err := h.generateIdToken(alg, c, u.ID, m, sess, accessToken, code)
+		if err != nil {
+			return util.RespondError(err), nil
+		}
+
+		params.Set("id_token", idToken)
+	}
+
+	return authorizationResponse(m, params)
+}
+
+// hasValue determines whether values contains value.
+func hasValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasValues determines whether values contains every one of required.
+func hasValues(values, required []string) bool {
+	for _, v := range required {
+		if !hasValue(values, v) {
+			return false
+		}
+	}
 
-	return util.Respond(http.StatusOK, resp), nil
+	return true
 }
 
-func (h *Handler) generateIdToken(alg gojwt.Algorithm, sub, state string, accessToken *string) (string, error) {
+// createCode stores a new authorization code for u, returning the code.
+func (h *Handler) createCode(ctx context.Context, c *dal.Client, u *dal.User, m *LoginModel, sess *dal.Session) (string, error) {
+	method := m.CodeChallengeMethod
+	if m.CodeChallenge != "" && method == "" {
+		method = oauth.CodeChallengeMethodPlain
+	}
+
+	code := &dal.AuthorizationCode{
+		Code:        util.RandomString(32),
+		ClientID:    c.ID,
+		UserID:      u.ID,
+		RedirectUri: m.RedirectUri,
+		Scopes:      m.Scopes,
+		Nonce:       m.Nonce,
+		AuthTime:    sess.AuthTime,
+		SessionID:   sess.ID,
+		Expires:     util.Time().Add(codeExpiry).Unix(),
+
+		CodeChallenge:       m.CodeChallenge,
+		CodeChallengeMethod: method,
+	}
+
+	err := h.codes.Create(ctx, code)
+	if err != nil {
+		return "", err
+	}
+
+	return code.Code, nil
+}
+
+// authorizationResponse returns params to the client's redirect uri, using the
+// response mode in m. Using the query and fragment modes, the login page
+// redirects to the returned uri, otherwise it renders the returned form.
+func authorizationResponse(m *LoginModel, params url.Values) (events.APIGatewayProxyResponse, error) {
+	switch m.ResponseMode {
+	case oauth.ResponseModeFormPost:
+		var buf bytes.Buffer
+		err := formPostTemplate.Execute(&buf, struct {
+			RedirectUri string
+			Params      url.Values
+		}{m.RedirectUri, params})
+		if err != nil {
+			return util.RespondError(err), nil
+		}
+
+		return util.Respond(http.StatusOK, ResponseModel{Form: buf.String()}), nil
+	case oauth.ResponseModeFragment:
+		return util.Respond(http.StatusOK, ResponseModel{
+			RedirectUri: m.RedirectUri + "#" + params.Encode(),
+		}), nil
+	default:
+		// The redirect uri may already have a query component, which must be kept.
+		separator := "?"
+		if strings.Contains(m.RedirectUri, "?") {
+			separator = "&"
+		}
+
+		return util.Respond(http.StatusOK, ResponseModel{
+			RedirectUri: m.RedirectUri + separator + params.Encode(),
+		}), nil
+	}
+}
+
+// authorizationError returns err to the client's redirect uri, along with the
+// state, as defined by RFC 6749 section 4.1.2.1. This must only be used once
+// the redirect uri has been validated.
+func authorizationError(m *LoginModel, err error) (events.APIGatewayProxyResponse, error) {
+	params := util.AsOAuthError(err).Params()
+	if m.State != "" {
+		params.Set("state", m.State)
+	}
+
+	return authorizationResponse(m, params)
+}
+
+// generateIdToken returns an ID token for sub, issued to c. The at_hash and
+// c_hash claims are only included when an access token or code is issued
+// alongside it, and s_hash when the client gave a state.
+func (h *Handler) generateIdToken(alg gojwt.Algorithm, c *dal.Client, sub string, m *LoginModel, sess *dal.Session, accessToken, code string) (string, error) {
 	claims := map[string]interface{}{
-		"sub":     sub,
-		"s_hash":  util.Sha256Half(state),
-		"at_hash": util.Sha256Half(*accessToken),
+		"sub":       sub,
+		"azp":       c.ID,
+		"auth_time": sess.AuthTime,
+		"sid":       sess.ID,
+		"amr":       []string{oauth.AuthenticationMethodPassword},
+	}
+
+	if m.Nonce != "" {
+		claims["nonce"] = m.Nonce
+	}
+
+	hashes := map[string]string{
+		"at_hash": accessToken,
+		"c_hash":  code,
+		"s_hash":  m.State,
+	}
+
+	for claim, value := range hashes {
+		if value == "" {
+			continue
+		}
+
+		hash, err := token.Hash(alg, value)
+		if err != nil {
+			return "", err
+		}
+
+		claims[claim] = hash
 	}
 
-	jwt, err := h.tokens.GenerateToken(alg, claims, 36000, "goidc")
+	jwt, err := h.tokens.GenerateToken(alg, claims, 36000, c.ID)
 	if err != nil {
 		return "", err
 	}
@@ -172,9 +863,11 @@ func (h *Handler) generateIdToken(alg gojwt.Algorithm, sub, state string, access
 	return jwt.AccessToken, nil
 }
 
-func (h *Handler) generateAccessToken(alg gojwt.Algorithm, sub string) (*token.Token, error) {
+func (h *Handler) generateAccessToken(alg gojwt.Algorithm, c *dal.Client, u *dal.User, scopes []string) (*token.Token, error) {
 	claims := map[string]interface{}{
-		"sub": sub,
+		"sub":       u.ID,
+		"client_id": c.ID,
+		"scopes":    scopes,
 	}
 
 	return h.tokens.GenerateToken(alg, claims, 3600, "goidc")
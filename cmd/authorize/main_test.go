@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,16 +10,21 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-sdk-go/awstesting/mock"
 	"github.com/golang/mock/gomock"
+	"github.com/reecerussell/gojwt"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/reecerussell/goidc/cookie"
 	"github.com/reecerussell/goidc/dal"
 	dalMock "github.com/reecerussell/goidc/dal/mock"
+	"github.com/reecerussell/goidc/oauth"
 	"github.com/reecerussell/goidc/token"
 	tokenMock "github.com/reecerussell/goidc/token/mock"
+	"github.com/reecerussell/goidc/util"
 	"github.com/reecerussell/goidc/validator"
 	valMock "github.com/reecerussell/goidc/validator/mock"
 )
@@ -47,13 +53,20 @@ func TestHandler_GivenIdTokenAndTokenTypes_ReturnsRedirectWithTokens(t *testing.
 
 	mockClientValidator := valMock.NewMockClientValidator(ctrl)
 	mockClientValidator.EXPECT().ValidateLoginRequest(testClient, testRedirectUri, testScopes).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseType(gomock.Any(), gomock.Any()).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseMode("id_token token", "fragment").Return(nil)
 
 	mockUserValidator := valMock.NewMockUserValidator(ctrl)
 	mockUserValidator.EXPECT().ValidatePassword(testUser, testPassword).Return(nil)
 
-	mockTokenService := tokenMock.NewMockService(ctrl)
-	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-		Return(&token.Token{AccessToken: testAccessToken, TokenType: "Bearer", Expires: 3600}, nil).Times(1)
+	mockTokenService := tokenMock.NewIssuerService(ctrl, "https://example.com/prod")
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), int64(3600), "goidc").
+		DoAndReturn(func(_ interface{}, claims map[string]interface{}, _ int64, _ string) (*token.Token, error) {
+			assert.Equal(t, testUser.ID, claims["sub"])
+			assert.Equal(t, testScopes, claims["scopes"])
+
+			return &token.Token{AccessToken: testAccessToken, TokenType: "Bearer", Expires: 3600}, nil
+		}).Times(1)
 	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(&token.Token{AccessToken: testIdToken}, nil)
 
@@ -64,15 +77,21 @@ func TestHandler_GivenIdTokenAndTokenTypes_ReturnsRedirectWithTokens(t *testing.
 		tokens:    mockTokenService,
 		clients:   mockClientProvider,
 		clientVal: mockClientValidator,
+		sessions:  buildSessionStore(ctrl),
 	}
 
 	req := events.APIGatewayProxyRequest{
 		HTTPMethod: http.MethodPost,
 		Headers: map[string]string{
 			"Content-Type": "application/json",
+			"Host":         "example.com",
+		},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Stage: "prod",
 		},
 		StageVariables: map[string]string{
-			"JWT_KEY_ID": "key id",
+			"SESSION_KEY": testSessionKey,
+			"JWT_KEY_ID":  "key id",
 		},
 		Body: fmt.Sprintf(`{
 			"clientId": "%s",
@@ -102,7 +121,9 @@ func TestHandler_GivenIdTokenAndTokenTypes_ReturnsRedirectWithTokens(t *testing.
 		t.Errorf("redirect uri should be '%s'", testRedirectUri)
 	}
 
-	queryValues := redirectUri.Query()
+	// Tokens must be returned in the fragment, rather than the query.
+	assert.Equal(t, "", redirectUri.RawQuery)
+	queryValues, _ := url.ParseQuery(redirectUri.Fragment)
 
 	assert.Equal(t, testIdToken, queryValues.Get("id_token"))
 	assert.Equal(t, testAccessToken, queryValues.Get("access_token"))
@@ -116,7 +137,7 @@ func TestHandler_GivenInvalidHTTPMethod_ReturnsMethodNotAllowed(t *testing.T) {
 
 	mockUserProvider := dalMock.NewMockUserProvider(ctrl)
 	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
-	mockTokenService := tokenMock.NewMockService(ctrl)
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
 
 	handler := &Handler{
 		sess:    mock.Session,
@@ -153,7 +174,7 @@ func TestHandler_GivenInvalidContentType_ReturnsBadRequest(t *testing.T) {
 
 	mockUserProvider := dalMock.NewMockUserProvider(ctrl)
 	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
-	mockTokenService := tokenMock.NewMockService(ctrl)
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
 
 	handler := &Handler{
 		sess:    mock.Session,
@@ -181,7 +202,8 @@ func TestHandler_GivenInvalidContentType_ReturnsBadRequest(t *testing.T) {
 	var data map[string]interface{}
 	json.Unmarshal([]byte(resp.Body), &data)
 
-	assert.Equal(t, "invalid content type", data["error"])
+	assert.Equal(t, "invalid_request", data["error"])
+	assert.Equal(t, "invalid content type", data["error_description"])
 }
 
 func TestHandler_GivenInvalidClient_ReturnsBadRequest(t *testing.T) {
@@ -194,7 +216,7 @@ func TestHandler_GivenInvalidClient_ReturnsBadRequest(t *testing.T) {
 	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
 	mockClientProvider.EXPECT().Get(gomock.Any(), testClientId).Return(nil, dal.ErrClientNotFound)
 
-	mockTokenService := tokenMock.NewMockService(ctrl)
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
 
 	handler := &Handler{
 		sess:    mock.Session,
@@ -225,7 +247,8 @@ func TestHandler_GivenInvalidClient_ReturnsBadRequest(t *testing.T) {
 	var data map[string]interface{}
 	json.Unmarshal([]byte(resp.Body), &data)
 
-	assert.Equal(t, "invalid client id", data["error"])
+	assert.Equal(t, "invalid_request", data["error"])
+	assert.Equal(t, "invalid client id", data["error_description"])
 }
 
 func TestHandler_WhereClientProviderFails_ReturnsInternalServerError(t *testing.T) {
@@ -239,7 +262,7 @@ func TestHandler_WhereClientProviderFails_ReturnsInternalServerError(t *testing.
 	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
 	mockClientProvider.EXPECT().Get(gomock.Any(), testClientId).Return(nil, testError)
 
-	mockTokenService := tokenMock.NewMockService(ctrl)
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
 
 	handler := &Handler{
 		sess:    mock.Session,
@@ -273,7 +296,7 @@ func TestHandler_WhereClientProviderFails_ReturnsInternalServerError(t *testing.
 	assert.Equal(t, testError.Error(), data["error"])
 }
 
-func TestHandler_WhereClientInfoIsInvalid_ReturnBadRequest(t *testing.T) {
+func TestHandler_WhereClientInfoIsInvalid_RedirectsWithError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -281,16 +304,15 @@ func TestHandler_WhereClientInfoIsInvalid_ReturnBadRequest(t *testing.T) {
 	testRedirectUri := "http://localhost:8080"
 	testScopes := []string{"openid", "test"}
 	testClient := &dal.Client{}
-	testError := errors.New("test error")
 
 	mockUserProvider := dalMock.NewMockUserProvider(ctrl)
 	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
 	mockClientProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
 
 	mockValidator := valMock.NewMockClientValidator(ctrl)
-	mockValidator.EXPECT().ValidateLoginRequest(testClient, testRedirectUri, testScopes).Return(testError)
+	mockValidator.EXPECT().ValidateLoginRequest(testClient, testRedirectUri, testScopes).Return(validator.ErrInvalidScope)
 
-	mockTokenService := tokenMock.NewMockService(ctrl)
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
 
 	handler := &Handler{
 		sess:      mock.Session,
@@ -311,20 +333,53 @@ func TestHandler_WhereClientInfoIsInvalid_ReturnBadRequest(t *testing.T) {
 		Body: fmt.Sprintf(`{
 			"clientId": "%s",
 			"redirectUri": "%s",
-			"scopes": ["%s"]
+			"scopes": ["%s"],
+			"responseType": "code",
+			"state": "my state"
 		}`, testClientId, testRedirectUri, strings.Join(testScopes, `","`)),
 	}
 
 	ctx := context.Background()
 	resp, err := handler.Handle(ctx, req)
 	assert.NoError(t, err)
-	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
 	assert.False(t, resp.IsBase64Encoded)
 
+	// Errors are returned to the client, once the redirect uri is validated.
+	values := readQuery(t, resp)
+	assert.Equal(t, "invalid_scope", values.Get("error"))
+	assert.Equal(t, "invalid scope", values.Get("error_description"))
+	assert.Equal(t, "my state", values.Get("state"))
+}
+
+func TestHandler_GivenInvalidRedirectUri_ReturnsBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testClient := &dal.Client{ID: "23493234"}
+
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockClientValidator := valMock.NewMockClientValidator(ctrl)
+	mockClientValidator.EXPECT().ValidateLoginRequest(gomock.Any(), gomock.Any(), gomock.Any()).Return(validator.ErrInvalidRedirectUri)
+
+	h := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockClientProvider,
+		clientVal: mockClientValidator,
+	}
+
+	resp, err := h.Handle(context.Background(), buildHybridRequest("code"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// Errors must not be sent to an unregistered redirect uri.
 	var data map[string]interface{}
 	json.Unmarshal([]byte(resp.Body), &data)
-
-	assert.Equal(t, testError.Error(), data["error"])
+	assert.Equal(t, "invalid_request", data["error"])
+	assert.Equal(t, "invalid redirect uri", data["error_description"])
+	assert.NotContains(t, data, "redirectUri")
 }
 
 func TestHandler_GivenInvalidEmail_ReturnBadRequest(t *testing.T) {
@@ -345,8 +400,10 @@ func TestHandler_GivenInvalidEmail_ReturnBadRequest(t *testing.T) {
 
 	mockValidator := valMock.NewMockClientValidator(ctrl)
 	mockValidator.EXPECT().ValidateLoginRequest(testClient, testRedirectUri, testScopes).Return(nil)
+	mockValidator.EXPECT().ValidateResponseType(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateResponseMode(gomock.Any(), gomock.Any()).Return(nil)
 
-	mockTokenService := tokenMock.NewMockService(ctrl)
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
 
 	handler := &Handler{
 		sess:      mock.Session,
@@ -368,6 +425,7 @@ func TestHandler_GivenInvalidEmail_ReturnBadRequest(t *testing.T) {
 			"clientId": "%s",
 			"redirectUri": "%s",
 			"scopes": ["%s"],
+			"responseType": "code",
 			"email": "%s"
 		}`, testClientId, testRedirectUri, strings.Join(testScopes, `","`), testEmail),
 	}
@@ -403,8 +461,10 @@ func TestHandler_WhereUserProviderFails_ReturnsInternalServerError(t *testing.T)
 
 	mockValidator := valMock.NewMockClientValidator(ctrl)
 	mockValidator.EXPECT().ValidateLoginRequest(testClient, testRedirectUri, testScopes).Return(nil)
+	mockValidator.EXPECT().ValidateResponseType(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateResponseMode(gomock.Any(), gomock.Any()).Return(nil)
 
-	mockTokenService := tokenMock.NewMockService(ctrl)
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
 
 	handler := &Handler{
 		sess:      mock.Session,
@@ -426,6 +486,7 @@ func TestHandler_WhereUserProviderFails_ReturnsInternalServerError(t *testing.T)
 			"clientId": "%s",
 			"redirectUri": "%s",
 			"scopes": ["%s"],
+			"responseType": "code",
 			"email": "%s"
 		}`, testClientId, testRedirectUri, strings.Join(testScopes, `","`), testEmail),
 	}
@@ -462,11 +523,13 @@ func TestHandler_GivenInvalidPassword_ReturnBadRequest(t *testing.T) {
 
 	mockClientValidator := valMock.NewMockClientValidator(ctrl)
 	mockClientValidator.EXPECT().ValidateLoginRequest(testClient, testRedirectUri, testScopes).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseType(gomock.Any(), gomock.Any()).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseMode(gomock.Any(), gomock.Any()).Return(nil)
 
 	mockUserValidator := valMock.NewMockUserValidator(ctrl)
 	mockUserValidator.EXPECT().ValidatePassword(testUser, testPassword).Return(validator.ErrInvalidPassword)
 
-	mockTokenService := tokenMock.NewMockService(ctrl)
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
 
 	handler := &Handler{
 		sess:      mock.Session,
@@ -489,6 +552,7 @@ func TestHandler_GivenInvalidPassword_ReturnBadRequest(t *testing.T) {
 			"clientId": "%s",
 			"redirectUri": "%s",
 			"scopes": ["%s"],
+			"responseType": "code",
 			"email": "%s",
 			"password": "%s"
 		}`, testClientId, testRedirectUri, strings.Join(testScopes, `","`), testEmail, testPassword),
@@ -527,11 +591,13 @@ func TestHandler_WherePasswordValidationFails_ReturnInternalServerError(t *testi
 
 	mockClientValidator := valMock.NewMockClientValidator(ctrl)
 	mockClientValidator.EXPECT().ValidateLoginRequest(testClient, testRedirectUri, testScopes).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseType(gomock.Any(), gomock.Any()).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseMode(gomock.Any(), gomock.Any()).Return(nil)
 
 	mockUserValidator := valMock.NewMockUserValidator(ctrl)
 	mockUserValidator.EXPECT().ValidatePassword(testUser, testPassword).Return(testError)
 
-	mockTokenService := tokenMock.NewMockService(ctrl)
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
 
 	handler := &Handler{
 		sess:      mock.Session,
@@ -554,6 +620,7 @@ func TestHandler_WherePasswordValidationFails_ReturnInternalServerError(t *testi
 			"clientId": "%s",
 			"redirectUri": "%s",
 			"scopes": ["%s"],
+			"responseType": "code",
 			"email": "%s",
 			"password": "%s"
 		}`, testClientId, testRedirectUri, strings.Join(testScopes, `","`), testEmail, testPassword),
@@ -595,11 +662,13 @@ func TestHandler_WhereIdTokenFails_ReturnInternalServerError(t *testing.T) {
 
 	mockClientValidator := valMock.NewMockClientValidator(ctrl)
 	mockClientValidator.EXPECT().ValidateLoginRequest(testClient, testRedirectUri, testScopes).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseType(gomock.Any(), gomock.Any()).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseMode(gomock.Any(), gomock.Any()).Return(nil)
 
 	mockUserValidator := valMock.NewMockUserValidator(ctrl)
 	mockUserValidator.EXPECT().ValidatePassword(testUser, testPassword).Return(nil)
 
-	mockTokenService := tokenMock.NewMockService(ctrl)
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
 	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(&token.Token{AccessToken: testAccessToken, TokenType: "Bearer", Expires: 3600}, nil).Times(1)
 	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
@@ -612,6 +681,7 @@ func TestHandler_WhereIdTokenFails_ReturnInternalServerError(t *testing.T) {
 		tokens:    mockTokenService,
 		clients:   mockClientProvider,
 		clientVal: mockClientValidator,
+		sessions:  buildSessionStore(ctrl),
 	}
 
 	req := events.APIGatewayProxyRequest{
@@ -620,7 +690,8 @@ func TestHandler_WhereIdTokenFails_ReturnInternalServerError(t *testing.T) {
 			"Content-Type": "application/json",
 		},
 		StageVariables: map[string]string{
-			"JWT_KEY_ID": "key id",
+			"SESSION_KEY": testSessionKey,
+			"JWT_KEY_ID":  "key id",
 		},
 		Body: fmt.Sprintf(`{
 			"clientId": "%s",
@@ -669,11 +740,13 @@ func TestHandler_WhereAccessTokenFails_ReturnInternalServerError(t *testing.T) {
 
 	mockClientValidator := valMock.NewMockClientValidator(ctrl)
 	mockClientValidator.EXPECT().ValidateLoginRequest(testClient, testRedirectUri, testScopes).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseType(gomock.Any(), gomock.Any()).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseMode(gomock.Any(), gomock.Any()).Return(nil)
 
 	mockUserValidator := valMock.NewMockUserValidator(ctrl)
 	mockUserValidator.EXPECT().ValidatePassword(testUser, testPassword).Return(nil)
 
-	mockTokenService := tokenMock.NewMockService(ctrl)
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
 	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(nil, testError).Times(1)
 
@@ -684,6 +757,7 @@ func TestHandler_WhereAccessTokenFails_ReturnInternalServerError(t *testing.T) {
 		tokens:    mockTokenService,
 		clients:   mockClientProvider,
 		clientVal: mockClientValidator,
+		sessions:  buildSessionStore(ctrl),
 	}
 
 	req := events.APIGatewayProxyRequest{
@@ -692,7 +766,8 @@ func TestHandler_WhereAccessTokenFails_ReturnInternalServerError(t *testing.T) {
 			"Content-Type": "application/json",
 		},
 		StageVariables: map[string]string{
-			"JWT_KEY_ID": "key id",
+			"SESSION_KEY": testSessionKey,
+			"JWT_KEY_ID":  "key id",
 		},
 		Body: fmt.Sprintf(`{
 			"clientId": "%s",
@@ -718,7 +793,7 @@ func TestHandler_WhereAccessTokenFails_ReturnInternalServerError(t *testing.T) {
 	assert.Equal(t, testError.Error(), data["error"])
 }
 
-func TestHandler_GivenUnsupportedResponseType_ReturnBadRequest(t *testing.T) {
+func TestHandler_GivenUnsupportedResponseType_RedirectsWithError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -730,8 +805,69 @@ func TestHandler_GivenUnsupportedResponseType_ReturnBadRequest(t *testing.T) {
 	testState := "2374923740234"
 	testNonce := "2304820340lskfle"
 	testClient := &dal.Client{}
+
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockClientValidator := valMock.NewMockClientValidator(ctrl)
+	mockClientValidator.EXPECT().ValidateLoginRequest(testClient, testRedirectUri, testScopes).Return(nil)
+
+	// The response type is rejected before the user is authenticated.
+
+	handler := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		sess:      mock.Session,
+		clients:   mockClientProvider,
+		clientVal: mockClientValidator,
+	}
+
+	req := events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		StageVariables: map[string]string{
+			"JWT_KEY_ID": "key id",
+		},
+		Body: fmt.Sprintf(`{
+			"clientId": "%s",
+			"redirectUri": "%s",
+			"scopes": ["%s"],
+			"email": "%s",
+			"password": "%s",
+			"responseType": "nnot a supported response type",
+			"state": "%s",
+			"nonce": "%s"
+		}`, testClientId, testRedirectUri, strings.Join(testScopes, `","`), testEmail, testPassword, testState, testNonce),
+	}
+
+	ctx := context.Background()
+	resp, err := handler.Handle(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.False(t, resp.IsBase64Encoded)
+
+	values := readFragment(t, resp)
+	assert.Equal(t, "unsupported_response_type", values.Get("error"))
+	assert.Equal(t, testState, values.Get("state"))
+}
+
+func TestHandler_GivenCodeResponseType_ReturnsRedirectWithCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testClientId := "23493234"
+	testRedirectUri := "http://localhost:8080"
+	testScopes := []string{"openid", "test"}
+	testEmail := "my@email.com"
+	testPassword := "myPassword1"
+	testState := "2374923740234"
+	testNonce := "2304820340lskfle"
+	testClient := &dal.Client{ID: testClientId}
 	testUser := &dal.User{ID: "testUserId", PasswordHash: "328y9ewhdk"}
 
+	var testCode *dal.AuthorizationCode
+
 	mockUserProvider := dalMock.NewMockUserProvider(ctrl)
 	mockUserProvider.EXPECT().GetByEmail(gomock.Any(), testEmail).Return(testUser, nil)
 
@@ -740,11 +876,21 @@ func TestHandler_GivenUnsupportedResponseType_ReturnBadRequest(t *testing.T) {
 
 	mockClientValidator := valMock.NewMockClientValidator(ctrl)
 	mockClientValidator.EXPECT().ValidateLoginRequest(testClient, testRedirectUri, testScopes).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseType(gomock.Any(), gomock.Any()).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseMode("code", "query").Return(nil)
 
 	mockUserValidator := valMock.NewMockUserValidator(ctrl)
 	mockUserValidator.EXPECT().ValidatePassword(testUser, testPassword).Return(nil)
 
-	mockTokenService := tokenMock.NewMockService(ctrl)
+	mockClientValidator.EXPECT().ValidateCodeChallenge(testClient, "", "").Return(nil)
+
+	mockCodes := dalMock.NewMockAuthorizationCodeStore(ctrl)
+	mockCodes.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, c *dal.AuthorizationCode) error {
+		testCode = c
+		return nil
+	})
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, "https://id.example.com")
 
 	handler := &Handler{
 		sess:      mock.Session,
@@ -753,6 +899,8 @@ func TestHandler_GivenUnsupportedResponseType_ReturnBadRequest(t *testing.T) {
 		tokens:    mockTokenService,
 		clients:   mockClientProvider,
 		clientVal: mockClientValidator,
+		codes:     mockCodes,
+		sessions:  buildSessionStore(ctrl),
 	}
 
 	req := events.APIGatewayProxyRequest{
@@ -761,7 +909,9 @@ func TestHandler_GivenUnsupportedResponseType_ReturnBadRequest(t *testing.T) {
 			"Content-Type": "application/json",
 		},
 		StageVariables: map[string]string{
-			"JWT_KEY_ID": "key id",
+			"SESSION_KEY": testSessionKey,
+			"JWT_KEY_ID":  "key id",
+			"ISSUER":      "https://id.example.com",
 		},
 		Body: fmt.Sprintf(`{
 			"clientId": "%s",
@@ -769,7 +919,7 @@ func TestHandler_GivenUnsupportedResponseType_ReturnBadRequest(t *testing.T) {
 			"scopes": ["%s"],
 			"email": "%s",
 			"password": "%s",
-			"responseType": "nnot a supported response type",
+			"responseType": "code",
 			"state": "%s",
 			"nonce": "%s"
 		}`, testClientId, testRedirectUri, strings.Join(testScopes, `","`), testEmail, testPassword, testState, testNonce),
@@ -778,11 +928,1613 @@ func TestHandler_GivenUnsupportedResponseType_ReturnBadRequest(t *testing.T) {
 	ctx := context.Background()
 	resp, err := handler.Handle(ctx, req)
 	assert.NoError(t, err)
-	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
-	assert.False(t, resp.IsBase64Encoded)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, testClientId, testCode.ClientID)
+	assert.Equal(t, testUser.ID, testCode.UserID)
+	assert.Equal(t, testRedirectUri, testCode.RedirectUri)
+	assert.Equal(t, testScopes, testCode.Scopes)
+	assert.Equal(t, testNonce, testCode.Nonce)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+
+	redirectUri, err := url.Parse(data["redirectUri"].(string))
+	assert.NoError(t, err)
+
+	queryValues := redirectUri.Query()
+
+	assert.Equal(t, testCode.Code, queryValues.Get("code"))
+	assert.Equal(t, testState, queryValues.Get("state"))
+	assert.Equal(t, "", queryValues.Get("access_token"))
+}
+
+func TestHandler_WhereCodeStoreFails_ReturnsInternalServerError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testClientId := "23493234"
+	testRedirectUri := "http://localhost:8080"
+	testScopes := []string{"openid", "test"}
+	testEmail := "my@email.com"
+	testPassword := "myPassword1"
+	testClient := &dal.Client{ID: testClientId}
+	testUser := &dal.User{ID: "testUserId", PasswordHash: "328y9ewhdk"}
+	testError := errors.New("error")
+
+	mockUserProvider := dalMock.NewMockUserProvider(ctrl)
+	mockUserProvider.EXPECT().GetByEmail(gomock.Any(), testEmail).Return(testUser, nil)
+
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockClientValidator := valMock.NewMockClientValidator(ctrl)
+	mockClientValidator.EXPECT().ValidateLoginRequest(testClient, testRedirectUri, testScopes).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseType(gomock.Any(), gomock.Any()).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseMode(gomock.Any(), gomock.Any()).Return(nil)
+
+	mockUserValidator := valMock.NewMockUserValidator(ctrl)
+	mockUserValidator.EXPECT().ValidatePassword(testUser, testPassword).Return(nil)
+
+	mockClientValidator.EXPECT().ValidateCodeChallenge(testClient, "", "").Return(nil)
+
+	mockCodes := dalMock.NewMockAuthorizationCodeStore(ctrl)
+	mockCodes.EXPECT().Create(gomock.Any(), gomock.Any()).Return(testError)
+
+	handler := &Handler{
+		sess:      mock.Session,
+		users:     mockUserProvider,
+		userVal:   mockUserValidator,
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockClientProvider,
+		clientVal: mockClientValidator,
+		codes:     mockCodes,
+		sessions:  buildSessionStore(ctrl),
+	}
+
+	req := events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		StageVariables: map[string]string{
+			"SESSION_KEY": testSessionKey,
+		},
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: fmt.Sprintf(`{
+			"clientId": "%s",
+			"redirectUri": "%s",
+			"scopes": ["%s"],
+			"email": "%s",
+			"password": "%s",
+			"responseType": "code"
+		}`, testClientId, testRedirectUri, strings.Join(testScopes, `","`), testEmail, testPassword),
+	}
+
+	ctx := context.Background()
+	resp, err := handler.Handle(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
 
 	var data map[string]interface{}
 	json.Unmarshal([]byte(resp.Body), &data)
 
-	assert.Equal(t, errUnsupportedResponseType.Error(), data["error"])
+	assert.Equal(t, testError.Error(), data["error"])
+}
+
+func TestHandler_GivenCodeChallenge_StoresChallengeWithCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testClientId := "23493234"
+	testRedirectUri := "http://localhost:8080"
+	testScopes := []string{"openid"}
+	testEmail := "my@email.com"
+	testPassword := "myPassword1"
+	testChallenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	testClient := &dal.Client{ID: testClientId}
+	testUser := &dal.User{ID: "testUserId", PasswordHash: "328y9ewhdk"}
+
+	mockUserProvider := dalMock.NewMockUserProvider(ctrl)
+	mockUserProvider.EXPECT().GetByEmail(gomock.Any(), testEmail).Return(testUser, nil)
+
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockClientValidator := valMock.NewMockClientValidator(ctrl)
+	mockClientValidator.EXPECT().ValidateLoginRequest(testClient, testRedirectUri, testScopes).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseType(gomock.Any(), gomock.Any()).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseMode(gomock.Any(), gomock.Any()).Return(nil)
+	mockClientValidator.EXPECT().ValidateCodeChallenge(testClient, testChallenge, "").Return(nil)
+
+	mockUserValidator := valMock.NewMockUserValidator(ctrl)
+	mockUserValidator.EXPECT().ValidatePassword(testUser, testPassword).Return(nil)
+
+	mockCodes := dalMock.NewMockAuthorizationCodeStore(ctrl)
+	mockCodes.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, c *dal.AuthorizationCode) error {
+		assert.Equal(t, testChallenge, c.CodeChallenge)
+		assert.Equal(t, oauth.CodeChallengeMethodPlain, c.CodeChallengeMethod)
+		return nil
+	})
+
+	handler := &Handler{
+		sess:      mock.Session,
+		users:     mockUserProvider,
+		userVal:   mockUserValidator,
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockClientProvider,
+		clientVal: mockClientValidator,
+		codes:     mockCodes,
+		sessions:  buildSessionStore(ctrl),
+	}
+
+	req := events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		StageVariables: map[string]string{
+			"SESSION_KEY": testSessionKey,
+		},
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: fmt.Sprintf(`{
+			"clientId": "%s",
+			"redirectUri": "%s",
+			"scopes": ["%s"],
+			"email": "%s",
+			"password": "%s",
+			"responseType": "code",
+			"codeChallenge": "%s"
+		}`, testClientId, testRedirectUri, strings.Join(testScopes, `","`), testEmail, testPassword, testChallenge),
+	}
+
+	ctx := context.Background()
+	resp, err := handler.Handle(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestHandler_GivenInvalidCodeChallenge_RedirectsWithError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testClientId := "23493234"
+	testRedirectUri := "http://localhost:8080"
+	testScopes := []string{"openid"}
+	testEmail := "my@email.com"
+	testPassword := "myPassword1"
+	testClient := &dal.Client{ID: testClientId}
+	testUser := &dal.User{ID: "testUserId", PasswordHash: "328y9ewhdk"}
+
+	mockUserProvider := dalMock.NewMockUserProvider(ctrl)
+	mockUserProvider.EXPECT().GetByEmail(gomock.Any(), testEmail).Return(testUser, nil)
+
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockClientValidator := valMock.NewMockClientValidator(ctrl)
+	mockClientValidator.EXPECT().ValidateLoginRequest(testClient, testRedirectUri, testScopes).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseType(gomock.Any(), gomock.Any()).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseMode(gomock.Any(), gomock.Any()).Return(nil)
+	mockClientValidator.EXPECT().ValidateCodeChallenge(testClient, "", "").Return(validator.ErrMissingCodeChallenge)
+
+	mockUserValidator := valMock.NewMockUserValidator(ctrl)
+	mockUserValidator.EXPECT().ValidatePassword(testUser, testPassword).Return(nil)
+
+	handler := &Handler{
+		sess:      mock.Session,
+		users:     mockUserProvider,
+		userVal:   mockUserValidator,
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockClientProvider,
+		clientVal: mockClientValidator,
+		codes:     dalMock.NewMockAuthorizationCodeStore(ctrl),
+		sessions:  buildSessionStore(ctrl),
+	}
+
+	req := events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		StageVariables: map[string]string{
+			"SESSION_KEY": testSessionKey,
+		},
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: fmt.Sprintf(`{
+			"clientId": "%s",
+			"redirectUri": "%s",
+			"scopes": ["%s"],
+			"email": "%s",
+			"password": "%s",
+			"responseType": "code"
+		}`, testClientId, testRedirectUri, strings.Join(testScopes, `","`), testEmail, testPassword),
+	}
+
+	ctx := context.Background()
+	resp, err := handler.Handle(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	values := readQuery(t, resp)
+	assert.Equal(t, "invalid_request", values.Get("error"))
+	assert.Equal(t, "missing code challenge", values.Get("error_description"))
+	assert.NotContains(t, values, "state")
+}
+
+func buildDeviceRequest(userCode, email, password string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: fmt.Sprintf(`{
+			"userCode": "%s",
+			"responseType": "code",
+			"email": "%s",
+			"password": "%s"
+		}`, userCode, email, password),
+	}
+}
+
+// buildAttemptStore returns a mock AttemptStore, where the test user
+// has made the given number of invalid user code attempts.
+func buildAttemptStore(ctrl *gomock.Controller, attempts int) *dalMock.MockAttemptStore {
+	mockAttempts := dalMock.NewMockAttemptStore(ctrl)
+	mockAttempts.EXPECT().Count(gomock.Any(), "user-code/testUserId").Return(attempts, nil)
+
+	return mockAttempts
+}
+
+func TestHandler_GivenUserCode_AuthorizesDeviceCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testEmail := "my@email.com"
+	testPassword := "myPassword1"
+	testUser := &dal.User{ID: "testUserId", PasswordHash: "328y9ewhdk"}
+	testCode := &dal.DeviceCode{
+		DeviceCode: "2k3j4h2k3j4h",
+		UserCode:   "BCDF-GHJK",
+		ClientID:   "23493234",
+		Expires:    util.Time().Unix() + 60,
+	}
+
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), testCode.ClientID).Return(&dal.Client{ID: testCode.ClientID}, nil)
+
+	mockDevices := dalMock.NewMockDeviceCodeStore(ctrl)
+	mockDevices.EXPECT().GetByUserCode(gomock.Any(), "BCDF-GHJK").Return(testCode, nil)
+	mockDevices.EXPECT().Authorize(gomock.Any(), testCode.DeviceCode, testUser.ID).Return(nil)
+
+	mockUserProvider := dalMock.NewMockUserProvider(ctrl)
+	mockUserProvider.EXPECT().GetByEmail(gomock.Any(), testEmail).Return(testUser, nil)
+
+	mockUserValidator := valMock.NewMockUserValidator(ctrl)
+	mockUserValidator.EXPECT().ValidatePassword(testUser, testPassword).Return(nil)
+
+	handler := &Handler{
+		tokens:   tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		users:    mockUserProvider,
+		userVal:  mockUserValidator,
+		clients:  mockClientProvider,
+		devices:  mockDevices,
+		attempts: buildAttemptStore(ctrl, 0),
+	}
+
+	// User codes are normalized, as they are typed in by the user.
+	resp, err := handler.Handle(context.Background(), buildDeviceRequest("bcdf ghjk", testEmail, testPassword))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data ResponseModel
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, "", data.RedirectUri)
+}
+
+func TestHandler_GivenUnknownUserCode_ReturnsBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	util.Freeze()
+	defer util.Reset()
+
+	testUser := &dal.User{ID: "testUserId", PasswordHash: "328y9ewhdk"}
+
+	mockDevices := dalMock.NewMockDeviceCodeStore(ctrl)
+	mockDevices.EXPECT().GetByUserCode(gomock.Any(), "BCDF-GHJK").Return(nil, dal.ErrDeviceCodeNotFound)
+
+	mockUserProvider := dalMock.NewMockUserProvider(ctrl)
+	mockUserProvider.EXPECT().GetByEmail(gomock.Any(), "my@email.com").Return(testUser, nil)
+
+	mockUserValidator := valMock.NewMockUserValidator(ctrl)
+	mockUserValidator.EXPECT().ValidatePassword(testUser, "myPassword1").Return(nil)
+
+	mockAttempts := buildAttemptStore(ctrl, 0)
+	mockAttempts.EXPECT().Fail(gomock.Any(), "user-code/testUserId", util.Time().Add(userCodeAttemptWindow).Unix()).Return(nil)
+
+	handler := &Handler{
+		tokens:   tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		users:    mockUserProvider,
+		userVal:  mockUserValidator,
+		devices:  mockDevices,
+		attempts: mockAttempts,
+	}
+
+	resp, err := handler.Handle(context.Background(), buildDeviceRequest("BCDF-GHJK", "my@email.com", "myPassword1"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, errInvalidUserCode.Error(), data["error"])
+}
+
+func TestHandler_GivenExpiredUserCode_ReturnsBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testUser := &dal.User{ID: "testUserId", PasswordHash: "328y9ewhdk"}
+	testCode := &dal.DeviceCode{
+		DeviceCode: "2k3j4h2k3j4h",
+		UserCode:   "BCDF-GHJK",
+		Expires:    util.Time().Unix() - 1,
+	}
+
+	mockDevices := dalMock.NewMockDeviceCodeStore(ctrl)
+	mockDevices.EXPECT().GetByUserCode(gomock.Any(), "BCDF-GHJK").Return(testCode, nil)
+
+	mockUserProvider := dalMock.NewMockUserProvider(ctrl)
+	mockUserProvider.EXPECT().GetByEmail(gomock.Any(), "my@email.com").Return(testUser, nil)
+
+	mockUserValidator := valMock.NewMockUserValidator(ctrl)
+	mockUserValidator.EXPECT().ValidatePassword(testUser, "myPassword1").Return(nil)
+
+	mockAttempts := buildAttemptStore(ctrl, 0)
+	mockAttempts.EXPECT().Fail(gomock.Any(), "user-code/testUserId", gomock.Any()).Return(nil)
+
+	handler := &Handler{
+		tokens:   tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		users:    mockUserProvider,
+		userVal:  mockUserValidator,
+		devices:  mockDevices,
+		attempts: mockAttempts,
+	}
+
+	resp, err := handler.Handle(context.Background(), buildDeviceRequest("BCDF-GHJK", "my@email.com", "myPassword1"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, errInvalidUserCode.Error(), data["error"])
+}
+
+func TestHandler_GivenTooManyUserCodeAttempts_ReturnsTooManyRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testUser := &dal.User{ID: "testUserId", PasswordHash: "328y9ewhdk"}
+
+	mockUserProvider := dalMock.NewMockUserProvider(ctrl)
+	mockUserProvider.EXPECT().GetByEmail(gomock.Any(), "my@email.com").Return(testUser, nil)
+
+	mockUserValidator := valMock.NewMockUserValidator(ctrl)
+	mockUserValidator.EXPECT().ValidatePassword(testUser, "myPassword1").Return(nil)
+
+	// The code isn't looked up, even if it's valid.
+	handler := &Handler{
+		tokens:   tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		users:    mockUserProvider,
+		userVal:  mockUserValidator,
+		devices:  dalMock.NewMockDeviceCodeStore(ctrl),
+		attempts: buildAttemptStore(ctrl, maxUserCodeAttempts),
+	}
+
+	resp, err := handler.Handle(context.Background(), buildDeviceRequest("BCDF-GHJK", "my@email.com", "myPassword1"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, errTooManyAttempts.Error(), data["error"])
+}
+
+func TestHandler_GivenUserCodeWithInvalidPassword_ReturnsBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testEmail := "my@email.com"
+	testPassword := "myPassword1"
+	testUser := &dal.User{ID: "testUserId", PasswordHash: "328y9ewhdk"}
+
+	mockUserProvider := dalMock.NewMockUserProvider(ctrl)
+	mockUserProvider.EXPECT().GetByEmail(gomock.Any(), testEmail).Return(testUser, nil)
+
+	mockUserValidator := valMock.NewMockUserValidator(ctrl)
+	mockUserValidator.EXPECT().ValidatePassword(testUser, testPassword).Return(validator.ErrInvalidPassword)
+
+	// The code must not be looked up before the user is authenticated,
+	// otherwise codes could be probed anonymously.
+	handler := &Handler{
+		tokens:   tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		users:    mockUserProvider,
+		userVal:  mockUserValidator,
+		devices:  dalMock.NewMockDeviceCodeStore(ctrl),
+		attempts: dalMock.NewMockAttemptStore(ctrl),
+	}
+
+	resp, err := handler.Handle(context.Background(), buildDeviceRequest("BCDF-GHJK", testEmail, testPassword))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, errInvalidCredentials.Error(), data["error"])
+}
+
+func TestHandler_GivenApprovedUserCode_ReturnsBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testEmail := "my@email.com"
+	testPassword := "myPassword1"
+	testUser := &dal.User{ID: "testUserId", PasswordHash: "328y9ewhdk"}
+	testCode := &dal.DeviceCode{
+		DeviceCode: "2k3j4h2k3j4h",
+		UserCode:   "BCDF-GHJK",
+		ClientID:   "23493234",
+		Expires:    util.Time().Unix() + 60,
+	}
+
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), testCode.ClientID).Return(&dal.Client{ID: testCode.ClientID}, nil)
+
+	mockDevices := dalMock.NewMockDeviceCodeStore(ctrl)
+	mockDevices.EXPECT().GetByUserCode(gomock.Any(), "BCDF-GHJK").Return(testCode, nil)
+	mockDevices.EXPECT().Authorize(gomock.Any(), testCode.DeviceCode, testUser.ID).Return(dal.ErrDeviceCodeNotFound)
+
+	mockUserProvider := dalMock.NewMockUserProvider(ctrl)
+	mockUserProvider.EXPECT().GetByEmail(gomock.Any(), testEmail).Return(testUser, nil)
+
+	mockUserValidator := valMock.NewMockUserValidator(ctrl)
+	mockUserValidator.EXPECT().ValidatePassword(testUser, testPassword).Return(nil)
+
+	handler := &Handler{
+		tokens:   tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		users:    mockUserProvider,
+		userVal:  mockUserValidator,
+		clients:  mockClientProvider,
+		devices:  mockDevices,
+		attempts: buildAttemptStore(ctrl, 0),
+	}
+
+	resp, err := handler.Handle(context.Background(), buildDeviceRequest("BCDF-GHJK", testEmail, testPassword))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, errInvalidUserCode.Error(), data["error"])
+}
+
+// buildDeviceHandler returns a handler which authenticates the test user, and
+// finds the user code for a device code requested by c, along with the mock
+// device code store, so tests can expect the code to be approved.
+func buildDeviceHandler(ctrl *gomock.Controller, c *dal.Client, code *dal.DeviceCode) (*Handler, *dalMock.MockDeviceCodeStore) {
+	testUser := &dal.User{ID: "testUserId", PasswordHash: "328y9ewhdk"}
+
+	mockUserProvider := dalMock.NewMockUserProvider(ctrl)
+	mockUserProvider.EXPECT().GetByEmail(gomock.Any(), "my@email.com").Return(testUser, nil)
+
+	mockUserValidator := valMock.NewMockUserValidator(ctrl)
+	mockUserValidator.EXPECT().ValidatePassword(testUser, "myPassword1").Return(nil)
+
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), c.ID).Return(c, nil)
+
+	mockDevices := dalMock.NewMockDeviceCodeStore(ctrl)
+	mockDevices.EXPECT().GetByUserCode(gomock.Any(), code.UserCode).Return(code, nil)
+
+	return &Handler{
+		tokens:   tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		users:    mockUserProvider,
+		userVal:  mockUserValidator,
+		clients:  mockClientProvider,
+		devices:  mockDevices,
+		attempts: buildAttemptStore(ctrl, 0),
+	}, mockDevices
+}
+
+func TestHandler_GivenUserCodeForThirdPartyClient_RequiresConsent(t *testing.T) {
+	testCode := &dal.DeviceCode{
+		DeviceCode: "2k3j4h2k3j4h",
+		UserCode:   "BCDF-GHJK",
+		ClientID:   testThirdPartyClient.ID,
+		Scopes:     []string{"openid", "profile"},
+		Expires:    util.Time().Unix() + 60,
+	}
+
+	t.Run("Given No Consent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// The device code must not be approved.
+		h, _ := buildDeviceHandler(ctrl, testThirdPartyClient, testCode)
+
+		mockConsents := dalMock.NewMockConsentStore(ctrl)
+		mockConsents.EXPECT().Get(gomock.Any(), "testUserId", testThirdPartyClient.ID).Return(nil, dal.ErrConsentNotFound)
+		h.consents = mockConsents
+
+		resp, err := h.Handle(context.Background(), buildDeviceRequest("BCDF-GHJK", "my@email.com", "myPassword1"))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, &ConsentModel{ClientName: "Third Party", Scopes: []string{"openid", "profile"}}, readConsent(t, resp))
+	})
+
+	t.Run("Given Consent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h, mockDevices := buildDeviceHandler(ctrl, testThirdPartyClient, testCode)
+		mockDevices.EXPECT().Authorize(gomock.Any(), testCode.DeviceCode, "testUserId").Return(nil)
+
+		// The user consents to the scopes requested by the device.
+		mockConsents := dalMock.NewMockConsentStore(ctrl)
+		mockConsents.EXPECT().Get(gomock.Any(), "testUserId", testThirdPartyClient.ID).Return(nil, dal.ErrConsentNotFound)
+		mockConsents.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, c *dal.Consent) error {
+			assert.Equal(t, testCode.Scopes, c.Scopes)
+			return nil
+		})
+		h.consents = mockConsents
+
+		req := withParams(buildDeviceRequest("BCDF-GHJK", "my@email.com", "myPassword1"), map[string]interface{}{"consent": true})
+		resp, err := h.Handle(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Nil(t, readConsent(t, resp))
+	})
+
+	t.Run("Given Refused Consent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h, _ := buildDeviceHandler(ctrl, testThirdPartyClient, testCode)
+
+		req := withParams(buildDeviceRequest("BCDF-GHJK", "my@email.com", "myPassword1"), map[string]interface{}{"consent": false})
+		resp, err := h.Handle(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		var data map[string]interface{}
+		json.Unmarshal([]byte(resp.Body), &data)
+		assert.Equal(t, "access_denied", data["error"])
+	})
+}
+
+func TestHandler_GivenFormPostResponseMode_ReturnsForm(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testClient := &dal.Client{ID: "23493234"}
+	testUser := &dal.User{ID: "testUserId"}
+
+	mockUserProvider := dalMock.NewMockUserProvider(ctrl)
+	mockUserProvider.EXPECT().GetByEmail(gomock.Any(), "my@email.com").Return(testUser, nil)
+
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockClientValidator := valMock.NewMockClientValidator(ctrl)
+	mockClientValidator.EXPECT().ValidateLoginRequest(testClient, "https://client.example.com/callback", []string{"openid"}).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseType(gomock.Any(), gomock.Any()).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseMode("id_token token", "form_post").Return(nil)
+
+	mockUserValidator := valMock.NewMockUserValidator(ctrl)
+	mockUserValidator.EXPECT().ValidatePassword(testUser, "myPassword1").Return(nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), int64(3600), "goidc").
+		Return(&token.Token{AccessToken: "my.access.token", TokenType: "Bearer", Expires: 3600}, nil)
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&token.Token{AccessToken: "my.id.token"}, nil)
+
+	handler := &Handler{
+		sess:      mock.Session,
+		users:     mockUserProvider,
+		userVal:   mockUserValidator,
+		tokens:    mockTokenService,
+		clients:   mockClientProvider,
+		clientVal: mockClientValidator,
+		sessions:  buildSessionStore(ctrl),
+	}
+
+	resp, err := handler.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		StageVariables: map[string]string{
+			"SESSION_KEY": testSessionKey,
+			"JWT_KEY_ID":  "key id",
+		},
+		Body: `{
+			"clientId": "23493234",
+			"redirectUri": "https://client.example.com/callback",
+			"scopes": ["openid"],
+			"email": "my@email.com",
+			"password": "myPassword1",
+			"responseType": "id_token token",
+			"responseMode": "form_post",
+			"state": "<script>",
+			"nonce": "my nonce"
+		}`,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data ResponseModel
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, "", data.RedirectUri)
+	assert.Contains(t, data.Form, `<form method="post" action="https://client.example.com/callback">`)
+	assert.Contains(t, data.Form, `<input type="hidden" name="access_token" value="my.access.token"/>`)
+	assert.Contains(t, data.Form, `<input type="hidden" name="id_token" value="my.id.token"/>`)
+
+	// Parameters must be escaped, as they're given by the client.
+	assert.Contains(t, data.Form, `<input type="hidden" name="state" value="&lt;script&gt;"/>`)
+}
+
+func TestHandler_GivenInvalidResponseMode_RedirectsWithError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testClient := &dal.Client{ID: "23493234"}
+
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockClientValidator := valMock.NewMockClientValidator(ctrl)
+	mockClientValidator.EXPECT().ValidateLoginRequest(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseType(gomock.Any(), gomock.Any()).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseMode("id_token token", "query").Return(validator.ErrInvalidResponseMode)
+
+	handler := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockClientProvider,
+		clientVal: mockClientValidator,
+	}
+
+	resp, err := handler.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: `{
+			"clientId": "23493234",
+			"redirectUri": "https://client.example.com/callback",
+			"responseType": "id_token token",
+			"responseMode": "query"
+		}`,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The error is returned using the default response mode instead.
+	values := readFragment(t, resp)
+	assert.Equal(t, "invalid_request", values.Get("error"))
+	assert.Equal(t, "invalid response mode", values.Get("error_description"))
+}
+
+func TestAuthorizationResponse_GivenRedirectUriWithQuery_KeepsQuery(t *testing.T) {
+	resp, err := authorizationResponse(&LoginModel{
+		RedirectUri:  "https://client.example.com/callback?tenant=1",
+		ResponseMode: oauth.ResponseModeQuery,
+	}, url.Values{"code": {"my code"}})
+	assert.NoError(t, err)
+
+	var data ResponseModel
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, "https://client.example.com/callback?tenant=1&code=my+code", data.RedirectUri)
+}
+
+// testSessionKey is the base64 encoded key used to encode session cookies.
+var testSessionKey = base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+
+// buildSessionStore returns a session store which accepts any new session.
+func buildSessionStore(ctrl *gomock.Controller) *dalMock.MockSessionStore {
+	mockSessions := dalMock.NewMockSessionStore(ctrl)
+	mockSessions.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	return mockSessions
+}
+
+func buildHybridHandler(ctrl *gomock.Controller, responseType string) (*Handler, *tokenMock.MockService) {
+	testClient := &dal.Client{ID: "23493234"}
+	testUser := &dal.User{ID: "testUserId"}
+
+	mockUserProvider := dalMock.NewMockUserProvider(ctrl)
+	mockUserProvider.EXPECT().GetByEmail(gomock.Any(), "my@email.com").Return(testUser, nil)
+
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockClientValidator := valMock.NewMockClientValidator(ctrl)
+	mockClientValidator.EXPECT().ValidateLoginRequest(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseType(testClient, responseType).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseMode(responseType, "fragment").Return(nil)
+	mockClientValidator.EXPECT().ValidateCodeChallenge(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	mockUserValidator := valMock.NewMockUserValidator(ctrl)
+	mockUserValidator.EXPECT().ValidatePassword(testUser, "myPassword1").Return(nil)
+
+	mockCodes := dalMock.NewMockAuthorizationCodeStore(ctrl)
+	mockCodes.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+
+	return &Handler{
+		sess:      mock.Session,
+		users:     mockUserProvider,
+		userVal:   mockUserValidator,
+		tokens:    mockTokenService,
+		clients:   mockClientProvider,
+		clientVal: mockClientValidator,
+		codes:     mockCodes,
+		sessions:  buildSessionStore(ctrl),
+	}, mockTokenService
+}
+
+func buildHybridRequest(responseType string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		StageVariables: map[string]string{
+			"JWT_KEY_ID":  "key id",
+			"SESSION_KEY": testSessionKey,
+		},
+		Body: fmt.Sprintf(`{
+			"clientId": "23493234",
+			"redirectUri": "https://client.example.com/callback",
+			"scopes": ["openid"],
+			"email": "my@email.com",
+			"password": "myPassword1",
+			"responseType": "%s",
+			"state": "my state",
+			"nonce": "my nonce"
+		}`, responseType),
+	}
+}
+
+func readFragment(t *testing.T, resp events.APIGatewayProxyResponse) url.Values {
+	var data ResponseModel
+	json.Unmarshal([]byte(resp.Body), &data)
+
+	redirectUri, err := url.Parse(data.RedirectUri)
+	assert.NoError(t, err)
+
+	values, _ := url.ParseQuery(redirectUri.Fragment)
+	return values
+}
+
+func readQuery(t *testing.T, resp events.APIGatewayProxyResponse) url.Values {
+	var data ResponseModel
+	json.Unmarshal([]byte(resp.Body), &data)
+
+	redirectUri, err := url.Parse(data.RedirectUri)
+	assert.NoError(t, err)
+
+	return redirectUri.Query()
+}
+
+func TestHandler_GivenCodeIdTokenTokenResponseType_ReturnsAllWithHashes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	util.Freeze()
+	defer util.Reset()
+
+	// The order of response type values is not significant.
+	h, mockTokenService := buildHybridHandler(ctrl, "code id_token token")
+
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), int64(3600), "goidc").
+		Return(&token.Token{AccessToken: "my.access.token", TokenType: "Bearer", Expires: 3600}, nil)
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), int64(36000), gomock.Any()).
+		DoAndReturn(func(_ interface{}, claims map[string]interface{}, _ int64, _ string) (*token.Token, error) {
+			// Hashes are the base64url encoded left half of the SHA-256 hash.
+			assert.Equal(t, "kjfjidY7gFP6wnASPmmh3w", claims["at_hash"])
+			assert.Equal(t, "MoPVXEy0uqG0aca38TU8iA", claims["s_hash"])
+			assert.NotEmpty(t, claims["c_hash"])
+			assert.Equal(t, "my nonce", claims["nonce"])
+			assert.Equal(t, "23493234", claims["azp"])
+			assert.Equal(t, []string{oauth.AuthenticationMethodPassword}, claims["amr"])
+			assert.Equal(t, util.Time().Unix(), claims["auth_time"])
+			assert.NotEmpty(t, claims["sid"])
+
+			return &token.Token{AccessToken: "my.id.token"}, nil
+		})
+
+	resp, err := h.Handle(context.Background(), buildHybridRequest("token code id_token"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	values := readFragment(t, resp)
+	assert.NotEmpty(t, values.Get("code"))
+	assert.Equal(t, "my.access.token", values.Get("access_token"))
+	assert.Equal(t, "my.id.token", values.Get("id_token"))
+	assert.Equal(t, "my state", values.Get("state"))
+}
+
+func TestHandler_GivenIdTokenResponseTypeWithoutNonce_RedirectsWithError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testClient := &dal.Client{ID: "23493234"}
+
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockClientValidator := valMock.NewMockClientValidator(ctrl)
+	mockClientValidator.EXPECT().ValidateLoginRequest(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseType(testClient, "id_token").Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseMode("id_token", "fragment").Return(nil)
+
+	handler := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockClientProvider,
+		clientVal: mockClientValidator,
+	}
+
+	resp, err := handler.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: `{
+			"clientId": "23493234",
+			"redirectUri": "https://client.example.com/callback",
+			"email": "my@email.com",
+			"password": "myPassword1",
+			"responseType": "id_token"
+		}`,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	values := readFragment(t, resp)
+	assert.Equal(t, "invalid_request", values.Get("error"))
+	assert.Equal(t, "missing nonce", values.Get("error_description"))
+}
+
+func TestHandler_GivenCodeIdTokenResponseType_ReturnsCodeHash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h, mockTokenService := buildHybridHandler(ctrl, "code id_token")
+
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), int64(36000), gomock.Any()).
+		DoAndReturn(func(_ interface{}, claims map[string]interface{}, _ int64, _ string) (*token.Token, error) {
+			assert.NotContains(t, claims, "at_hash")
+			assert.NotEmpty(t, claims["c_hash"])
+
+			return &token.Token{AccessToken: "my.id.token"}, nil
+		})
+
+	resp, err := h.Handle(context.Background(), buildHybridRequest("code id_token"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	values := readFragment(t, resp)
+	assert.NotEmpty(t, values.Get("code"))
+	assert.Equal(t, "", values.Get("access_token"))
+	assert.Equal(t, "my.id.token", values.Get("id_token"))
+}
+
+func TestHandler_GivenIdTokenResponseType_ReturnsOnlyIdToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h, mockTokenService := buildHybridHandler(ctrl, "id_token")
+
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), int64(36000), gomock.Any()).
+		DoAndReturn(func(_ interface{}, claims map[string]interface{}, _ int64, _ string) (*token.Token, error) {
+			assert.NotContains(t, claims, "at_hash")
+			assert.NotContains(t, claims, "c_hash")
+
+			return &token.Token{AccessToken: "my.id.token"}, nil
+		})
+
+	resp, err := h.Handle(context.Background(), buildHybridRequest("id_token"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	values := readFragment(t, resp)
+	assert.Equal(t, "", values.Get("code"))
+	assert.Equal(t, "", values.Get("access_token"))
+	assert.Equal(t, "my.id.token", values.Get("id_token"))
+}
+
+func TestHandler_GivenCodeTokenResponseType_ReturnsCodeAndAccessToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h, mockTokenService := buildHybridHandler(ctrl, "code token")
+
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), int64(3600), "goidc").
+		Return(&token.Token{AccessToken: "my.access.token", TokenType: "Bearer", Expires: 3600}, nil)
+
+	resp, err := h.Handle(context.Background(), buildHybridRequest("code token"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	values := readFragment(t, resp)
+	assert.NotEmpty(t, values.Get("code"))
+	assert.Equal(t, "my.access.token", values.Get("access_token"))
+	assert.Equal(t, "", values.Get("id_token"))
+}
+
+func TestHandler_GivenUnregisteredResponseType_RedirectsWithError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testClient := &dal.Client{ID: "23493234"}
+
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockClientValidator := valMock.NewMockClientValidator(ctrl)
+	mockClientValidator.EXPECT().ValidateLoginRequest(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseType(testClient, "id_token token").Return(validator.ErrInvalidResponseType)
+
+	h := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockClientProvider,
+		clientVal: mockClientValidator,
+	}
+
+	resp, err := h.Handle(context.Background(), buildHybridRequest("token id_token"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	values := readFragment(t, resp)
+	assert.Equal(t, "unauthorized_client", values.Get("error"))
+	assert.Equal(t, "my state", values.Get("state"))
+}
+
+func buildSessionRequest(sessionCookie string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+			"Cookie":       cookie.SessionName + "=" + sessionCookie,
+		},
+		StageVariables: map[string]string{
+			"JWT_KEY_ID":  "key id",
+			"SESSION_KEY": testSessionKey,
+		},
+		Body: `{
+			"clientId": "23493234",
+			"redirectUri": "https://client.example.com/callback",
+			"scopes": ["openid"],
+			"responseType": "code",
+			"state": "my state"
+		}`,
+	}
+}
+
+func encodeSessionCookie(t *testing.T, id string) string {
+	key, _ := base64.StdEncoding.DecodeString(testSessionKey)
+	codec, err := cookie.NewCodec(key)
+	assert.NoError(t, err)
+
+	value, err := codec.Encode(cookie.SessionName, id)
+	assert.NoError(t, err)
+
+	return value
+}
+
+func buildSessionHandler(ctrl *gomock.Controller) *Handler {
+	return buildClientSessionHandler(ctrl, &dal.Client{ID: "23493234"})
+}
+
+// buildClientSessionHandler returns a handler which expects a request for
+// testClient, authorized using a session.
+func buildClientSessionHandler(ctrl *gomock.Controller, testClient *dal.Client) *Handler {
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockClientValidator := valMock.NewMockClientValidator(ctrl)
+	mockClientValidator.EXPECT().ValidateLoginRequest(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseType(testClient, "code").Return(nil)
+	mockClientValidator.EXPECT().ValidateResponseMode("code", "query").Return(nil)
+	mockClientValidator.EXPECT().ValidateCodeChallenge(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	return &Handler{
+		sess:      mock.Session,
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockClientProvider,
+		clientVal: mockClientValidator,
+		users:     dalMock.NewMockUserProvider(ctrl),
+		codes:     dalMock.NewMockAuthorizationCodeStore(ctrl),
+		sessions:  dalMock.NewMockSessionStore(ctrl),
+		consents:  dalMock.NewMockConsentStore(ctrl),
+	}
+}
+
+func TestHandler_GivenSessionCookie_AuthorizesWithoutCredentials(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h := buildSessionHandler(ctrl)
+
+	testSession := &dal.Session{
+		ID:       "my session id",
+		UserID:   "testUserId",
+		AuthTime: 1600000000,
+		Expires:  util.Time().Add(time.Hour).Unix(),
+	}
+
+	// The client hasn't been authorized during the session yet.
+	mockSessions := dalMock.NewMockSessionStore(ctrl)
+	mockSessions.EXPECT().Get(gomock.Any(), testSession.ID).Return(testSession, nil)
+	mockSessions.EXPECT().AddClient(gomock.Any(), testSession.ID, "23493234").Return(nil)
+	h.sessions = mockSessions
+
+	mockUserProvider := dalMock.NewMockUserProvider(ctrl)
+	mockUserProvider.EXPECT().Get(gomock.Any(), testSession.UserID).Return(&dal.User{ID: testSession.UserID}, nil)
+	h.users = mockUserProvider
+
+	var created *dal.AuthorizationCode
+	mockCodes := dalMock.NewMockAuthorizationCodeStore(ctrl)
+	mockCodes.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, c *dal.AuthorizationCode) error {
+		created = c
+		return nil
+	})
+	h.codes = mockCodes
+
+	resp, err := h.Handle(context.Background(), buildSessionRequest(encodeSessionCookie(t, testSession.ID)))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The user authenticated when the session was created.
+	assert.Equal(t, testSession.UserID, created.UserID)
+	assert.Equal(t, testSession.AuthTime, created.AuthTime)
+	assert.Equal(t, testSession.ID, created.SessionID)
+
+	values := readQuery(t, resp)
+	assert.Equal(t, created.Code, values.Get("code"))
+	assert.Equal(t, "my state", values.Get("state"))
+}
+
+func TestHandler_GivenInvalidSession_ReturnsUnauthorized(t *testing.T) {
+	t.Run("Given No Cookie", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h := buildSessionHandler(ctrl)
+
+		resp, err := h.Handle(context.Background(), buildSessionRequest(""))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, "{\"error\":\"login_required\",\"error_description\":\"login required\"}", resp.Body)
+	})
+
+	t.Run("Given Tampered Cookie", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h := buildSessionHandler(ctrl)
+
+		resp, err := h.Handle(context.Background(), buildSessionRequest("bXkgc2Vzc2lvbiBpZA.c2lnbmF0dXJl"))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Given Expired Session", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h := buildSessionHandler(ctrl)
+
+		mockSessions := dalMock.NewMockSessionStore(ctrl)
+		mockSessions.EXPECT().Get(gomock.Any(), "my session id").Return(&dal.Session{
+			ID:      "my session id",
+			UserID:  "testUserId",
+			Expires: util.Time().Add(-time.Minute).Unix(),
+		}, nil)
+		h.sessions = mockSessions
+
+		resp, err := h.Handle(context.Background(), buildSessionRequest(encodeSessionCookie(t, "my session id")))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
+
+func TestHandler_GivenCredentials_SetsSessionCookie(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	util.Freeze()
+	defer util.Reset()
+
+	h, mockTokenService := buildHybridHandler(ctrl, "id_token")
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), int64(36000), gomock.Any()).
+		Return(&token.Token{AccessToken: "my.id.token"}, nil)
+
+	var created *dal.Session
+	mockSessions := dalMock.NewMockSessionStore(ctrl)
+	mockSessions.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, s *dal.Session) error {
+		created = s
+		return nil
+	})
+	h.sessions = mockSessions
+
+	resp, err := h.Handle(context.Background(), buildHybridRequest("id_token"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, "testUserId", created.UserID)
+	assert.Equal(t, util.Time().Unix(), created.AuthTime)
+	assert.Equal(t, []string{"23493234"}, created.ClientIDs)
+	assert.Equal(t, util.Time().Add(sessionExpiry).Unix(), created.Expires)
+
+	header := http.Header{"Set-Cookie": {resp.Headers["Set-Cookie"]}}
+	cookies := (&http.Response{Header: header}).Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, cookie.SessionName, cookies[0].Name)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.True(t, cookies[0].Secure)
+	assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+
+	// The cookie holds the session id, which cannot be read by the browser.
+	assert.NotContains(t, cookies[0].Value, created.ID)
+
+	key, _ := base64.StdEncoding.DecodeString(testSessionKey)
+	codec, _ := cookie.NewCodec(key)
+	id, err := codec.Decode(cookie.SessionName, cookies[0].Value)
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID, id)
+}
+
+// withParams returns req with the given parameters added to its body.
+func withParams(req events.APIGatewayProxyRequest, params map[string]interface{}) events.APIGatewayProxyRequest {
+	var body map[string]interface{}
+	json.Unmarshal([]byte(req.Body), &body)
+
+	for name, value := range params {
+		body[name] = value
+	}
+
+	data, _ := json.Marshal(body)
+	req.Body = string(data)
+
+	return req
+}
+
+// expectSession sets up h to find the session with the given id, which was
+// created when u logged in at authTime, to be authorized by the test client.
+func expectSession(ctrl *gomock.Controller, h *Handler, id string, u *dal.User, authTime int64) {
+	mockSessions := dalMock.NewMockSessionStore(ctrl)
+	mockSessions.EXPECT().Get(gomock.Any(), id).Return(&dal.Session{
+		ID:        id,
+		UserID:    u.ID,
+		AuthTime:  authTime,
+		Expires:   util.Time().Add(time.Hour).Unix(),
+		ClientIDs: []string{"23493234"},
+	}, nil).AnyTimes()
+	h.sessions = mockSessions
+
+	mockUserProvider := dalMock.NewMockUserProvider(ctrl)
+	mockUserProvider.EXPECT().Get(gomock.Any(), u.ID).Return(u, nil).AnyTimes()
+	h.users = mockUserProvider
+
+	mockCodes := dalMock.NewMockAuthorizationCodeStore(ctrl)
+	mockCodes.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	h.codes = mockCodes
+}
+
+func TestHandler_GivenPromptNoneWithoutSession_RedirectsWithLoginRequired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h := buildSessionHandler(ctrl)
+
+	req := withParams(buildSessionRequest(""), map[string]interface{}{"prompt": "none"})
+	resp, err := h.Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	values := readQuery(t, resp)
+	assert.Equal(t, "login_required", values.Get("error"))
+	assert.Equal(t, "my state", values.Get("state"))
+}
+
+func TestHandler_GivenPromptNoneWithSession_AuthorizesWithoutCredentials(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h := buildSessionHandler(ctrl)
+	expectSession(ctrl, h, "my session id", &dal.User{ID: "testUserId"}, 1600000000)
+
+	req := withParams(buildSessionRequest(encodeSessionCookie(t, "my session id")), map[string]interface{}{"prompt": "none"})
+	resp, err := h.Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	values := readQuery(t, resp)
+	assert.NotEmpty(t, values.Get("code"))
+	assert.Empty(t, values.Get("error"))
+}
+
+func TestHandler_GivenPromptNoneWithOtherValues_RedirectsWithError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h := buildSessionHandler(ctrl)
+
+	req := withParams(buildSessionRequest(""), map[string]interface{}{"prompt": "none login"})
+	resp, err := h.Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	values := readQuery(t, resp)
+	assert.Equal(t, "invalid_request", values.Get("error"))
+	assert.Equal(t, errInvalidPrompt.Description, values.Get("error_description"))
+}
+
+func TestHandler_GivenPromptRequiringLogin_IgnoresSession(t *testing.T) {
+	for _, prompt := range []string{"login", "select_account"} {
+		t.Run(prompt, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// The session store isn't expected to be used.
+			h := buildSessionHandler(ctrl)
+
+			req := withParams(buildSessionRequest(encodeSessionCookie(t, "my session id")), map[string]interface{}{"prompt": prompt})
+			resp, err := h.Handle(context.Background(), req)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		})
+	}
+}
+
+func TestHandler_GivenMaxAge_RequiresRecentLogin(t *testing.T) {
+	util.Freeze()
+	defer util.Reset()
+
+	authTime := util.Time().Add(-10 * time.Minute).Unix()
+
+	t.Run("Given Recent Login", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h := buildSessionHandler(ctrl)
+		expectSession(ctrl, h, "my session id", &dal.User{ID: "testUserId"}, authTime)
+
+		req := withParams(buildSessionRequest(encodeSessionCookie(t, "my session id")), map[string]interface{}{"maxAge": 900})
+		resp, err := h.Handle(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NotEmpty(t, readQuery(t, resp).Get("code"))
+	})
+
+	t.Run("Given Old Login", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h := buildSessionHandler(ctrl)
+		expectSession(ctrl, h, "my session id", &dal.User{ID: "testUserId"}, authTime)
+
+		req := withParams(buildSessionRequest(encodeSessionCookie(t, "my session id")), map[string]interface{}{"maxAge": 300})
+		resp, err := h.Handle(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Given Old Login And Prompt None", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h := buildSessionHandler(ctrl)
+		expectSession(ctrl, h, "my session id", &dal.User{ID: "testUserId"}, authTime)
+
+		req := withParams(buildSessionRequest(encodeSessionCookie(t, "my session id")), map[string]interface{}{
+			"maxAge": 0,
+			"prompt": "none",
+		})
+		resp, err := h.Handle(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, "login_required", readQuery(t, resp).Get("error"))
+	})
+
+	t.Run("Given Negative Max Age", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h := buildSessionHandler(ctrl)
+
+		req := withParams(buildSessionRequest(""), map[string]interface{}{"maxAge": -1})
+		resp, err := h.Handle(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, "invalid_request", readQuery(t, resp).Get("error"))
+	})
+}
+
+func TestHandler_GivenLoginHint_RequiresMatchingUser(t *testing.T) {
+	testUser := &dal.User{ID: "testUserId", Email: "my@email.com"}
+
+	t.Run("Given Matching Hint", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h := buildSessionHandler(ctrl)
+		expectSession(ctrl, h, "my session id", testUser, 1600000000)
+
+		req := withParams(buildSessionRequest(encodeSessionCookie(t, "my session id")), map[string]interface{}{"loginHint": "My@Email.com"})
+		resp, err := h.Handle(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Given Hint For Another User", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h := buildSessionHandler(ctrl)
+		expectSession(ctrl, h, "my session id", testUser, 1600000000)
+
+		req := withParams(buildSessionRequest(encodeSessionCookie(t, "my session id")), map[string]interface{}{"loginHint": "other@email.com"})
+		resp, err := h.Handle(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
+
+func TestHandler_GivenIDTokenHint_RequiresMatchingUser(t *testing.T) {
+	testUser := &dal.User{ID: "testUserId"}
+
+	t.Run("Given Matching Hint", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h := buildSessionHandler(ctrl)
+		expectSession(ctrl, h, "my session id", testUser, 1600000000)
+
+		mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+		mockTokenService.EXPECT().VerifyTokenIgnoringExpiry(gomock.Any(), "my.id.token", "23493234").
+			Return(gojwt.Claims{"sub": testUser.ID}, nil)
+		h.tokens = mockTokenService
+
+		req := withParams(buildSessionRequest(encodeSessionCookie(t, "my session id")), map[string]interface{}{"idTokenHint": "my.id.token"})
+		resp, err := h.Handle(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NotEmpty(t, readQuery(t, resp).Get("code"))
+	})
+
+	t.Run("Given Hint For Another User", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h := buildSessionHandler(ctrl)
+		expectSession(ctrl, h, "my session id", testUser, 1600000000)
+
+		mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+		mockTokenService.EXPECT().VerifyTokenIgnoringExpiry(gomock.Any(), "my.id.token", "23493234").
+			Return(gojwt.Claims{"sub": "otherUserId"}, nil)
+		h.tokens = mockTokenService
+
+		req := withParams(buildSessionRequest(encodeSessionCookie(t, "my session id")), map[string]interface{}{
+			"idTokenHint": "my.id.token",
+			"prompt":      "none",
+		})
+		resp, err := h.Handle(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, "login_required", readQuery(t, resp).Get("error"))
+	})
+
+	t.Run("Given Invalid Hint", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h := buildSessionHandler(ctrl)
+
+		mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+		mockTokenService.EXPECT().VerifyTokenIgnoringExpiry(gomock.Any(), "my.id.token", "23493234").
+			Return(nil, token.ErrInvalidSignature)
+		h.tokens = mockTokenService
+
+		req := withParams(buildSessionRequest(encodeSessionCookie(t, "my session id")), map[string]interface{}{"idTokenHint": "my.id.token"})
+		resp, err := h.Handle(context.Background(), req)
+		assert.NoError(t, err)
+
+		values := readQuery(t, resp)
+		assert.Equal(t, "invalid_request", values.Get("error"))
+		assert.Equal(t, errInvalidIDTokenHint.Description, values.Get("error_description"))
+	})
+}
+
+var testThirdPartyClient = &dal.Client{
+	ID:         "23493234",
+	Name:       "Third Party",
+	ThirdParty: true,
+}
+
+// readConsent returns the consent screen model from resp.
+func readConsent(t *testing.T, resp events.APIGatewayProxyResponse) *ConsentModel {
+	var data ResponseModel
+	err := json.Unmarshal([]byte(resp.Body), &data)
+	assert.NoError(t, err)
+
+	return data.Consent
+}
+
+func TestHandler_GivenThirdPartyClient_RequiresConsent(t *testing.T) {
+	testUser := &dal.User{ID: "testUserId"}
+
+	t.Run("Given No Consent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h := buildClientSessionHandler(ctrl, testThirdPartyClient)
+		expectSession(ctrl, h, "my session id", testUser, 1600000000)
+
+		mockConsents := dalMock.NewMockConsentStore(ctrl)
+		mockConsents.EXPECT().Get(gomock.Any(), testUser.ID, testThirdPartyClient.ID).Return(nil, dal.ErrConsentNotFound)
+		h.consents = mockConsents
+
+		resp, err := h.Handle(context.Background(), buildSessionRequest(encodeSessionCookie(t, "my session id")))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, &ConsentModel{ClientName: "Third Party", Scopes: []string{"openid"}}, readConsent(t, resp))
+	})
+
+	t.Run("Given Consent Covering Request", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h := buildClientSessionHandler(ctrl, testThirdPartyClient)
+		expectSession(ctrl, h, "my session id", testUser, 1600000000)
+
+		mockConsents := dalMock.NewMockConsentStore(ctrl)
+		mockConsents.EXPECT().Get(gomock.Any(), testUser.ID, testThirdPartyClient.ID).Return(&dal.Consent{
+			UserID:   testUser.ID,
+			ClientID: testThirdPartyClient.ID,
+			Scopes:   []string{"openid", "profile"},
+		}, nil)
+		h.consents = mockConsents
+
+		resp, err := h.Handle(context.Background(), buildSessionRequest(encodeSessionCookie(t, "my session id")))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NotEmpty(t, readQuery(t, resp).Get("code"))
+	})
+
+	t.Run("Given Consent Not Covering Request", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h := buildClientSessionHandler(ctrl, testThirdPartyClient)
+		expectSession(ctrl, h, "my session id", testUser, 1600000000)
+
+		mockConsents := dalMock.NewMockConsentStore(ctrl)
+		mockConsents.EXPECT().Get(gomock.Any(), testUser.ID, testThirdPartyClient.ID).Return(&dal.Consent{
+			UserID:   testUser.ID,
+			ClientID: testThirdPartyClient.ID,
+			Scopes:   []string{"profile"},
+		}, nil)
+		h.consents = mockConsents
+
+		resp, err := h.Handle(context.Background(), buildSessionRequest(encodeSessionCookie(t, "my session id")))
+		assert.NoError(t, err)
+		assert.NotNil(t, readConsent(t, resp))
+	})
+
+	t.Run("Given Prompt None", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h := buildClientSessionHandler(ctrl, testThirdPartyClient)
+		expectSession(ctrl, h, "my session id", testUser, 1600000000)
+
+		mockConsents := dalMock.NewMockConsentStore(ctrl)
+		mockConsents.EXPECT().Get(gomock.Any(), testUser.ID, testThirdPartyClient.ID).Return(nil, dal.ErrConsentNotFound)
+		h.consents = mockConsents
+
+		req := withParams(buildSessionRequest(encodeSessionCookie(t, "my session id")), map[string]interface{}{"prompt": "none"})
+		resp, err := h.Handle(context.Background(), req)
+		assert.NoError(t, err)
+
+		values := readQuery(t, resp)
+		assert.Equal(t, "consent_required", values.Get("error"))
+		assert.Equal(t, "my state", values.Get("state"))
+	})
+}
+
+func TestHandler_GivenConsent_SavesConsentAndAuthorizes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	util.Freeze()
+	defer util.Reset()
+
+	testUser := &dal.User{ID: "testUserId"}
+
+	h := buildClientSessionHandler(ctrl, testThirdPartyClient)
+	expectSession(ctrl, h, "my session id", testUser, 1600000000)
+
+	// The user has already consented to other scopes, which are kept.
+	var saved *dal.Consent
+	mockConsents := dalMock.NewMockConsentStore(ctrl)
+	mockConsents.EXPECT().Get(gomock.Any(), testUser.ID, testThirdPartyClient.ID).Return(&dal.Consent{
+		UserID:   testUser.ID,
+		ClientID: testThirdPartyClient.ID,
+		Scopes:   []string{"profile"},
+	}, nil)
+	mockConsents.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, c *dal.Consent) error {
+		saved = c
+		return nil
+	})
+	h.consents = mockConsents
+
+	req := withParams(buildSessionRequest(encodeSessionCookie(t, "my session id")), map[string]interface{}{"consent": true})
+	resp, err := h.Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEmpty(t, readQuery(t, resp).Get("code"))
+
+	assert.Equal(t, &dal.Consent{
+		UserID:   testUser.ID,
+		ClientID: testThirdPartyClient.ID,
+		Scopes:   []string{"profile", "openid"},
+		Granted:  util.Time().Unix(),
+	}, saved)
+}
+
+func TestHandler_GivenRefusedConsent_RedirectsWithAccessDenied(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h := buildClientSessionHandler(ctrl, testThirdPartyClient)
+	expectSession(ctrl, h, "my session id", &dal.User{ID: "testUserId"}, 1600000000)
+
+	req := withParams(buildSessionRequest(encodeSessionCookie(t, "my session id")), map[string]interface{}{"consent": false})
+	resp, err := h.Handle(context.Background(), req)
+	assert.NoError(t, err)
+
+	values := readQuery(t, resp)
+	assert.Equal(t, "access_denied", values.Get("error"))
+	assert.Equal(t, "my state", values.Get("state"))
+}
+
+func TestHandler_GivenPromptConsent_RequiresConsent(t *testing.T) {
+	t.Run("Given Session", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// Consent is required, regardless of any the user has already given.
+		h := buildSessionHandler(ctrl)
+		expectSession(ctrl, h, "my session id", &dal.User{ID: "testUserId"}, 1600000000)
+
+		req := withParams(buildSessionRequest(encodeSessionCookie(t, "my session id")), map[string]interface{}{"prompt": "consent"})
+		resp, err := h.Handle(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, []string{"openid"}, readConsent(t, resp).Scopes)
+	})
+
+	t.Run("Given Credentials", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// The session isn't created until the user has consented.
+		h, _ := buildHybridHandler(ctrl, "id_token")
+		h.sessions = dalMock.NewMockSessionStore(ctrl)
+
+		req := withParams(buildHybridRequest("id_token"), map[string]interface{}{"prompt": "consent"})
+		resp, err := h.Handle(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NotNil(t, readConsent(t, resp))
+		assert.Empty(t, resp.Headers["Set-Cookie"])
+	})
 }
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws/session"
+
+	"github.com/reecerussell/goidc"
+	"github.com/reecerussell/goidc/dal"
+	"github.com/reecerussell/goidc/dal/dynamo"
+	"github.com/reecerussell/goidc/oauth"
+	"github.com/reecerussell/goidc/token"
+	"github.com/reecerussell/goidc/util"
+)
+
+var (
+	errMissingToken      = errors.New("missing access token")
+	errInvalidToken      = util.NewOAuthError(http.StatusUnauthorized, util.ErrorCodeInvalidToken, "invalid access token")
+	errThirdPartyToken   = util.NewOAuthError(http.StatusForbidden, util.ErrorCodeAccessDenied, "third-party clients cannot manage connected apps")
+	errClientToken       = util.NewOAuthError(http.StatusForbidden, util.ErrorCodeAccessDenied, "the access token was not issued on behalf of a user")
+	errInsufficientScope = util.NewOAuthError(http.StatusForbidden, util.ErrorCodeInsufficientScope, "the access token was not granted the connected_apps scope")
+	errMissingClientID   = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidRequest, "missing client id")
+)
+
+func main() {
+	log.Println("Starting...")
+
+	sess := session.Must(session.NewSession())
+
+	hdlr := &Handler{
+		sess:        sess,
+		tokens:      token.New(""), // The issuer is set per request.
+		revocations: dynamo.NewRevocationStore(sess),
+		clients:     dynamo.NewClientProvider(sess),
+		consents:    dynamo.NewConsentStore(sess),
+		refresh:     dynamo.NewRefreshTokenStore(sess),
+	}
+
+	lambda.Start(hdlr.Handle)
+}
+
+// Handler is used to provide a Lambda handler function.
+type Handler struct {
+	sess        *session.Session
+	tokens      token.Service
+	revocations dal.RevocationStore
+	clients     dal.ClientProvider
+	consents    dal.ConsentStore
+	refresh     dal.RefreshTokenStore
+}
+
+// ResponseModel represents the body of the response listing the user's apps.
+type ResponseModel struct {
+	Apps []*AppModel `json:"apps"`
+}
+
+// AppModel represents a client the user has consented to, and the
+// scopes they have allowed it to be authorized with.
+type AppModel struct {
+	ClientID string   `json:"clientId"`
+	Name     string   `json:"name"`
+	Scopes   []string `json:"scopes"`
+
+	// Granted is the unix timestamp at which the user last consented.
+	Granted int64 `json:"granted"`
+}
+
+// Handle lists the apps the user has consented to, or revokes one of them,
+// given a bearer access token issued to a first-party client on their behalf,
+// with the connected_apps scope.
+func (h *Handler) Handle(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if req.HTTPMethod != http.MethodGet && req.HTTPMethod != http.MethodDelete {
+		return util.RespondMethodNotAllowed(errors.New("method not allowed")), nil
+	}
+
+	ctx = goidc.NewContext(ctx, &req)
+	c := *h
+	c.tokens = oauth.IssuerTokens(ctx, req, h.tokens)
+	h = &c
+
+	userID, err := h.authenticate(ctx, req)
+	if err != nil {
+		if err == errMissingToken || err == errInvalidToken {
+			return unauthorized(err), nil
+		}
+
+		if err == errInsufficientScope {
+			resp := util.RespondOAuthError(err)
+			resp.Headers["WWW-Authenticate"] = `Bearer error="insufficient_scope", scope="` + oauth.ScopeConnectedApps + `"`
+			return resp, nil
+		}
+
+		if err == errThirdPartyToken || err == errClientToken {
+			return util.RespondOAuthError(err), nil
+		}
+
+		return util.RespondError(err), nil
+	}
+
+	if req.HTTPMethod == http.MethodDelete {
+		return h.revoke(ctx, userID, req.QueryStringParameters["clientId"])
+	}
+
+	return h.list(ctx, userID)
+}
+
+// authenticate returns the subject of req's bearer access token, which must not
+// have been revoked. Third-party clients may have been given access tokens by
+// the user, but must not be able to manage the user's consents, so the token
+// must have been issued to a first-party client, on behalf of a user, and
+// granted the connected_apps scope.
+func (h *Handler) authenticate(ctx context.Context, req events.APIGatewayProxyRequest) (string, error) {
+	accessToken := util.BearerToken(req)
+	if accessToken == "" {
+		return "", errMissingToken
+	}
+
+	alg, _ := token.NewKMSAlgorithm(h.sess, goidc.StageVariable(ctx, "JWT_KEY_ID"))
+	claims, err := h.tokens.VerifyToken(alg, accessToken, "goidc")
+	if err != nil {
+		log.Printf("Invalid access token: %v\n", err)
+		return "", errInvalidToken
+	}
+
+	if jti, ok := claims.String("jti"); ok {
+		revoked, err := h.revocations.IsRevoked(ctx, jti)
+		if err != nil {
+			return "", err
+		}
+
+		if revoked {
+			return "", errInvalidToken
+		}
+	}
+
+	sub, _ := claims.String("sub")
+	clientID, _ := claims.String("client_id")
+	if sub == "" || clientID == "" {
+		return "", errInvalidToken
+	}
+
+	// Tokens issued to a client for itself, such as using the client
+	// credentials grant, have the client as their subject.
+	if sub == clientID {
+		return "", errClientToken
+	}
+
+	if !hasScope(token.Scopes(claims), oauth.ScopeConnectedApps) {
+		return "", errInsufficientScope
+	}
+
+	c, err := h.clients.Get(ctx, clientID)
+	if err != nil {
+		if err == dal.ErrClientNotFound {
+			return "", errInvalidToken
+		}
+
+		return "", err
+	}
+
+	if c.ThirdParty {
+		return "", errThirdPartyToken
+	}
+
+	return sub, nil
+}
+
+// list returns the apps userID has consented to. Consents to clients
+// which have since been removed are left out.
+func (h *Handler) list(ctx context.Context, userID string) (events.APIGatewayProxyResponse, error) {
+	consents, err := h.consents.List(ctx, userID)
+	if err != nil {
+		return util.RespondError(err), nil
+	}
+
+	apps := make([]*AppModel, 0, len(consents))
+	for _, consent := range consents {
+		c, err := h.clients.Get(ctx, consent.ClientID)
+		if err != nil {
+			if err == dal.ErrClientNotFound {
+				continue
+			}
+
+			return util.RespondError(err), nil
+		}
+
+		apps = append(apps, &AppModel{
+			ClientID: c.ID,
+			Name:     c.Name,
+			Scopes:   consent.Scopes,
+			Granted:  consent.Granted,
+		})
+	}
+
+	return util.RespondOk(ResponseModel{Apps: apps}), nil
+}
+
+// revoke removes userID's consent to clientID, so they must consent again
+// the next time the client is authorized. The client's refresh tokens are
+// revoked first, so it cannot keep using the access it was given.
+func (h *Handler) revoke(ctx context.Context, userID, clientID string) (events.APIGatewayProxyResponse, error) {
+	if clientID == "" {
+		return util.RespondOAuthError(errMissingClientID), nil
+	}
+
+	err := h.refresh.RevokeClient(ctx, userID, clientID)
+	if err != nil {
+		return util.RespondError(err), nil
+	}
+
+	err = h.consents.Delete(ctx, userID, clientID)
+	if err != nil {
+		return util.RespondError(err), nil
+	}
+
+	return util.Respond(http.StatusNoContent, nil), nil
+}
+
+// unauthorized builds a 401 response, with a WWW-Authenticate header as
+// per RFC 6750. The error code is only included in the header for OAuth
+// errors, as requests without a token are simply challenged.
+func unauthorized(err error) events.APIGatewayProxyResponse {
+	oerr, ok := err.(*util.OAuthError)
+	if !ok {
+		resp := util.RespondUnauthorized(err)
+		resp.Headers["WWW-Authenticate"] = "Bearer"
+		return resp
+	}
+
+	resp := util.RespondOAuthError(oerr)
+	resp.Headers["WWW-Authenticate"] = `Bearer error="` + oerr.Code + `"`
+
+	return resp
+}
+
+// hasScope determines whether scope is in scopes.
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
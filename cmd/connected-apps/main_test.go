@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/awstesting/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/reecerussell/gojwt"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc/dal"
+	dalMock "github.com/reecerussell/goidc/dal/mock"
+	"github.com/reecerussell/goidc/oauth"
+	"github.com/reecerussell/goidc/token"
+	tokenMock "github.com/reecerussell/goidc/token/mock"
+	"github.com/reecerussell/goidc/util"
+)
+
+const (
+	testAccessToken = "my.access.token"
+	testUserID      = "23847"
+	testClientID    = "account-client"
+)
+
+func buildRequest(method string, params map[string]string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		HTTPMethod: method,
+		Headers: map[string]string{
+			"Authorization": "Bearer " + testAccessToken,
+		},
+		QueryStringParameters: params,
+		StageVariables: map[string]string{
+			"JWT_KEY_ID": "test key id",
+		},
+	}
+}
+
+// buildClaims returns the claims of an access token issued to clientID on
+// behalf of sub, with the connected_apps scope.
+func buildClaims(sub, clientID string) gojwt.Claims {
+	return gojwt.Claims{
+		"sub":       sub,
+		"client_id": clientID,
+		"scopes":    []interface{}{"openid", oauth.ScopeConnectedApps},
+	}
+}
+
+// buildHandler returns a handler which authenticates the test access token,
+// issued to a first-party client for the test user.
+func buildHandler(ctrl *gomock.Controller) (*Handler, *dalMock.MockClientProvider) {
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), testAccessToken, "goidc").
+		Return(buildClaims(testUserID, testClientID), nil)
+
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), testClientID).Return(&dal.Client{ID: testClientID}, nil)
+
+	return &Handler{
+		sess:     mock.Session,
+		tokens:   mockTokenService,
+		clients:  mockClientProvider,
+		consents: dalMock.NewMockConsentStore(ctrl),
+		refresh:  dalMock.NewMockRefreshTokenStore(ctrl),
+	}, mockClientProvider
+}
+
+func TestHandler_GivenGet_ReturnsApps(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h, mockClientProvider := buildHandler(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), "third-party-client").
+		Return(&dal.Client{ID: "third-party-client", Name: "Third Party", ThirdParty: true}, nil)
+	mockClientProvider.EXPECT().Get(gomock.Any(), "deleted-client").Return(nil, dal.ErrClientNotFound)
+
+	mockConsents := dalMock.NewMockConsentStore(ctrl)
+	mockConsents.EXPECT().List(gomock.Any(), testUserID).Return([]*dal.Consent{
+		{UserID: testUserID, ClientID: "third-party-client", Scopes: []string{"openid", "email"}, Granted: 1622505000},
+		{UserID: testUserID, ClientID: "deleted-client", Scopes: []string{"openid"}, Granted: 1622505000},
+	}, nil)
+	h.consents = mockConsents
+
+	resp, err := h.Handle(context.Background(), buildRequest(http.MethodGet, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data ResponseModel
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, []*AppModel{
+		{ClientID: "third-party-client", Name: "Third Party", Scopes: []string{"openid", "email"}, Granted: 1622505000},
+	}, data.Apps)
+}
+
+func TestHandler_GivenGetWithoutConsents_ReturnsEmptyList(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h, _ := buildHandler(ctrl)
+
+	mockConsents := dalMock.NewMockConsentStore(ctrl)
+	mockConsents.EXPECT().List(gomock.Any(), testUserID).Return([]*dal.Consent{}, nil)
+	h.consents = mockConsents
+
+	resp, err := h.Handle(context.Background(), buildRequest(http.MethodGet, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"apps":[]}`, resp.Body)
+}
+
+func TestHandler_GivenDelete_RevokesApp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h, _ := buildHandler(ctrl)
+
+	mockRefreshTokens := dalMock.NewMockRefreshTokenStore(ctrl)
+	revokeTokens := mockRefreshTokens.EXPECT().RevokeClient(gomock.Any(), testUserID, "third-party-client").Return(nil)
+	h.refresh = mockRefreshTokens
+
+	mockConsents := dalMock.NewMockConsentStore(ctrl)
+	mockConsents.EXPECT().Delete(gomock.Any(), testUserID, "third-party-client").Return(nil).After(revokeTokens)
+	h.consents = mockConsents
+
+	resp, err := h.Handle(context.Background(), buildRequest(http.MethodDelete, map[string]string{"clientId": "third-party-client"}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Empty(t, resp.Body)
+}
+
+func TestHandler_WhereRevokingTokensFails_KeepsConsent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h, _ := buildHandler(ctrl)
+
+	mockRefreshTokens := dalMock.NewMockRefreshTokenStore(ctrl)
+	mockRefreshTokens.EXPECT().RevokeClient(gomock.Any(), testUserID, "third-party-client").Return(errors.New("an error occured"))
+	h.refresh = mockRefreshTokens
+
+	resp, err := h.Handle(context.Background(), buildRequest(http.MethodDelete, map[string]string{"clientId": "third-party-client"}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
+
+func TestHandler_GivenDeleteWithoutClientID_ReturnsBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h, _ := buildHandler(ctrl)
+
+	resp, err := h.Handle(context.Background(), buildRequest(http.MethodDelete, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, resp.Body, errMissingClientID.Description)
+}
+
+func TestHandler_GivenNoToken_ReturnsUnauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	req := buildRequest(http.MethodGet, nil)
+	delete(req.Headers, "Authorization")
+
+	resp, err := (&Handler{tokens: tokenMock.NewIssuerService(ctrl, gomock.Any())}).Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "Bearer", resp.Headers["WWW-Authenticate"])
+}
+
+func TestHandler_GivenInvalidToken_ReturnsUnauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), testAccessToken, "goidc").Return(nil, token.ErrInvalidSignature)
+
+	h := &Handler{
+		sess:   mock.Session,
+		tokens: mockTokenService,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(http.MethodGet, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, `Bearer error="invalid_token"`, resp.Headers["WWW-Authenticate"])
+}
+
+func TestHandler_GivenRevokedToken_ReturnsUnauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), testAccessToken, "goidc").
+		Return(gojwt.Claims{"sub": testUserID, "client_id": testClientID, "jti": "2o3i4u2o3i4u"}, nil)
+
+	mockRevocations := dalMock.NewMockRevocationStore(ctrl)
+	mockRevocations.EXPECT().IsRevoked(gomock.Any(), "2o3i4u2o3i4u").Return(true, nil)
+
+	h := &Handler{
+		sess:        mock.Session,
+		tokens:      mockTokenService,
+		revocations: mockRevocations,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(http.MethodGet, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestHandler_GivenThirdPartyToken_ReturnsForbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), testAccessToken, "goidc").
+		Return(buildClaims(testUserID, "third-party-client"), nil)
+
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), "third-party-client").
+		Return(&dal.Client{ID: "third-party-client", ThirdParty: true}, nil)
+
+	// The consent must not be revoked.
+	h := &Handler{
+		sess:     mock.Session,
+		tokens:   mockTokenService,
+		clients:  mockClientProvider,
+		consents: dalMock.NewMockConsentStore(ctrl),
+		refresh:  dalMock.NewMockRefreshTokenStore(ctrl),
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(http.MethodDelete, map[string]string{"clientId": "third-party-client"}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Contains(t, resp.Body, "access_denied")
+}
+
+func TestHandler_GivenClientCredentialsToken_ReturnsForbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The client is the subject of tokens it was issued for itself.
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), testAccessToken, "goidc").
+		Return(buildClaims(testClientID, testClientID), nil)
+
+	h := &Handler{
+		sess:     mock.Session,
+		tokens:   mockTokenService,
+		clients:  dalMock.NewMockClientProvider(ctrl),
+		consents: dalMock.NewMockConsentStore(ctrl),
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(http.MethodGet, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	var data map[string]string
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, errClientToken.Code, data["error"])
+	assert.Equal(t, errClientToken.Description, data["error_description"])
+}
+
+func TestHandler_GivenTokenWithoutScope_ReturnsForbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), testAccessToken, "goidc").
+		Return(gojwt.Claims{"sub": testUserID, "client_id": testClientID, "scopes": []interface{}{"openid"}}, nil)
+
+	h := &Handler{
+		sess:     mock.Session,
+		tokens:   mockTokenService,
+		clients:  dalMock.NewMockClientProvider(ctrl),
+		consents: dalMock.NewMockConsentStore(ctrl),
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(http.MethodGet, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, `Bearer error="insufficient_scope", scope="connected_apps"`, resp.Headers["WWW-Authenticate"])
+
+	var data map[string]string
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, util.ErrorCodeInsufficientScope, data["error"])
+}
+
+func TestHandler_GivenInvalidMethod_ReturnsMethodNotAllowed(t *testing.T) {
+	resp, err := (&Handler{}).Handle(context.Background(), buildRequest(http.MethodPost, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws/session"
+
+	"github.com/reecerussell/goidc"
+	"github.com/reecerussell/goidc/clientauth"
+	"github.com/reecerussell/goidc/dal"
+	"github.com/reecerussell/goidc/dal/dynamo"
+	"github.com/reecerussell/goidc/jwk"
+	"github.com/reecerussell/goidc/oauth"
+	"github.com/reecerussell/goidc/token"
+	"github.com/reecerussell/goidc/util"
+	"github.com/reecerussell/goidc/validator"
+)
+
+const (
+	// codeExpiry is the lifetime of a device code, giving the user
+	// time to find another device and enter the user code.
+	codeExpiry = 10 * time.Minute
+
+	// pollingInterval is the minimum number of seconds the device
+	// must wait between requests to the token endpoint.
+	pollingInterval = 5
+)
+
+func main() {
+	log.Println("Starting...")
+
+	sess := session.Must(session.NewSession())
+
+	hdlr := &Handler{
+		clients:    dynamo.NewClientProvider(sess),
+		validator:  validator.NewClientValidator(),
+		codes:      dynamo.NewDeviceCodeStore(sess),
+		tokens:     token.New(""), // Only used to verify client assertions.
+		keys:       jwk.NewFetcher(),
+		assertions: dynamo.NewAssertionStore(sess),
+	}
+
+	lambda.Start(hdlr.Handle)
+}
+
+// Handler is used to provide a Lambda handler function.
+type Handler struct {
+	clients    dal.ClientProvider
+	validator  validator.ClientValidator
+	codes      dal.DeviceCodeStore
+	tokens     token.Service
+	keys       jwk.Fetcher
+	assertions dal.AssertionStore
+}
+
+// ResponseModel is a device authorization response, as defined by RFC 8628.
+type ResponseModel struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationUri         string `json:"verification_uri"`
+	VerificationUriComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+}
+
+func (h *Handler) Handle(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if req.HTTPMethod != http.MethodPost {
+		return util.RespondMethodNotAllowed(errors.New("method not allowed")), nil
+	}
+
+	if util.Header(req, "Content-Type") != "application/x-www-form-urlencoded" {
+		return util.RespondOAuthError(errors.New("invalid content type")), nil
+	}
+
+	data := util.ReadForm(req)
+
+	var scopes []string
+	if scope := data.Get("scope"); scope != "" {
+		scopes = strings.Split(scope, " ")
+	}
+
+	ctx = goidc.NewContext(ctx, &req)
+	client, err := h.authenticate(ctx, req, data, scopes)
+	if err != nil {
+		if _, ok := err.(*util.OAuthError); ok {
+			return util.RespondOAuthError(err), nil
+		}
+
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	code := &dal.DeviceCode{
+		DeviceCode: util.RandomString(32),
+		UserCode:   util.RandomUserCode(),
+		ClientID:   client.ID,
+		Scopes:     scopes,
+		Interval:   pollingInterval,
+		Expires:    util.Time().Add(codeExpiry).Unix(),
+	}
+
+	err = h.codes.Create(ctx, code)
+	if err != nil {
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	// The user code is entered on the login page, which completes the
+	// request instead of redirecting back to a client.
+	verificationUri := oauth.Issuer(ctx, req) + oauth.AuthorizationPath
+	query := url.Values{"user_code": {code.UserCode}}
+
+	return util.RespondOk(&ResponseModel{
+		DeviceCode:              code.DeviceCode,
+		UserCode:                code.UserCode,
+		VerificationUri:         verificationUri,
+		VerificationUriComplete: verificationUri + "?" + query.Encode(),
+		ExpiresIn:               int64(codeExpiry / time.Second),
+		Interval:                code.Interval,
+	}), nil
+}
+
+// authenticate authenticates the client which sent req, in the same way as the
+// token endpoint, and validates that it may use the device code grant. The
+// client's secret is validated, unless it authenticated using an assertion.
+func (h *Handler) authenticate(ctx context.Context, req events.APIGatewayProxyRequest, data url.Values, scopes []string) (*dal.Client, error) {
+	auth := clientauth.New(h.clients, h.validator, h.tokens, h.keys, h.assertions)
+	client, creds, err := auth.Authenticate(ctx, req, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if creds.Assertion != "" {
+		err = h.validator.ValidateGrantRequest(client, oauth.GrantTypeDeviceCode, scopes)
+	} else {
+		err = h.validator.ValidateTokenRequest(client, creds.Secret, oauth.GrantTypeDeviceCode, scopes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
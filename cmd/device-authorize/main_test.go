@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/golang/mock/gomock"
+	"github.com/reecerussell/gojwt"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc/dal"
+	dalMock "github.com/reecerussell/goidc/dal/mock"
+	"github.com/reecerussell/goidc/oauth"
+	tokenMock "github.com/reecerussell/goidc/token/mock"
+	"github.com/reecerussell/goidc/util"
+	"github.com/reecerussell/goidc/validator"
+	valMock "github.com/reecerussell/goidc/validator/mock"
+)
+
+const (
+	testClientId     = "3247023"
+	testClientSecret = "my secret"
+	testScope        = "openid email"
+)
+
+var testClient = &dal.Client{
+	ID:         testClientId,
+	GrantTypes: []string{oauth.GrantTypeDeviceCode},
+	Scopes:     []string{"openid", "email"},
+}
+
+func buildRequest(values url.Values) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+			"Host":         "example.com",
+		},
+		Body: values.Encode(),
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Stage: "prod",
+		},
+	}
+}
+
+func TestHandler_GivenValidRequest_ReturnsCodes(t *testing.T) {
+	util.Freeze()
+	defer util.Reset()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(testClient, oauth.TokenEndpointAuthMethodNone).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, "", oauth.GrantTypeDeviceCode, []string{"openid", "email"}).Return(nil)
+
+	var created *dal.DeviceCode
+	mockCodes := dalMock.NewMockDeviceCodeStore(ctrl)
+	mockCodes.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, c *dal.DeviceCode) error {
+		created = c
+		return nil
+	})
+
+	h := &Handler{
+		clients:   mockProvider,
+		validator: mockValidator,
+		codes:     mockCodes,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(url.Values{
+		"client_id": {testClientId},
+		"scope":     {testScope},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, testClientId, created.ClientID)
+	assert.Equal(t, []string{"openid", "email"}, created.Scopes)
+	assert.Equal(t, "", created.UserID)
+	assert.Equal(t, int64(pollingInterval), created.Interval)
+	assert.Equal(t, util.Time().Add(codeExpiry).Unix(), created.Expires)
+
+	var data ResponseModel
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, created.DeviceCode, data.DeviceCode)
+	assert.Equal(t, created.UserCode, data.UserCode)
+	assert.Equal(t, "https://example.com/prod/oauth/authorize", data.VerificationUri)
+	assert.Equal(t, "https://example.com/prod/oauth/authorize?user_code="+created.UserCode, data.VerificationUriComplete)
+	assert.Equal(t, int64(600), data.ExpiresIn)
+	assert.Equal(t, int64(pollingInterval), data.Interval)
+}
+
+func TestHandler_GivenInvalidClientId_ReturnsUnauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(nil, dal.ErrClientNotFound)
+
+	h := &Handler{clients: mockProvider}
+
+	resp, err := h.Handle(context.Background(), buildRequest(url.Values{"client_id": {testClientId}}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "{\"error\":\"invalid_client\",\"error_description\":\"invalid client id\"}", resp.Body)
+}
+
+func TestHandler_WithIssuerStageVariable_ReturnsVerificationUri(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(testClient, oauth.TokenEndpointAuthMethodNone).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, "", oauth.GrantTypeDeviceCode, nil).Return(nil)
+
+	mockCodes := dalMock.NewMockDeviceCodeStore(ctrl)
+	mockCodes.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+	h := &Handler{
+		clients:   mockProvider,
+		validator: mockValidator,
+		codes:     mockCodes,
+	}
+
+	req := buildRequest(url.Values{"client_id": {testClientId}})
+	req.StageVariables = map[string]string{"ISSUER": "https://id.example.com"}
+
+	resp, err := h.Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data ResponseModel
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, "https://id.example.com/oauth/authorize", data.VerificationUri)
+}
+
+func TestHandler_GivenBasicAuth_ValidatesSecret(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(testClient, oauth.TokenEndpointAuthMethodClientSecretBasic).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, testClientSecret, oauth.GrantTypeDeviceCode, nil).Return(validator.ErrInvalidSecret)
+
+	h := &Handler{
+		clients:   mockProvider,
+		validator: mockValidator,
+	}
+
+	req := buildRequest(url.Values{})
+	req.Headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(testClientId+":"+testClientSecret))
+
+	resp, err := h.Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestHandler_GivenClientAssertion_ReturnsCodes(t *testing.T) {
+	testAssertionClient := &dal.Client{
+		ID:                      testClientId,
+		GrantTypes:              []string{oauth.GrantTypeDeviceCode},
+		TokenEndpointAuthMethod: oauth.TokenEndpointAuthMethodClientSecretJWT,
+		SharedKey:               "my shared key",
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testAssertionClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateClientAssertion(testAssertionClient, gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateGrantRequest(testAssertionClient, oauth.GrantTypeDeviceCode, nil).Return(nil)
+
+	mockTokenService := tokenMock.NewMockService(ctrl)
+	mockTokenService.EXPECT().VerifyAssertion(gomock.Any(), "my.client.assertion", testClientId, "https://example.com/prod/oauth/token").
+		Return(gojwt.Claims{"jti": "29384", "exp": float64(1622505600)}, nil)
+
+	mockAssertions := dalMock.NewMockAssertionStore(ctrl)
+	mockAssertions.EXPECT().Use(gomock.Any(), &dal.UsedAssertion{ID: testClientId + "/29384", Expires: 1622505600}).Return(nil)
+
+	mockCodes := dalMock.NewMockDeviceCodeStore(ctrl)
+	mockCodes.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+	h := &Handler{
+		clients:    mockProvider,
+		validator:  mockValidator,
+		codes:      mockCodes,
+		tokens:     mockTokenService,
+		assertions: mockAssertions,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(url.Values{
+		"client_id":             {testClientId},
+		"client_assertion":      {"my.client.assertion"},
+		"client_assertion_type": {oauth.ClientAssertionTypeJWTBearer},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestHandler_GivenInvalidRequest_ReturnsBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(testClient, oauth.TokenEndpointAuthMethodNone).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, "", oauth.GrantTypeDeviceCode, []string{"admin"}).Return(validator.ErrInvalidScope)
+
+	h := &Handler{
+		clients:   mockProvider,
+		validator: mockValidator,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(url.Values{
+		"client_id": {testClientId},
+		"scope":     {"admin"},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "{\"error\":\"invalid_scope\",\"error_description\":\"invalid scope\"}", resp.Body)
+}
+
+func TestHandler_WhereCreateFails_ReturnsError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(testClient, oauth.TokenEndpointAuthMethodNone).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, "", oauth.GrantTypeDeviceCode, nil).Return(nil)
+
+	mockCodes := dalMock.NewMockDeviceCodeStore(ctrl)
+	mockCodes.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errors.New("an error occured"))
+
+	h := &Handler{
+		clients:   mockProvider,
+		validator: mockValidator,
+		codes:     mockCodes,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(url.Values{"client_id": {testClientId}}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
+
+func TestHandler_GivenInvalidMethod_ReturnsMethodNotAllowed(t *testing.T) {
+	req := buildRequest(url.Values{})
+	req.HTTPMethod = http.MethodGet
+
+	resp, err := (&Handler{}).Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
@@ -0,0 +1,11 @@
+module github.com/reecerussell/goidc/cmd/discovery
+
+go 1.15
+
+replace github.com/reecerussell/goidc v0.0.0 => ../../
+
+require (
+	github.com/aws/aws-lambda-go v1.23.0
+	github.com/reecerussell/goidc v0.0.0
+	github.com/stretchr/testify v1.7.0
+)
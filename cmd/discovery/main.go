@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/reecerussell/goidc"
+	"github.com/reecerussell/goidc/oauth"
+	"github.com/reecerussell/goidc/util"
+)
+
+func main() {
+	log.Println("Starting...")
+
+	hdlr := &Handler{}
+
+	lambda.Start(hdlr.Handle)
+}
+
+// Handler is used to provide a Lambda handler function.
+type Handler struct{}
+
+func (h *Handler) Handle(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if req.HTTPMethod != http.MethodGet {
+		return util.RespondMethodNotAllowed(errors.New("method not allowed")), nil
+	}
+
+	ctx = goidc.NewContext(ctx, &req)
+	discovery := oauth.NewDiscovery(oauth.Issuer(ctx, req))
+
+	return util.RespondOk(discovery), nil
+}
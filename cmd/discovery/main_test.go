@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc/oauth"
+)
+
+func TestHandler_ReturnsDiscoveryDocument(t *testing.T) {
+	h := &Handler{}
+
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		Headers: map[string]string{
+			"Host": "example.com",
+		},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Stage: "dev",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data oauth.Discovery
+	json.Unmarshal([]byte(resp.Body), &data)
+
+	assert.Equal(t, "https://example.com/dev", data.Issuer)
+	assert.Equal(t, "https://example.com/dev/oauth/authorize", data.AuthorizationEndpoint)
+	assert.Equal(t, "https://example.com/dev/oauth/token", data.TokenEndpoint)
+	assert.Equal(t, oauth.GrantTypes, data.GrantTypesSupported)
+	assert.Equal(t, oauth.ResponseTypes, data.ResponseTypesSupported)
+}
+
+func TestHandler_GivenIssuerStageVariable_ReturnsIssuer(t *testing.T) {
+	h := &Handler{}
+
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		Headers: map[string]string{
+			"Host": "abc123.execute-api.eu-west-2.amazonaws.com",
+		},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Stage: "dev",
+		},
+		StageVariables: map[string]string{
+			"ISSUER": "https://id.example.com",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data oauth.Discovery
+	json.Unmarshal([]byte(resp.Body), &data)
+
+	assert.Equal(t, "https://id.example.com", data.Issuer)
+	assert.Equal(t, "https://id.example.com/oauth/token", data.TokenEndpoint)
+}
+
+func TestHandler_GivenInvalidMethod_ReturnsMethodNotAllowed(t *testing.T) {
+	h := &Handler{}
+
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
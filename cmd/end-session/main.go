@@ -0,0 +1,425 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/reecerussell/gojwt"
+
+	"github.com/reecerussell/goidc"
+	"github.com/reecerussell/goidc/cookie"
+	"github.com/reecerussell/goidc/dal"
+	"github.com/reecerussell/goidc/dal/dynamo"
+	"github.com/reecerussell/goidc/logout"
+	"github.com/reecerussell/goidc/oauth"
+	"github.com/reecerussell/goidc/token"
+	"github.com/reecerussell/goidc/util"
+	"github.com/reecerussell/goidc/validator"
+)
+
+// loggedOutTemplate is shown to the user once they have been logged out, when
+// the client didn't ask for them to be redirected back to it, or clients must
+// be notified using their front-channel logout uris. These are rendered in
+// hidden iframes, after which the user is redirected, if required.
+var loggedOutTemplate = template.Must(template.New("logged_out").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Logged Out</title></head>
+<body>
+<p>You have been logged out.</p>
+{{range .FrontchannelUris}}<iframe src="{{.}}" style="display:none"></iframe>
+{{end}}{{if .RedirectUri}}<script>window.onload = function() { window.location.replace({{.RedirectUri}}); };</script>
+{{end}}</body>
+</html>
+`))
+
+// confirmTemplate asks the user to confirm they want to log out, when the
+// request could have been sent by any site. The confirmation is posted back to
+// the endpoint with the request's parameters, and as the session cookie is
+// only sent with same-site posts, it can't be forged by another site.
+var confirmTemplate = template.Must(template.New("confirm").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Log Out</title></head>
+<body>
+<form method="post" action="{{.Action}}">
+<p>Do you want to log out?</p>
+{{range $name, $value := .Params}}<input type="hidden" name="{{$name}}" value="{{$value}}"/>
+{{end}}<button type="submit">Log Out</button>
+</form>
+</body>
+</html>
+`))
+
+var (
+	errInvalidClient      = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidRequest, "invalid client id")
+	errInvalidIDTokenHint = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidRequest, "invalid id token hint")
+	errMissingClient      = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidRequest, "an id token hint or client id is required to redirect after logout")
+)
+
+func main() {
+	log.Println("Starting...")
+
+	sess := session.Must(session.NewSession())
+
+	hdlr := &Handler{
+		sess:      sess,
+		tokens:    token.New(""), // The issuer is set per request.
+		clients:   dynamo.NewClientProvider(sess),
+		clientVal: validator.NewClientValidator(),
+		sessions:  dynamo.NewSessionStore(sess),
+		sender:    logout.NewSender(),
+	}
+
+	lambda.Start(hdlr.Handle)
+}
+
+// Handler is used to provide a Lambda handler function.
+type Handler struct {
+	sess      *session.Session
+	tokens    token.Service
+	clients   dal.ClientProvider
+	clientVal validator.ClientValidator
+	sessions  dal.SessionStore
+	sender    logout.Sender
+}
+
+// Handle logs the user out, as defined by OpenID Connect RP-Initiated Logout,
+// by ending their single sign-on session and notifying the clients which were
+// authorized during it. The parameters may be given in the query string, or
+// as a form post. Without a valid id_token_hint, the user must confirm the
+// logout, which is then posted back.
+func (h *Handler) Handle(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var params url.Values
+	switch req.HTTPMethod {
+	case http.MethodGet:
+		params = query(req)
+	case http.MethodPost:
+		if util.Header(req, "Content-Type") != "application/x-www-form-urlencoded" {
+			return util.RespondOAuthError(errors.New("invalid content type")), nil
+		}
+
+		params = util.ReadForm(req)
+	default:
+		return util.RespondMethodNotAllowed(errors.New("method not allowed")), nil
+	}
+
+	ctx = goidc.NewContext(ctx, &req)
+	issuer := oauth.Issuer(ctx, req)
+	c := *h
+	c.tokens = oauth.IssuerTokens(ctx, req, h.tokens)
+	h = &c
+
+	client, subject, err := h.logoutClient(ctx, params)
+	if err != nil {
+		if err == errInvalidClient || err == errInvalidIDTokenHint {
+			return util.RespondOAuthError(err), nil
+		}
+
+		return util.RespondError(err), nil
+	}
+
+	redirectUri := params.Get("post_logout_redirect_uri")
+	if redirectUri != "" {
+		// Without knowing the client, the redirect uri cannot be trusted.
+		if client == nil {
+			return util.RespondOAuthError(errMissingClient), nil
+		}
+
+		err = h.clientVal.ValidateLogoutRequest(client, redirectUri)
+		if err != nil {
+			return util.RespondOAuthError(err), nil
+		}
+	}
+
+	// Without a valid hint, the request isn't known to come from a client
+	// the user has used, so sessions are only ended by a post.
+	if subject == "" && req.HTTPMethod == http.MethodGet && util.Cookie(req, cookie.SessionName) != "" {
+		resp, err := confirmLogout(issuer+oauth.EndSessionPath, params)
+		if err != nil {
+			return util.RespondError(err), nil
+		}
+
+		return resp, nil
+	}
+
+	sess, clearCookie, err := h.endSession(ctx, req, subject)
+	if err != nil {
+		return util.RespondError(err), nil
+	}
+
+	var frontchannelUris []string
+	if sess != nil {
+		frontchannelUris = h.notifyClients(ctx, issuer, sess)
+	}
+
+	resp, err := loggedOut(redirectUri, params.Get("state"), frontchannelUris)
+	if err != nil {
+		return util.RespondError(err), nil
+	}
+
+	if clearCookie {
+		resp.Headers["Set-Cookie"] = expiredSessionCookie()
+	}
+
+	return resp, nil
+}
+
+// logoutClient returns the client requesting the logout, identified by the
+// client_id parameter, or the id_token_hint, along with the hint's subject.
+// The client is nil if neither parameter is given.
+func (h *Handler) logoutClient(ctx context.Context, params url.Values) (*dal.Client, string, error) {
+	clientID := params.Get("client_id")
+	hint := params.Get("id_token_hint")
+
+	// The hint must be decoded to find the client it is verified for.
+	if hint != "" && clientID == "" {
+		claims, err := token.UnverifiedClaims(hint)
+		if err != nil {
+			return nil, "", errInvalidIDTokenHint
+		}
+
+		clientID, _ = claims.String("azp")
+		if clientID == "" {
+			clientID, _ = claims.String("aud")
+		}
+	}
+
+	if clientID == "" {
+		return nil, "", nil
+	}
+
+	client, err := h.clients.Get(ctx, clientID)
+	if err != nil {
+		if err == dal.ErrClientNotFound {
+			return nil, "", errInvalidClient
+		}
+
+		return nil, "", err
+	}
+
+	if hint == "" {
+		return client, "", nil
+	}
+
+	// The hint is accepted after it has expired, as the user
+	// may have been logged in for longer than its lifetime.
+	alg, _ := token.NewKMSAlgorithm(h.sess, goidc.StageVariable(ctx, "JWT_KEY_ID"))
+	claims, err := h.tokens.VerifyTokenIgnoringExpiry(alg, hint, client.ID)
+	if err != nil {
+		log.Printf("Invalid id token hint: %v\n", err)
+		return nil, "", errInvalidIDTokenHint
+	}
+
+	sub, ok := claims.String("sub")
+	if !ok || sub == "" {
+		return nil, "", errInvalidIDTokenHint
+	}
+
+	return client, sub, nil
+}
+
+// endSession deletes the session in req's session cookie, returning the
+// deleted session, if any, and whether the cookie should be cleared. If subject
+// is given, sessions belonging to other users are kept, as they are not the
+// user the client is logging out.
+func (h *Handler) endSession(ctx context.Context, req events.APIGatewayProxyRequest, subject string) (*dal.Session, bool, error) {
+	value := util.Cookie(req, cookie.SessionName)
+	if value == "" {
+		return nil, false, nil
+	}
+
+	codec, err := cookie.NewSessionCodec(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	id, err := codec.Decode(cookie.SessionName, value)
+	if err != nil {
+		return nil, true, nil
+	}
+
+	sess, err := h.sessions.Get(ctx, id)
+	if err != nil {
+		if err == dal.ErrSessionNotFound {
+			return nil, true, nil
+		}
+
+		return nil, false, err
+	}
+
+	if subject != "" && sess.UserID != subject {
+		return nil, false, nil
+	}
+
+	err = h.sessions.Delete(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return sess, true, nil
+}
+
+// notifyClients notifies the clients authorized during sess that the user has
+// logged out. Logout tokens are sent to their back-channel logout uris, while
+// their front-channel logout uris are returned, to be rendered by the user's
+// browser. Failing to notify a client doesn't stop the user logging out.
+func (h *Handler) notifyClients(ctx context.Context, issuer string, sess *dal.Session) []string {
+	alg, _ := token.NewKMSAlgorithm(h.sess, goidc.StageVariable(ctx, "JWT_KEY_ID"))
+
+	var (
+		wg               sync.WaitGroup
+		frontchannelUris []string
+	)
+
+	for _, id := range sess.ClientIDs {
+		c, err := h.clients.Get(ctx, id)
+		if err != nil {
+			log.Printf("Failed to get client %s: %v\n", id, err)
+			continue
+		}
+
+		if c.FrontchannelLogoutUri != "" {
+			frontchannelUris = append(frontchannelUris, logout.FrontchannelUri(c, issuer, sess.ID))
+		}
+
+		if c.BackchannelLogoutUri == "" {
+			continue
+		}
+
+		// Tokens are sent concurrently, as each may be retried.
+		wg.Add(1)
+		go func(c *dal.Client) {
+			defer wg.Done()
+
+			err := h.backchannelLogout(ctx, alg, c, sess)
+			if err != nil {
+				log.Printf("Failed to notify client %s of logout: %v\n", c.ID, err)
+			}
+		}(c)
+	}
+
+	wg.Wait()
+
+	return frontchannelUris
+}
+
+// backchannelLogout sends a logout token for sess to c's back-channel logout uri.
+func (h *Handler) backchannelLogout(ctx context.Context, alg gojwt.Algorithm, c *dal.Client, sess *dal.Session) error {
+	claims := logout.TokenClaims(sess.UserID, sess.ID)
+	jwt, err := h.tokens.GenerateToken(alg, claims, logout.TokenExpiry, c.ID)
+	if err != nil {
+		return err
+	}
+
+	return h.sender.Send(ctx, c.BackchannelLogoutUri, jwt.AccessToken)
+}
+
+// loggedOut returns the response once the user has been logged out. If the
+// client gave a redirect uri, the user is redirected to it with the state,
+// once the page has rendered the given front-channel logout uris.
+func loggedOut(redirectUri, state string, frontchannelUris []string) (events.APIGatewayProxyResponse, error) {
+	if redirectUri != "" && state != "" {
+		// The redirect uri may already have a query component, which must be kept.
+		separator := "?"
+		if strings.Contains(redirectUri, "?") {
+			separator = "&"
+		}
+
+		redirectUri += separator + url.Values{"state": {state}}.Encode()
+	}
+
+	if redirectUri != "" && len(frontchannelUris) < 1 {
+		return events.APIGatewayProxyResponse{
+			StatusCode: http.StatusFound,
+			Headers: map[string]string{
+				"Location": redirectUri,
+			},
+		}, nil
+	}
+
+	var buf bytes.Buffer
+	err := loggedOutTemplate.Execute(&buf, struct {
+		RedirectUri      string
+		FrontchannelUris []string
+	}{redirectUri, frontchannelUris})
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type": "text/html; charset=utf-8",
+		},
+		Body: buf.String(),
+	}, nil
+}
+
+// confirmLogout returns a page asking the user to confirm they want to log
+// out, which posts the client's parameters back to action.
+func confirmLogout(action string, params url.Values) (events.APIGatewayProxyResponse, error) {
+	fields := make(map[string]string)
+	for _, name := range []string{"client_id", "post_logout_redirect_uri", "state"} {
+		if value := params.Get(name); value != "" {
+			fields[name] = value
+		}
+	}
+
+	var buf bytes.Buffer
+	err := confirmTemplate.Execute(&buf, struct {
+		Action string
+		Params map[string]string
+	}{action, fields})
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type": "text/html; charset=utf-8",
+		},
+		Body: buf.String(),
+	}, nil
+}
+
+// expiredSessionCookie returns the Set-Cookie header value
+// which removes the session cookie from the browser.
+func expiredSessionCookie() string {
+	c := &http.Cookie{
+		Name:     cookie.SessionName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	}
+
+	return c.String()
+}
+
+// query returns the query string parameters of req.
+func query(req events.APIGatewayProxyRequest) url.Values {
+	params := url.Values{}
+	for name, values := range req.MultiValueQueryStringParameters {
+		params[name] = values
+	}
+
+	for name, value := range req.QueryStringParameters {
+		if _, ok := params[name]; !ok {
+			params.Set(name, value)
+		}
+	}
+
+	return params
+}
@@ -0,0 +1,520 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/awstesting/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/reecerussell/gojwt"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc/cookie"
+	"github.com/reecerussell/goidc/dal"
+	dalMock "github.com/reecerussell/goidc/dal/mock"
+	"github.com/reecerussell/goidc/logout"
+	logoutMock "github.com/reecerussell/goidc/logout/mock"
+	"github.com/reecerussell/goidc/token"
+	tokenMock "github.com/reecerussell/goidc/token/mock"
+	"github.com/reecerussell/goidc/validator"
+	valMock "github.com/reecerussell/goidc/validator/mock"
+)
+
+const (
+	testClientId    = "3247023"
+	testUserId      = "testUserId"
+	testSessionId   = "my session id"
+	testRedirectUri = "https://client.example.com/logged-out"
+
+	// testSessionKey is the base64 encoded key used to encode session cookies.
+	testSessionKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+)
+
+var testClient = &dal.Client{
+	ID:                     testClientId,
+	PostLogoutRedirectUris: []string{testRedirectUri},
+}
+
+// buildHint returns an unsigned ID token with the given claims,
+// as the signature is verified by the mock token service.
+func buildHint(claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256"})
+	payload, _ := json.Marshal(claims)
+
+	return base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload) + ".c2lnbmF0dXJl"
+}
+
+func encodeSessionCookie(t *testing.T, id string) string {
+	key, _ := base64.StdEncoding.DecodeString(testSessionKey)
+	codec, err := cookie.NewCodec(key)
+	assert.NoError(t, err)
+
+	value, err := codec.Encode(cookie.SessionName, id)
+	assert.NoError(t, err)
+
+	return value
+}
+
+func buildRequest(params map[string]string, sessionCookie string) events.APIGatewayProxyRequest {
+	req := events.APIGatewayProxyRequest{
+		HTTPMethod:            http.MethodGet,
+		Headers:               map[string]string{},
+		QueryStringParameters: params,
+		StageVariables: map[string]string{
+			"JWT_KEY_ID":  "test key id",
+			"SESSION_KEY": testSessionKey,
+		},
+	}
+
+	if sessionCookie != "" {
+		req.Headers["Cookie"] = cookie.SessionName + "=" + sessionCookie
+	}
+
+	return req
+}
+
+// buildFormRequest returns a request posting params, as the
+// confirmation page does.
+func buildFormRequest(params url.Values, sessionCookie string) events.APIGatewayProxyRequest {
+	req := buildRequest(nil, sessionCookie)
+	req.HTTPMethod = http.MethodPost
+	req.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+	req.Body = params.Encode()
+
+	return req
+}
+
+// buildHandler returns a handler which expects the session to be
+// found and deleted, once the request has been validated.
+func buildHandler(ctrl *gomock.Controller) *Handler {
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil).AnyTimes()
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyTokenIgnoringExpiry(gomock.Any(), gomock.Any(), testClientId).
+		Return(gojwt.Claims{"sub": testUserId}, nil).AnyTimes()
+
+	mockSessions := dalMock.NewMockSessionStore(ctrl)
+	mockSessions.EXPECT().Get(gomock.Any(), testSessionId).
+		Return(&dal.Session{ID: testSessionId, UserID: testUserId}, nil).AnyTimes()
+	mockSessions.EXPECT().Delete(gomock.Any(), testSessionId).Return(nil)
+
+	mockClientValidator := valMock.NewMockClientValidator(ctrl)
+	mockClientValidator.EXPECT().ValidateLogoutRequest(testClient, testRedirectUri).Return(nil).AnyTimes()
+
+	return &Handler{
+		sess:      mock.Session,
+		tokens:    mockTokenService,
+		clients:   mockClientProvider,
+		clientVal: mockClientValidator,
+		sessions:  mockSessions,
+	}
+}
+
+func buildClientValidator(ctrl *gomock.Controller) *valMock.MockClientValidator {
+	mockClientValidator := valMock.NewMockClientValidator(ctrl)
+	mockClientValidator.EXPECT().ValidateLogoutRequest(testClient, testRedirectUri).Return(nil)
+
+	return mockClientValidator
+}
+
+func readSetCookie(resp events.APIGatewayProxyResponse) []*http.Cookie {
+	header := http.Header{"Set-Cookie": {resp.Headers["Set-Cookie"]}}
+	return (&http.Response{Header: header}).Cookies()
+}
+
+func TestHandler_GivenIDTokenHintAndRedirectUri_EndsSessionAndRedirects(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h := buildHandler(ctrl)
+
+	req := buildRequest(map[string]string{
+		"id_token_hint":            buildHint(map[string]interface{}{"azp": testClientId, "sub": testUserId}),
+		"post_logout_redirect_uri": testRedirectUri,
+		"state":                    "my state",
+	}, encodeSessionCookie(t, testSessionId))
+
+	resp, err := h.Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, testRedirectUri+"?state=my+state", resp.Headers["Location"])
+
+	cookies := readSetCookie(resp)
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, cookie.SessionName, cookies[0].Name)
+	assert.Equal(t, "", cookies[0].Value)
+	assert.True(t, cookies[0].MaxAge < 0)
+}
+
+func TestHandler_GivenClientIdAndRedirectUri_ShowsConfirmation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	// The session must not be ended until the user confirms.
+	h := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockClientProvider,
+		clientVal: buildClientValidator(ctrl),
+		sessions:  dalMock.NewMockSessionStore(ctrl),
+	}
+
+	req := buildRequest(map[string]string{
+		"client_id":                testClientId,
+		"post_logout_redirect_uri": testRedirectUri,
+		"state":                    "<script>",
+	}, encodeSessionCookie(t, testSessionId))
+	req.Headers["Host"] = "example.com"
+	req.RequestContext.Stage = "prod"
+
+	resp, err := h.Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/html; charset=utf-8", resp.Headers["Content-Type"])
+	assert.Contains(t, resp.Body, `<form method="post" action="https://example.com/prod/oauth/end_session">`)
+	assert.Contains(t, resp.Body, `<input type="hidden" name="client_id" value="3247023"/>`)
+	assert.Contains(t, resp.Body, `<input type="hidden" name="post_logout_redirect_uri" value="https://client.example.com/logged-out"/>`)
+	assert.Contains(t, resp.Body, `<input type="hidden" name="state" value="&lt;script&gt;"/>`)
+	assert.Empty(t, resp.Headers["Set-Cookie"])
+}
+
+func TestHandler_GivenNoParameters_ShowsConfirmation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h := &Handler{tokens: tokenMock.NewIssuerService(ctrl, gomock.Any()), sessions: dalMock.NewMockSessionStore(ctrl)}
+
+	resp, err := h.Handle(context.Background(), buildRequest(nil, encodeSessionCookie(t, testSessionId)))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Body, "Do you want to log out?")
+	assert.NotContains(t, resp.Body, `type="hidden"`)
+	assert.Empty(t, resp.Headers["Set-Cookie"])
+}
+
+func TestHandler_GivenConfirmationWithoutParameters_EndsSessionAndShowsPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h := buildHandler(ctrl)
+
+	resp, err := h.Handle(context.Background(), buildFormRequest(url.Values{}, encodeSessionCookie(t, testSessionId)))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/html; charset=utf-8", resp.Headers["Content-Type"])
+	assert.Contains(t, resp.Body, "You have been logged out.")
+	assert.NotContains(t, resp.Body, "<script>")
+	assert.Len(t, readSetCookie(resp), 1)
+}
+
+func TestHandler_GivenFormPost_EndsSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h := buildHandler(ctrl)
+
+	req := buildFormRequest(url.Values{
+		"client_id":                {testClientId},
+		"post_logout_redirect_uri": {testRedirectUri},
+	}, encodeSessionCookie(t, testSessionId))
+
+	resp, err := h.Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, testRedirectUri, resp.Headers["Location"])
+}
+
+func TestHandler_GivenInvalidMethod_ReturnsMethodNotAllowed(t *testing.T) {
+	req := buildRequest(nil, "")
+	req.HTTPMethod = http.MethodPut
+
+	resp, err := (&Handler{}).Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestHandler_GivenUnregisteredRedirectUri_ReturnsBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockClientValidator := valMock.NewMockClientValidator(ctrl)
+	mockClientValidator.EXPECT().ValidateLogoutRequest(testClient, "https://evil.example.com").
+		Return(validator.ErrInvalidPostLogoutRedirectUri)
+
+	// The session must not be ended.
+	h := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockClientProvider,
+		clientVal: mockClientValidator,
+		sessions:  dalMock.NewMockSessionStore(ctrl),
+	}
+
+	req := buildRequest(map[string]string{
+		"client_id":                testClientId,
+		"post_logout_redirect_uri": "https://evil.example.com",
+	}, encodeSessionCookie(t, testSessionId))
+
+	resp, err := h.Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var data map[string]string
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, validator.ErrInvalidPostLogoutRedirectUri.Code, data["error"])
+	assert.Equal(t, validator.ErrInvalidPostLogoutRedirectUri.Description, data["error_description"])
+}
+
+func TestHandler_GivenRedirectUriWithoutClient_ReturnsBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h := &Handler{tokens: tokenMock.NewIssuerService(ctrl, gomock.Any()), sessions: dalMock.NewMockSessionStore(ctrl)}
+
+	req := buildRequest(map[string]string{
+		"post_logout_redirect_uri": testRedirectUri,
+	}, encodeSessionCookie(t, testSessionId))
+
+	resp, err := h.Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, resp.Body, errMissingClient.Description)
+}
+
+func TestHandler_GivenInvalidIDTokenHint_ReturnsBadRequest(t *testing.T) {
+	t.Run("Given Malformed Hint", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		req := buildRequest(map[string]string{"id_token_hint": "not a token"}, "")
+
+		resp, err := (&Handler{tokens: tokenMock.NewIssuerService(ctrl, gomock.Any())}).Handle(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Contains(t, resp.Body, errInvalidIDTokenHint.Description)
+	})
+
+	t.Run("Given Hint With Invalid Signature", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+		mockClientProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+		mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+		mockTokenService.EXPECT().VerifyTokenIgnoringExpiry(gomock.Any(), gomock.Any(), testClientId).
+			Return(nil, token.ErrInvalidSignature)
+
+		h := &Handler{
+			sess:     mock.Session,
+			tokens:   mockTokenService,
+			clients:  mockClientProvider,
+			sessions: dalMock.NewMockSessionStore(ctrl),
+		}
+
+		req := buildRequest(map[string]string{
+			"id_token_hint": buildHint(map[string]interface{}{"azp": testClientId, "sub": testUserId}),
+		}, encodeSessionCookie(t, testSessionId))
+
+		resp, err := h.Handle(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Contains(t, resp.Body, errInvalidIDTokenHint.Description)
+	})
+}
+
+func TestHandler_GivenUnknownClient_ReturnsBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), "unknown").Return(nil, dal.ErrClientNotFound)
+
+	h := &Handler{tokens: tokenMock.NewIssuerService(ctrl, gomock.Any()), clients: mockClientProvider}
+
+	resp, err := h.Handle(context.Background(), buildRequest(map[string]string{"client_id": "unknown"}, ""))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, resp.Body, errInvalidClient.Description)
+}
+
+func TestHandler_GivenHintForAnotherUser_KeepsSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyTokenIgnoringExpiry(gomock.Any(), gomock.Any(), testClientId).
+		Return(gojwt.Claims{"sub": "otherUserId"}, nil)
+
+	mockSessions := dalMock.NewMockSessionStore(ctrl)
+	mockSessions.EXPECT().Get(gomock.Any(), testSessionId).
+		Return(&dal.Session{ID: testSessionId, UserID: testUserId}, nil)
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    mockTokenService,
+		clients:   mockClientProvider,
+		clientVal: buildClientValidator(ctrl),
+		sessions:  mockSessions,
+	}
+
+	req := buildRequest(map[string]string{
+		"id_token_hint":            buildHint(map[string]interface{}{"azp": testClientId, "sub": "otherUserId"}),
+		"post_logout_redirect_uri": testRedirectUri,
+	}, encodeSessionCookie(t, testSessionId))
+
+	resp, err := h.Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Empty(t, resp.Headers["Set-Cookie"])
+}
+
+func TestHandler_GivenNoSessionCookie_Redirects(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	h := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockClientProvider,
+		clientVal: buildClientValidator(ctrl),
+		sessions:  dalMock.NewMockSessionStore(ctrl),
+	}
+
+	req := buildRequest(map[string]string{
+		"client_id":                testClientId,
+		"post_logout_redirect_uri": testRedirectUri,
+	}, "")
+
+	resp, err := h.Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Empty(t, resp.Headers["Set-Cookie"])
+}
+
+func TestHandler_GivenSessionWithClients_NotifiesClients(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	backchannelClient := &dal.Client{
+		ID:                   "backchannel client",
+		BackchannelLogoutUri: "https://backchannel.example.com/logout",
+	}
+	frontchannelClient := &dal.Client{
+		ID:                                "frontchannel client",
+		FrontchannelLogoutUri:             "https://frontchannel.example.com/logout",
+		FrontchannelLogoutSessionRequired: true,
+	}
+
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil).Times(2)
+	mockClientProvider.EXPECT().Get(gomock.Any(), backchannelClient.ID).Return(backchannelClient, nil)
+	mockClientProvider.EXPECT().Get(gomock.Any(), frontchannelClient.ID).Return(frontchannelClient, nil)
+	mockClientProvider.EXPECT().Get(gomock.Any(), "deleted client").Return(nil, dal.ErrClientNotFound)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().
+		GenerateToken(gomock.Any(), logout.TokenClaims(testUserId, testSessionId), logout.TokenExpiry, backchannelClient.ID).
+		Return(&token.Token{AccessToken: "my logout token"}, nil)
+
+	mockSessions := dalMock.NewMockSessionStore(ctrl)
+	mockSessions.EXPECT().Get(gomock.Any(), testSessionId).Return(&dal.Session{
+		ID:        testSessionId,
+		UserID:    testUserId,
+		ClientIDs: []string{testClientId, backchannelClient.ID, frontchannelClient.ID, "deleted client"},
+	}, nil)
+	mockSessions.EXPECT().Delete(gomock.Any(), testSessionId).Return(nil)
+
+	mockSender := logoutMock.NewMockSender(ctrl)
+	mockSender.EXPECT().Send(gomock.Any(), backchannelClient.BackchannelLogoutUri, "my logout token").Return(nil)
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    mockTokenService,
+		clients:   mockClientProvider,
+		clientVal: buildClientValidator(ctrl),
+		sessions:  mockSessions,
+		sender:    mockSender,
+	}
+
+	req := buildFormRequest(url.Values{
+		"client_id":                {testClientId},
+		"post_logout_redirect_uri": {testRedirectUri},
+		"state":                    {"my state"},
+	}, encodeSessionCookie(t, testSessionId))
+
+	resp, err := h.Handle(context.Background(), req)
+	assert.NoError(t, err)
+
+	// The user is redirected once the front-channel logout uris have been rendered.
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Body, `<iframe src="https://frontchannel.example.com/logout?iss=`)
+	assert.Contains(t, resp.Body, "sid=my&#43;session&#43;id")
+	assert.Contains(t, resp.Body, `window.location.replace("https://client.example.com/logged-out?state=my+state")`)
+	assert.Len(t, readSetCookie(resp), 1)
+}
+
+func TestHandler_GivenBackchannelLogoutFails_EndsSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	backchannelClient := &dal.Client{
+		ID:                   "backchannel client",
+		BackchannelLogoutUri: "https://backchannel.example.com/logout",
+	}
+
+	mockClientProvider := dalMock.NewMockClientProvider(ctrl)
+	mockClientProvider.EXPECT().Get(gomock.Any(), backchannelClient.ID).Return(backchannelClient, nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), logout.TokenExpiry, backchannelClient.ID).
+		Return(&token.Token{AccessToken: "my logout token"}, nil)
+
+	mockSessions := dalMock.NewMockSessionStore(ctrl)
+	mockSessions.EXPECT().Get(gomock.Any(), testSessionId).Return(&dal.Session{
+		ID:        testSessionId,
+		UserID:    testUserId,
+		ClientIDs: []string{backchannelClient.ID},
+	}, nil)
+	mockSessions.EXPECT().Delete(gomock.Any(), testSessionId).Return(nil)
+
+	mockSender := logoutMock.NewMockSender(ctrl)
+	mockSender.EXPECT().Send(gomock.Any(), backchannelClient.BackchannelLogoutUri, "my logout token").
+		Return(errors.New("unreachable"))
+
+	h := &Handler{
+		sess:     mock.Session,
+		tokens:   mockTokenService,
+		clients:  mockClientProvider,
+		sessions: mockSessions,
+		sender:   mockSender,
+	}
+
+	resp, err := h.Handle(context.Background(), buildFormRequest(url.Values{}, encodeSessionCookie(t, testSessionId)))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, readSetCookie(resp), 1)
+}
+
+func TestLoggedOut_GivenRedirectUriWithQuery_KeepsQuery(t *testing.T) {
+	resp, err := loggedOut("https://client.example.com/logged-out?foo=bar", "my state", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://client.example.com/logged-out?foo=bar&state=my+state", resp.Headers["Location"])
+}
@@ -5,43 +5,102 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/reecerussell/goidc"
-	"github.com/reecerussell/gojwt/kms"
+	"github.com/reecerussell/gojwt"
 
+	"github.com/reecerussell/goidc/clientauth"
 	"github.com/reecerussell/goidc/dal"
 	"github.com/reecerussell/goidc/dal/dynamo"
+	"github.com/reecerussell/goidc/jwk"
+	"github.com/reecerussell/goidc/oauth"
 	"github.com/reecerussell/goidc/token"
 	"github.com/reecerussell/goidc/util"
 	"github.com/reecerussell/goidc/validator"
 )
 
+// refreshTokenExpiry is the lifetime of a refresh token family. Rotated
+// refresh tokens inherit the expiry of the token they replace.
+const refreshTokenExpiry = 30 * 24 * time.Hour
+
+// slowDownInterval is the number of seconds added to a device's polling
+// interval each time it polls too frequently, as defined by RFC 8628.
+const slowDownInterval = 5
+
+// Token exchange errors, returned when the subject token
+// cannot be exchanged, as defined by RFC 8693.
+var (
+	errInvalidSubjectToken  = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidRequest, "invalid subject token")
+	errUnsupportedTokenType = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidRequest, "unsupported token type")
+	errExpiredSubjectToken  = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidGrant, "subject token has expired")
+)
+
+// errUnsupportedGrantType is returned when the grant type
+// isn't one the token endpoint supports.
+var errUnsupportedGrantType = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeUnsupportedGrantType, "unsupported grant type")
+
+// errInvalidAssertion is returned when the assertion given using the JWT
+// bearer grant is invalid, or was not issued by a trusted issuer.
+var errInvalidAssertion = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidGrant, "invalid assertion")
+
+// errInvalidCredentials is returned when the resource owner's
+// credentials are invalid, using the password grant.
+var errInvalidCredentials = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidGrant, "email and/or password is invalid")
+
+// Device code grant errors, as defined by RFC 8628. These are returned to the
+// device as error codes, so it knows whether to continue polling.
+var (
+	errAuthorizationPending = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeAuthorizationPending, "")
+	errSlowDown             = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeSlowDown, "")
+	errExpiredToken         = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeExpiredToken, "")
+)
+
 func main() {
 	log.Println("Starting...")
 
 	sess := session.Must(session.NewSession())
-	tokenService := token.New("goidc")
+	tokenService := token.New("") // The issuer is set per request.
 	clientProvider := dynamo.NewClientProvider(sess)
 
 	hdlr := &Handler{
-		sess:      sess,
-		tokens:    tokenService,
-		clients:   clientProvider,
-		validator: validator.NewClientValidator(),
+		sess:        sess,
+		tokens:      tokenService,
+		clients:     clientProvider,
+		validator:   validator.NewClientValidator(),
+		codes:       dynamo.NewAuthorizationCodeStore(sess),
+		refresh:     dynamo.NewRefreshTokenStore(sess),
+		devices:     dynamo.NewDeviceCodeStore(sess),
+		users:       dynamo.NewUserProvider(sess),
+		userVal:     validator.NewUserValidator(),
+		keys:        jwk.NewFetcher(),
+		assertions:  dynamo.NewAssertionStore(sess),
+		revocations: dynamo.NewRevocationStore(sess),
+		issuers:     dynamo.NewTrustedIssuerProvider(sess),
 	}
 
 	lambda.Start(hdlr.Handle)
 }
 
 type Handler struct {
-	sess      *session.Session
-	tokens    token.Service
-	clients   dal.ClientProvider
-	validator validator.ClientValidator
+	sess        *session.Session
+	tokens      token.Service
+	clients     dal.ClientProvider
+	validator   validator.ClientValidator
+	codes       dal.AuthorizationCodeStore
+	refresh     dal.RefreshTokenStore
+	devices     dal.DeviceCodeStore
+	users       dal.UserProvider
+	userVal     validator.UserValidator
+	keys        jwk.Fetcher
+	assertions  dal.AssertionStore
+	revocations dal.RevocationStore
+	issuers     dal.TrustedIssuerProvider
 }
 
 func (h *Handler) Handle(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
@@ -51,40 +110,634 @@ func (h *Handler) Handle(ctx context.Context, req events.APIGatewayProxyRequest)
 
 	if util.Header(req, "Content-Type") != "application/x-www-form-urlencoded" {
 		log.Printf("Invalid Content Type: %v", req.Headers["Content-Type"])
-		return util.RespondBadRequest(errors.New("invalid content type")), nil
+		return util.RespondOAuthError(errors.New("invalid content type")), nil
 	}
 
 	data := util.ReadForm(req)
-	clientId := data.Get("client_id")
-	clientSecret := data.Get("client_secret")
 	grantType := data.Get("grant_type")
-	scopes := strings.Split(data.Get("scope"), " ")
 
 	ctx = goidc.NewContext(ctx, &req)
-	client, err := h.clients.Get(ctx, clientId)
+	c := *h
+	c.tokens = oauth.IssuerTokens(ctx, req, h.tokens)
+	h = &c
+
+	// The JWT bearer grant is authenticated by the assertion itself, which
+	// identifies the client using the trusted issuer's subject mappings.
+	if grantType == oauth.GrantTypeJWTBearer {
+		return h.jwtBearer(ctx, req, data)
+	}
+
+	client, auth, err := h.authenticator().Authenticate(ctx, req, data)
 	if err != nil {
-		if err == dal.ErrClientNotFound {
-			return util.RespondBadRequest(errors.New("invalid client id")), nil
+		if _, ok := err.(*util.OAuthError); ok {
+			return util.RespondOAuthError(err), nil
+		}
+
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	switch grantType {
+	case oauth.GrantTypeAuthorizationCode:
+		return h.authorizationCode(ctx, client, auth, data)
+	case oauth.GrantTypeRefreshToken:
+		return h.refreshToken(ctx, client, auth, data)
+	case oauth.GrantTypeDeviceCode:
+		return h.deviceCode(ctx, client, auth, data)
+	case oauth.GrantTypePassword:
+		return h.password(ctx, client, auth, data)
+	case oauth.GrantTypeTokenExchange:
+		return h.tokenExchange(ctx, client, auth, data)
+	case oauth.GrantTypeClientCredentials:
+		return h.clientCredentials(ctx, client, auth, data)
+	default:
+		return util.RespondOAuthError(errUnsupportedGrantType), nil
+	}
+}
+
+// authenticator returns the Authenticator used to authenticate clients.
+func (h *Handler) authenticator() clientauth.Authenticator {
+	return clientauth.New(h.clients, h.validator, h.tokens, h.keys, h.assertions)
+}
+
+// validateTokenRequest validates the grant type and scopes of the request, as
+// well as the client's secret, unless it authenticated using an assertion.
+func (h *Handler) validateTokenRequest(c *dal.Client, auth *clientauth.Credentials, grantType string, scopes []string) error {
+	if auth.Assertion != "" {
+		return h.validator.ValidateGrantRequest(c, grantType, scopes)
+	}
+
+	return h.validator.ValidateTokenRequest(c, auth.Secret, grantType, scopes)
+}
+
+func (h *Handler) clientCredentials(ctx context.Context, c *dal.Client, auth *clientauth.Credentials, data url.Values) (events.APIGatewayProxyResponse, error) {
+	scopes := strings.Split(data.Get("scope"), " ")
+
+	err := h.validateTokenRequest(c, auth, oauth.GrantTypeClientCredentials, scopes)
+	if err != nil {
+		return util.RespondOAuthError(err), nil
+	}
+
+	claims := map[string]interface{}{
+		"sub":       c.ID,
+		"client_id": c.ID,
+		"scopes":    scopes,
+	}
+
+	alg, _ := token.NewKMSAlgorithm(h.sess, goidc.StageVariable(ctx, "JWT_KEY_ID"))
+	accessToken, err := h.tokens.GenerateToken(alg, claims, 3600, "goidc")
+	if err != nil {
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	return util.RespondOk(accessToken), nil
+}
+
+func (h *Handler) authorizationCode(ctx context.Context, c *dal.Client, auth *clientauth.Credentials, data url.Values) (events.APIGatewayProxyResponse, error) {
+	// The scopes were validated when the code was issued, so only
+	// the client's credentials and grant type need to be validated.
+	err := h.validateTokenRequest(c, auth, oauth.GrantTypeAuthorizationCode, nil)
+	if err != nil {
+		return util.RespondOAuthError(err), nil
+	}
+
+	code, err := h.codes.Redeem(ctx, data.Get("code"))
+	if err != nil {
+		if err == dal.ErrAuthorizationCodeNotFound {
+			return util.RespondOAuthError(validator.ErrInvalidCode), nil
 		}
 
-		return util.RespondError(err), nil
+		return util.RespondOAuthServerError(err), nil
 	}
 
-	err = h.validator.ValidateTokenRequest(client, clientSecret, grantType, scopes)
+	err = h.validator.ValidateAuthorizationCode(c, code, data.Get("redirect_uri"))
 	if err != nil {
-		return util.RespondBadRequest(err), nil
+		return util.RespondOAuthError(err), nil
+	}
+
+	err = h.validator.ValidateCodeVerifier(c, code, data.Get("code_verifier"))
+	if err != nil {
+		return util.RespondOAuthError(err), nil
 	}
 
 	claims := map[string]interface{}{
-		"sub":    client.ID,
-		"scopes": scopes,
+		"sub":       code.UserID,
+		"client_id": c.ID,
+		"scopes":    code.Scopes,
 	}
 
-	alg, _ := kms.New(h.sess, goidc.StageVariable(ctx, "JWT_KEY_ID"), kms.RSA_PKCS1_S256)
+	alg, _ := token.NewKMSAlgorithm(h.sess, goidc.StageVariable(ctx, "JWT_KEY_ID"))
 	accessToken, err := h.tokens.GenerateToken(alg, claims, 3600, "goidc")
 	if err != nil {
-		return util.RespondError(err), nil
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	idToken, err := h.generateIdToken(alg, c, code.UserID, code.Nonce, code.SessionID, accessToken.AccessToken, code.AuthTime)
+	if err != nil {
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	accessToken.IDToken = idToken
+
+	if canRefresh(c) {
+		expires := util.Time().Add(refreshTokenExpiry).Unix()
+		accessToken.RefreshToken, err = h.issueRefreshToken(ctx, c, code.UserID, code.Scopes, util.RandomString(16), expires)
+		if err != nil {
+			return util.RespondOAuthServerError(err), nil
+		}
 	}
 
 	return util.RespondOk(accessToken), nil
 }
+
+func (h *Handler) refreshToken(ctx context.Context, c *dal.Client, auth *clientauth.Credentials, data url.Values) (events.APIGatewayProxyResponse, error) {
+	var scopes []string
+	if scope := data.Get("scope"); scope != "" {
+		scopes = strings.Split(scope, " ")
+	}
+
+	err := h.validateTokenRequest(c, auth, oauth.GrantTypeRefreshToken, nil)
+	if err != nil {
+		return util.RespondOAuthError(err), nil
+	}
+
+	rt, err := h.refresh.Get(ctx, util.Sha256(data.Get("refresh_token")))
+	if err != nil {
+		if err == dal.ErrRefreshTokenNotFound {
+			return util.RespondOAuthError(validator.ErrInvalidRefreshToken), nil
+		}
+
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	err = h.validator.ValidateRefreshToken(c, rt, scopes)
+	if err != nil {
+		return util.RespondOAuthError(err), nil
+	}
+
+	if rt.Used {
+		return h.revokeRefreshTokenFamily(ctx, rt)
+	}
+
+	err = h.refresh.MarkUsed(ctx, rt.ID)
+	if err != nil {
+		if err == dal.ErrRefreshTokenUsed {
+			return h.revokeRefreshTokenFamily(ctx, rt)
+		}
+
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	if scopes == nil {
+		scopes = rt.Scopes
+	}
+
+	claims := map[string]interface{}{
+		"sub":       rt.UserID,
+		"client_id": c.ID,
+		"scopes":    scopes,
+	}
+
+	alg, _ := token.NewKMSAlgorithm(h.sess, goidc.StageVariable(ctx, "JWT_KEY_ID"))
+	accessToken, err := h.tokens.GenerateToken(alg, claims, 3600, "goidc")
+	if err != nil {
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	// The rotated token keeps the original scopes, so that a narrower
+	// access token can be requested without losing access later on.
+	accessToken.RefreshToken, err = h.issueRefreshToken(ctx, c, rt.UserID, rt.Scopes, rt.FamilyID, rt.Expires)
+	if err != nil {
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	return util.RespondOk(accessToken), nil
+}
+
+func (h *Handler) deviceCode(ctx context.Context, c *dal.Client, auth *clientauth.Credentials, data url.Values) (events.APIGatewayProxyResponse, error) {
+	// The scopes were validated when the device code was issued, so only
+	// the client's credentials and grant type need to be validated.
+	err := h.validateTokenRequest(c, auth, oauth.GrantTypeDeviceCode, nil)
+	if err != nil {
+		return util.RespondOAuthError(err), nil
+	}
+
+	code, err := h.devices.Get(ctx, data.Get("device_code"))
+	if err != nil {
+		if err == dal.ErrDeviceCodeNotFound {
+			return util.RespondOAuthError(validator.ErrInvalidDeviceCode), nil
+		}
+
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	err = h.validator.ValidateDeviceCode(c, code)
+	if err != nil {
+		if err == validator.ErrDeviceCodeExpired {
+			return util.RespondOAuthError(errExpiredToken), nil
+		}
+
+		return util.RespondOAuthError(err), nil
+	}
+
+	if code.UserID == "" {
+		return h.pollDeviceCode(ctx, code)
+	}
+
+	// Redeeming the code ensures the device can only obtain tokens once,
+	// even if it polls again before receiving the first response.
+	code, err = h.devices.Redeem(ctx, code.DeviceCode)
+	if err != nil {
+		if err == dal.ErrDeviceCodeNotFound {
+			return util.RespondOAuthError(validator.ErrInvalidDeviceCode), nil
+		}
+
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	claims := map[string]interface{}{
+		"sub":       code.UserID,
+		"client_id": c.ID,
+		"scopes":    code.Scopes,
+	}
+
+	alg, _ := token.NewKMSAlgorithm(h.sess, goidc.StageVariable(ctx, "JWT_KEY_ID"))
+	accessToken, err := h.tokens.GenerateToken(alg, claims, 3600, "goidc")
+	if err != nil {
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	idToken, err := h.generateIdToken(alg, c, code.UserID, "", "", accessToken.AccessToken, 0)
+	if err != nil {
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	accessToken.IDToken = idToken
+
+	if canRefresh(c) {
+		expires := util.Time().Add(refreshTokenExpiry).Unix()
+		accessToken.RefreshToken, err = h.issueRefreshToken(ctx, c, code.UserID, code.Scopes, util.RandomString(16), expires)
+		if err != nil {
+			return util.RespondOAuthServerError(err), nil
+		}
+	}
+
+	return util.RespondOk(accessToken), nil
+}
+
+// password exchanges a user's credentials for tokens directly, using the
+// resource owner password credentials grant. As the client handles the user's
+// password, only confidential clients which explicitly list the grant type
+// are able to use it.
+func (h *Handler) password(ctx context.Context, c *dal.Client, auth *clientauth.Credentials, data url.Values) (events.APIGatewayProxyResponse, error) {
+	var scopes []string
+	if scope := data.Get("scope"); scope != "" {
+		scopes = strings.Split(scope, " ")
+	}
+
+	err := h.validateTokenRequest(c, auth, oauth.GrantTypePassword, scopes)
+	if err != nil {
+		return util.RespondOAuthError(err), nil
+	}
+
+	// As defined by RFC 6749, the user's credentials are given as the
+	// username and password parameters, where the username is an email.
+	user, err := h.users.GetByEmail(ctx, data.Get("username"))
+	if err != nil {
+		if err == dal.ErrUserNotFound {
+			return util.RespondOAuthError(errInvalidCredentials), nil
+		}
+
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	err = h.userVal.ValidatePassword(user, data.Get("password"))
+	if err != nil {
+		if err == validator.ErrInvalidPassword {
+			return util.RespondOAuthError(errInvalidCredentials), nil
+		}
+
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	claims := map[string]interface{}{
+		"sub":       user.ID,
+		"client_id": c.ID,
+		"scopes":    scopes,
+	}
+
+	alg, _ := token.NewKMSAlgorithm(h.sess, goidc.StageVariable(ctx, "JWT_KEY_ID"))
+	accessToken, err := h.tokens.GenerateToken(alg, claims, 3600, "goidc")
+	if err != nil {
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	idToken, err := h.generateIdToken(alg, c, user.ID, "", "", accessToken.AccessToken, util.Time().Unix())
+	if err != nil {
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	accessToken.IDToken = idToken
+
+	if canRefresh(c) {
+		expires := util.Time().Add(refreshTokenExpiry).Unix()
+		accessToken.RefreshToken, err = h.issueRefreshToken(ctx, c, user.ID, scopes, util.RandomString(16), expires)
+		if err != nil {
+			return util.RespondOAuthServerError(err), nil
+		}
+	}
+
+	return util.RespondOk(accessToken), nil
+}
+
+// tokenExchange exchanges a user's access token for a token with a narrower
+// audience and scopes, as defined by RFC 8693. The user remains the subject of
+// the issued token, and the client is recorded as the acting party.
+func (h *Handler) tokenExchange(ctx context.Context, c *dal.Client, auth *clientauth.Credentials, data url.Values) (events.APIGatewayProxyResponse, error) {
+	err := h.validateTokenRequest(c, auth, oauth.GrantTypeTokenExchange, nil)
+	if err != nil {
+		return util.RespondOAuthError(err), nil
+	}
+
+	if data.Get("subject_token_type") != oauth.TokenTypeAccessToken {
+		return util.RespondOAuthError(errUnsupportedTokenType), nil
+	}
+
+	alg, _ := token.NewKMSAlgorithm(h.sess, goidc.StageVariable(ctx, "JWT_KEY_ID"))
+	subject, err := h.tokens.VerifyToken(alg, data.Get("subject_token"), "goidc")
+	if err != nil {
+		log.Printf("Invalid subject token: %v\n", err)
+		return util.RespondOAuthError(errInvalidSubjectToken), nil
+	}
+
+	if jti, ok := subject.String("jti"); ok {
+		revoked, err := h.revocations.IsRevoked(ctx, jti)
+		if err != nil {
+			return util.RespondOAuthServerError(err), nil
+		}
+
+		if revoked {
+			return util.RespondOAuthError(errInvalidSubjectToken), nil
+		}
+	}
+
+	// If no scopes are requested, the subject token's scopes are kept.
+	subjectScopes := token.Scopes(subject)
+	scopes := subjectScopes
+	if scope := data.Get("scope"); scope != "" {
+		scopes = strings.Split(scope, " ")
+	}
+
+	audience := data.Get("audience")
+	err = h.validator.ValidateTokenExchange(c, audience, scopes, subjectScopes)
+	if err != nil {
+		return util.RespondOAuthError(err), nil
+	}
+
+	// The acting client is recorded in the act claim, nesting any
+	// actors from the subject token, should it have been exchanged.
+	act := map[string]interface{}{"sub": c.ID}
+	if prev, ok := subject["act"]; ok {
+		act["act"] = prev
+	}
+
+	sub, _ := subject.String("sub")
+	claims := map[string]interface{}{
+		"sub":       sub,
+		"client_id": c.ID,
+		"scopes":    scopes,
+		"act":       act,
+	}
+
+	// The exchanged token cannot outlive the subject token.
+	var expiry int64 = 3600
+	if exp, ok := subject.Expiry(); ok && exp.Unix()-util.Time().Unix() < expiry {
+		expiry = exp.Unix() - util.Time().Unix()
+	}
+
+	// The subject token may be accepted within the clock skew after it
+	// expires, but a token can't be issued without any lifetime.
+	if expiry <= 0 {
+		return util.RespondOAuthError(errExpiredSubjectToken), nil
+	}
+
+	accessToken, err := h.tokens.GenerateToken(alg, claims, expiry, audience)
+	if err != nil {
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	accessToken.IssuedTokenType = oauth.TokenTypeAccessToken
+
+	return util.RespondOk(accessToken), nil
+}
+
+// jwtBearer issues an access token in exchange for a JWT issued by a trusted
+// issuer, as defined by RFC 7523. The token is issued to the client which the
+// assertion's subject is mapped to, allowing workloads such as CI jobs to get
+// tokens without sharing a secret.
+func (h *Handler) jwtBearer(ctx context.Context, req events.APIGatewayProxyRequest, data url.Values) (events.APIGatewayProxyResponse, error) {
+	assertion := data.Get("assertion")
+
+	// The issuer is needed to know which keys to verify the assertion with.
+	unverified, err := token.UnverifiedClaims(assertion)
+	if err != nil {
+		return util.RespondOAuthError(errInvalidAssertion), nil
+	}
+
+	iss, _ := unverified.String("iss")
+	issuer, err := h.issuers.Get(ctx, iss)
+	if err != nil {
+		if err == dal.ErrTrustedIssuerNotFound {
+			return util.RespondOAuthError(errInvalidAssertion), nil
+		}
+
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	set := issuer.JWKS
+	if set == nil && issuer.JWKSUri != "" {
+		set, err = h.keys.Fetch(ctx, issuer.JWKSUri)
+		if err != nil {
+			return util.RespondOAuthServerError(err), nil
+		}
+	}
+
+	// An issuer without any keys can't have signed the assertion.
+	if set == nil {
+		return util.RespondOAuthError(errInvalidAssertion), nil
+	}
+
+	audience := issuer.Audience
+	if audience == "" {
+		audience = oauth.Issuer(ctx, req) + oauth.TokenPath
+	}
+
+	claims, err := h.tokens.VerifyAssertionWithKeySet(set, assertion, issuer.Issuer, audience)
+	if err != nil {
+		log.Printf("Invalid assertion: %v\n", err)
+		return util.RespondOAuthError(errInvalidAssertion), nil
+	}
+
+	sub, _ := claims.String("sub")
+	clientId, ok := issuer.Subjects[sub]
+	if !ok {
+		return util.RespondOAuthError(errInvalidAssertion), nil
+	}
+
+	// Assertions can only be used once, in the same way as client
+	// assertions. The jti is only unique to the issuer.
+	jti, ok := claims.String("jti")
+	if !ok || jti == "" {
+		return util.RespondOAuthError(errInvalidAssertion), nil
+	}
+
+	exp, _ := claims.Expiry()
+	err = h.assertions.Use(ctx, &dal.UsedAssertion{
+		ID:      issuer.Issuer + "/" + jti,
+		Expires: exp.Unix(),
+	})
+	if err != nil {
+		if err == dal.ErrAssertionUsed {
+			return util.RespondOAuthError(errInvalidAssertion), nil
+		}
+
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	c, err := h.clients.Get(ctx, clientId)
+	if err != nil {
+		if err == dal.ErrClientNotFound {
+			return util.RespondOAuthError(clientauth.ErrInvalidClient), nil
+		}
+
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	var scopes []string
+	if scope := data.Get("scope"); scope != "" {
+		scopes = strings.Split(scope, " ")
+	}
+
+	err = h.validator.ValidateGrantRequest(c, oauth.GrantTypeJWTBearer, scopes)
+	if err != nil {
+		return util.RespondOAuthError(err), nil
+	}
+
+	alg, _ := token.NewKMSAlgorithm(h.sess, goidc.StageVariable(ctx, "JWT_KEY_ID"))
+	accessToken, err := h.tokens.GenerateToken(alg, map[string]interface{}{
+		"sub":       c.ID,
+		"client_id": c.ID,
+		"scopes":    scopes,
+	}, 3600, "goidc")
+	if err != nil {
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	return util.RespondOk(accessToken), nil
+}
+
+// pollDeviceCode records a polling request for a device code the user has
+// not yet approved. If the device is polling faster than its interval allows,
+// the interval is increased and the device is told to slow down.
+func (h *Handler) pollDeviceCode(ctx context.Context, code *dal.DeviceCode) (events.APIGatewayProxyResponse, error) {
+	now := util.Time().Unix()
+	interval := code.Interval
+	tooFast := code.LastPolledAt > 0 && now-code.LastPolledAt < code.Interval
+	if tooFast {
+		interval += slowDownInterval
+	}
+
+	err := h.devices.UpdatePolling(ctx, code.DeviceCode, now, interval)
+	if err != nil {
+		if err == dal.ErrDeviceCodeNotFound {
+			return util.RespondOAuthError(validator.ErrInvalidDeviceCode), nil
+		}
+
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	if tooFast {
+		return util.RespondOAuthError(errSlowDown), nil
+	}
+
+	return util.RespondOAuthError(errAuthorizationPending), nil
+}
+
+// revokeRefreshTokenFamily is called when a refresh token which has already
+// been used is presented again. As either the client or an attacker holds a
+// stolen token, every token in the family is revoked.
+func (h *Handler) revokeRefreshTokenFamily(ctx context.Context, rt *dal.RefreshToken) (events.APIGatewayProxyResponse, error) {
+	log.Printf("Refresh token reuse detected, revoking family: %s\n", rt.FamilyID)
+
+	err := h.refresh.RevokeFamily(ctx, rt.FamilyID)
+	if err != nil {
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	return util.RespondOAuthError(validator.ErrInvalidRefreshToken), nil
+}
+
+// issueRefreshToken generates and persists a new refresh token in the given
+// family, returning the token to be given to the client.
+func (h *Handler) issueRefreshToken(ctx context.Context, c *dal.Client, userID string, scopes []string, familyID string, expires int64) (string, error) {
+	value := util.RandomString(32)
+	rt := &dal.RefreshToken{
+		ID:       util.Sha256(value),
+		FamilyID: familyID,
+		ClientID: c.ID,
+		UserID:   userID,
+		Scopes:   scopes,
+		Expires:  expires,
+	}
+
+	err := h.refresh.Create(ctx, rt)
+	if err != nil {
+		return "", err
+	}
+
+	return value, nil
+}
+
+// canRefresh determines whether c is allowed to use the refresh token grant.
+func canRefresh(c *dal.Client) bool {
+	for _, grantType := range c.GrantTypes {
+		if grantType == oauth.GrantTypeRefreshToken {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h *Handler) generateIdToken(alg gojwt.Algorithm, c *dal.Client, userID, nonce, sessionID, accessToken string, authTime int64) (string, error) {
+	atHash, err := token.Hash(alg, accessToken)
+	if err != nil {
+		return "", err
+	}
+
+	claims := map[string]interface{}{
+		"sub":     userID,
+		"azp":     c.ID,
+		"amr":     []string{oauth.AuthenticationMethodPassword},
+		"at_hash": atHash,
+	}
+
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+
+	if authTime > 0 {
+		claims["auth_time"] = authTime
+	}
+
+	// Only users who logged in using the authorization endpoint have a session.
+	if sessionID != "" {
+		claims["sid"] = sessionID
+	}
+
+	jwt, err := h.tokens.GenerateToken(alg, claims, 36000, c.ID)
+	if err != nil {
+		return "", err
+	}
+
+	return jwt.AccessToken, nil
+}
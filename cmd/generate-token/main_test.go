@@ -2,35 +2,44 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-sdk-go/awstesting/mock"
 	"github.com/golang/mock/gomock"
+	"github.com/reecerussell/gojwt"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/reecerussell/goidc/clientauth"
 	"github.com/reecerussell/goidc/dal"
 	dalMock "github.com/reecerussell/goidc/dal/mock"
+	"github.com/reecerussell/goidc/jwk"
+	jwkMock "github.com/reecerussell/goidc/jwk/mock"
+	"github.com/reecerussell/goidc/oauth"
 	"github.com/reecerussell/goidc/token"
 	tokenMock "github.com/reecerussell/goidc/token/mock"
+	"github.com/reecerussell/goidc/util"
+	"github.com/reecerussell/goidc/validator"
 	valMock "github.com/reecerussell/goidc/validator/mock"
 )
 
 func TestHandler(t *testing.T) {
 	testClientId := "3247023"
 	testClientSecret := "2934uldnf"
-	testGrantType := "code"
+	testGrantType := "client_credentials"
 	testScopes := "openid"
 
 	testClient := &dal.Client{
 		ID:         testClientId,
 		Scopes:     []string{"openid"},
 		Secrets:    []string{"my secret"},
-		GrantTypes: []string{"code"},
+		GrantTypes: []string{"client_credentials"},
 	}
 	testToken := &token.Token{
 		AccessToken: "my.jwt.token",
@@ -45,9 +54,10 @@ func TestHandler(t *testing.T) {
 	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
 
 	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
 	mockValidator.EXPECT().ValidateTokenRequest(testClient, testClientSecret, testGrantType, []string{testScopes}).Return(nil)
 
-	mockTokenService := tokenMock.NewMockService(ctrl)
+	mockTokenService := tokenMock.NewIssuerService(ctrl, "https://id.example.com")
 	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(testToken, nil)
 
 	h := &Handler{
@@ -72,6 +82,7 @@ func TestHandler(t *testing.T) {
 		Body: testBody.Encode(),
 		StageVariables: map[string]string{
 			"JWT_KEY_ID": "test key id",
+			"ISSUER":     "https://id.example.com",
 		},
 	})
 	assert.NoError(t, err)
@@ -86,7 +97,7 @@ func TestHandler(t *testing.T) {
 func TestHandler_GivenInvalidHTTPMethod_ReturnsMethodNotSupported(t *testing.T) {
 	testClientId := "3247023"
 	testClientSecret := "2934uldnf"
-	testGrantType := "code"
+	testGrantType := "client_credentials"
 	testScopes := "openid"
 
 	ctrl := gomock.NewController(t)
@@ -94,7 +105,7 @@ func TestHandler_GivenInvalidHTTPMethod_ReturnsMethodNotSupported(t *testing.T)
 
 	mockProvider := dalMock.NewMockClientProvider(ctrl)
 	mockValidator := valMock.NewMockClientValidator(ctrl)
-	mockTokenService := tokenMock.NewMockService(ctrl)
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
 
 	h := &Handler{
 		sess:      mock.Session,
@@ -129,7 +140,7 @@ func TestHandler_GivenInvalidHTTPMethod_ReturnsMethodNotSupported(t *testing.T)
 func TestHandler_GivenInvalidContentType_ReturnsBadRequest(t *testing.T) {
 	testClientId := "3247023"
 	testClientSecret := "2934uldnf"
-	testGrantType := "code"
+	testGrantType := "client_credentials"
 	testScopes := "openid"
 
 	ctrl := gomock.NewController(t)
@@ -137,7 +148,7 @@ func TestHandler_GivenInvalidContentType_ReturnsBadRequest(t *testing.T) {
 
 	mockProvider := dalMock.NewMockClientProvider(ctrl)
 	mockValidator := valMock.NewMockClientValidator(ctrl)
-	mockTokenService := tokenMock.NewMockService(ctrl)
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
 
 	h := &Handler{
 		sess:      mock.Session,
@@ -165,14 +176,14 @@ func TestHandler_GivenInvalidContentType_ReturnsBadRequest(t *testing.T) {
 	assert.False(t, resp.IsBase64Encoded)
 	assert.Equal(t, "application/json; charset=utf-8", resp.Headers["Content-Type"])
 
-	bytes, _ := json.Marshal(map[string]string{"error": "invalid content type"})
+	bytes, _ := json.Marshal(map[string]string{"error": "invalid_request", "error_description": "invalid content type"})
 	assert.Equal(t, string(bytes), resp.Body)
 }
 
-func TestHandler_GivenInvalidClientId_ReturnsBadRequest(t *testing.T) {
+func TestHandler_GivenInvalidClientId_ReturnsUnauthorized(t *testing.T) {
 	testClientId := "3247023"
 	testClientSecret := "2934uldnf"
-	testGrantType := "code"
+	testGrantType := "client_credentials"
 	testScopes := "openid"
 
 	ctrl := gomock.NewController(t)
@@ -182,7 +193,7 @@ func TestHandler_GivenInvalidClientId_ReturnsBadRequest(t *testing.T) {
 	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(nil, dal.ErrClientNotFound)
 
 	mockValidator := valMock.NewMockClientValidator(ctrl)
-	mockTokenService := tokenMock.NewMockService(ctrl)
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
 
 	h := &Handler{
 		sess:      mock.Session,
@@ -206,18 +217,19 @@ func TestHandler_GivenInvalidClientId_ReturnsBadRequest(t *testing.T) {
 		Body: testBody.Encode(),
 	})
 	assert.NoError(t, err)
-	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "Basic", resp.Headers["WWW-Authenticate"])
 	assert.False(t, resp.IsBase64Encoded)
 	assert.Equal(t, "application/json; charset=utf-8", resp.Headers["Content-Type"])
 
-	bytes, _ := json.Marshal(map[string]string{"error": "invalid client id"})
+	bytes, _ := json.Marshal(clientauth.ErrInvalidClient)
 	assert.Equal(t, string(bytes), resp.Body)
 }
 
 func TestHandler_WhereClientProviderFails_ReturnsInternalServerError(t *testing.T) {
 	testClientId := "3247023"
 	testClientSecret := "2934uldnf"
-	testGrantType := "code"
+	testGrantType := "client_credentials"
 	testScopes := "openid"
 
 	testError := errors.New("an error occured")
@@ -229,7 +241,7 @@ func TestHandler_WhereClientProviderFails_ReturnsInternalServerError(t *testing.
 	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(nil, testError)
 
 	mockValidator := valMock.NewMockClientValidator(ctrl)
-	mockTokenService := tokenMock.NewMockService(ctrl)
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
 
 	h := &Handler{
 		sess:      mock.Session,
@@ -257,21 +269,21 @@ func TestHandler_WhereClientProviderFails_ReturnsInternalServerError(t *testing.
 	assert.False(t, resp.IsBase64Encoded)
 	assert.Equal(t, "application/json; charset=utf-8", resp.Headers["Content-Type"])
 
-	bytes, _ := json.Marshal(map[string]string{"error": testError.Error()})
-	assert.Equal(t, string(bytes), resp.Body)
+	// Internal errors aren't returned to the client.
+	assert.Equal(t, `{"error":"server_error"}`, resp.Body)
 }
 
 func TestHandler_GivenInvalidClient_ReturnsBadRequest(t *testing.T) {
 	testClientId := "3247023"
 	testClientSecret := "2934uldnf"
-	testGrantType := "code"
+	testGrantType := "client_credentials"
 	testScopes := "openid"
 
 	testClient := &dal.Client{
 		ID:         testClientId,
 		Scopes:     []string{"openid"},
 		Secrets:    []string{"my secret"},
-		GrantTypes: []string{"code"},
+		GrantTypes: []string{"client_credentials"},
 	}
 	testError := errors.New("invalid client")
 
@@ -282,9 +294,10 @@ func TestHandler_GivenInvalidClient_ReturnsBadRequest(t *testing.T) {
 	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
 
 	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
 	mockValidator.EXPECT().ValidateTokenRequest(testClient, testClientSecret, testGrantType, gomock.Any()).Return(testError)
 
-	mockTokenService := tokenMock.NewMockService(ctrl)
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
 
 	h := &Handler{
 		sess:      mock.Session,
@@ -312,21 +325,21 @@ func TestHandler_GivenInvalidClient_ReturnsBadRequest(t *testing.T) {
 	assert.False(t, resp.IsBase64Encoded)
 	assert.Equal(t, "application/json; charset=utf-8", resp.Headers["Content-Type"])
 
-	bytes, _ := json.Marshal(map[string]string{"error": testError.Error()})
+	bytes, _ := json.Marshal(util.AsOAuthError(testError))
 	assert.Equal(t, string(bytes), resp.Body)
 }
 
 func TestHandler_GivenTokenGenerationFails_ReturnsInternalServerError(t *testing.T) {
 	testClientId := "3247023"
 	testClientSecret := "2934uldnf"
-	testGrantType := "code"
+	testGrantType := "client_credentials"
 	testScopes := "openid"
 
 	testClient := &dal.Client{
 		ID:         testClientId,
 		Scopes:     []string{"openid"},
 		Secrets:    []string{"my secret"},
-		GrantTypes: []string{"code"},
+		GrantTypes: []string{"client_credentials"},
 	}
 	testError := errors.New("invalid client")
 
@@ -337,9 +350,10 @@ func TestHandler_GivenTokenGenerationFails_ReturnsInternalServerError(t *testing
 	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
 
 	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
 	mockValidator.EXPECT().ValidateTokenRequest(testClient, testClientSecret, testGrantType, gomock.Any()).Return(nil)
 
-	mockTokenService := tokenMock.NewMockService(ctrl)
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
 	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, testError)
 
 	h := &Handler{
@@ -371,6 +385,2122 @@ func TestHandler_GivenTokenGenerationFails_ReturnsInternalServerError(t *testing
 	assert.False(t, resp.IsBase64Encoded)
 	assert.Equal(t, "application/json; charset=utf-8", resp.Headers["Content-Type"])
 
-	bytes, _ := json.Marshal(map[string]string{"error": testError.Error()})
+	// Internal errors aren't returned to the client.
+	assert.Equal(t, `{"error":"server_error"}`, resp.Body)
+}
+
+func TestHandler_GivenAuthorizationCodeGrant_ReturnsTokens(t *testing.T) {
+	testClientId := "3247023"
+	testClientSecret := "2934uldnf"
+	testCode := "23o4iu2o3i4u"
+	testRedirectUri := "http://localhost:8080"
+
+	testClient := &dal.Client{
+		ID:         testClientId,
+		Scopes:     []string{"openid"},
+		Secrets:    []string{"my secret"},
+		GrantTypes: []string{"authorization_code"},
+	}
+	testAuthorizationCode := &dal.AuthorizationCode{
+		Code:        testCode,
+		ClientID:    testClientId,
+		UserID:      "testUserId",
+		RedirectUri: testRedirectUri,
+		Scopes:      []string{"openid"},
+		Nonce:       "2304820340lskfle",
+		AuthTime:    1600000000,
+		SessionID:   "my session id",
+	}
+	testToken := &token.Token{
+		AccessToken: "my.jwt.token",
+		TokenType:   "Bearer",
+		Expires:     3600,
+	}
+	testIdToken := &token.Token{
+		AccessToken: "my.id.token",
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockCodes := dalMock.NewMockAuthorizationCodeStore(ctrl)
+	mockCodes.EXPECT().Redeem(gomock.Any(), testCode).Return(testAuthorizationCode, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, testClientSecret, "authorization_code", nil).Return(nil)
+	mockValidator.EXPECT().ValidateAuthorizationCode(testClient, testAuthorizationCode, testRedirectUri).Return(nil)
+	mockValidator.EXPECT().ValidateCodeVerifier(testClient, testAuthorizationCode, "").Return(nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), int64(3600), "goidc").Return(testToken, nil)
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), gomock.Any(), testClientId).
+		DoAndReturn(func(_ interface{}, claims map[string]interface{}, _ int64, _ string) (*token.Token, error) {
+			assert.Equal(t, "testUserId", claims["sub"])
+			assert.Equal(t, testAuthorizationCode.Nonce, claims["nonce"])
+			assert.Equal(t, testAuthorizationCode.AuthTime, claims["auth_time"])
+			assert.Equal(t, testAuthorizationCode.SessionID, claims["sid"])
+			assert.Equal(t, testClientId, claims["azp"])
+			assert.Equal(t, []string{oauth.AuthenticationMethodPassword}, claims["amr"])
+			assert.Equal(t, "y5rX-5LuMLo9O5tRFBCIlA", claims["at_hash"])
+
+			return testIdToken, nil
+		})
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    mockTokenService,
+		clients:   mockProvider,
+		validator: mockValidator,
+		codes:     mockCodes,
+	}
+
+	testBody := url.Values{
+		"client_id":     {testClientId},
+		"client_secret": {testClientSecret},
+		"grant_type":    {"authorization_code"},
+		"code":          {testCode},
+		"redirect_uri":  {testRedirectUri},
+	}
+
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+		},
+		Body: testBody.Encode(),
+		StageVariables: map[string]string{
+			"JWT_KEY_ID": "test key id",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+
+	assert.Equal(t, testToken.AccessToken, data["access_token"])
+	assert.Equal(t, testIdToken.AccessToken, data["id_token"])
+}
+
+func TestHandler_GivenUnknownAuthorizationCode_ReturnsBadRequest(t *testing.T) {
+	testClientId := "3247023"
+	testClientSecret := "2934uldnf"
+	testCode := "23o4iu2o3i4u"
+
+	testClient := &dal.Client{
+		ID:         testClientId,
+		Secrets:    []string{"my secret"},
+		GrantTypes: []string{"authorization_code"},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockCodes := dalMock.NewMockAuthorizationCodeStore(ctrl)
+	mockCodes.EXPECT().Redeem(gomock.Any(), testCode).Return(nil, dal.ErrAuthorizationCodeNotFound)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, testClientSecret, "authorization_code", nil).Return(nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    mockTokenService,
+		clients:   mockProvider,
+		validator: mockValidator,
+		codes:     mockCodes,
+	}
+
+	testBody := url.Values{
+		"client_id":     {testClientId},
+		"client_secret": {testClientSecret},
+		"grant_type":    {"authorization_code"},
+		"code":          {testCode},
+		"redirect_uri":  {"http://localhost:8080"},
+	}
+
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+		},
+		Body: testBody.Encode(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	bytes, _ := json.Marshal(validator.ErrInvalidCode)
+	assert.Equal(t, string(bytes), resp.Body)
+}
+
+func TestHandler_GivenInvalidAuthorizationCode_ReturnsBadRequest(t *testing.T) {
+	testClientId := "3247023"
+	testClientSecret := "2934uldnf"
+	testCode := "23o4iu2o3i4u"
+	testRedirectUri := "http://localhost:8080"
+
+	testClient := &dal.Client{
+		ID:         testClientId,
+		Secrets:    []string{"my secret"},
+		GrantTypes: []string{"authorization_code"},
+	}
+	testAuthorizationCode := &dal.AuthorizationCode{
+		Code:     testCode,
+		ClientID: "another client",
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockCodes := dalMock.NewMockAuthorizationCodeStore(ctrl)
+	mockCodes.EXPECT().Redeem(gomock.Any(), testCode).Return(testAuthorizationCode, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, testClientSecret, "authorization_code", nil).Return(nil)
+	mockValidator.EXPECT().ValidateAuthorizationCode(testClient, testAuthorizationCode, testRedirectUri).Return(validator.ErrInvalidCode)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    mockTokenService,
+		clients:   mockProvider,
+		validator: mockValidator,
+		codes:     mockCodes,
+	}
+
+	testBody := url.Values{
+		"client_id":     {testClientId},
+		"client_secret": {testClientSecret},
+		"grant_type":    {"authorization_code"},
+		"code":          {testCode},
+		"redirect_uri":  {testRedirectUri},
+	}
+
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+		},
+		Body: testBody.Encode(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	bytes, _ := json.Marshal(validator.ErrInvalidCode)
+	assert.Equal(t, string(bytes), resp.Body)
+}
+
+func TestHandler_GivenInvalidCodeVerifier_ReturnsBadRequest(t *testing.T) {
+	testClientId := "3247023"
+	testCode := "23o4iu2o3i4u"
+	testRedirectUri := "http://localhost:8080"
+	testVerifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+	testClient := &dal.Client{
+		ID:         testClientId,
+		GrantTypes: []string{"authorization_code"},
+	}
+	testAuthorizationCode := &dal.AuthorizationCode{
+		Code:                testCode,
+		ClientID:            testClientId,
+		RedirectUri:         testRedirectUri,
+		CodeChallenge:       "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+		CodeChallengeMethod: "S256",
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockCodes := dalMock.NewMockAuthorizationCodeStore(ctrl)
+	mockCodes.EXPECT().Redeem(gomock.Any(), testCode).Return(testAuthorizationCode, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, "", "authorization_code", nil).Return(nil)
+	mockValidator.EXPECT().ValidateAuthorizationCode(testClient, testAuthorizationCode, testRedirectUri).Return(nil)
+	mockValidator.EXPECT().ValidateCodeVerifier(testClient, testAuthorizationCode, testVerifier).Return(validator.ErrInvalidCodeVerifier)
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockProvider,
+		validator: mockValidator,
+		codes:     mockCodes,
+	}
+
+	testBody := url.Values{
+		"client_id":     {testClientId},
+		"grant_type":    {"authorization_code"},
+		"code":          {testCode},
+		"redirect_uri":  {testRedirectUri},
+		"code_verifier": {testVerifier},
+	}
+
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+		},
+		Body: testBody.Encode(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	bytes, _ := json.Marshal(validator.ErrInvalidCodeVerifier)
 	assert.Equal(t, string(bytes), resp.Body)
 }
+
+func TestHandler_GivenAuthorizationCodeGrantForRefreshingClient_ReturnsRefreshToken(t *testing.T) {
+	testClientId := "3247023"
+	testClientSecret := "2934uldnf"
+	testCode := "23o4iu2o3i4u"
+	testRedirectUri := "http://localhost:8080"
+
+	testClient := &dal.Client{
+		ID:         testClientId,
+		Scopes:     []string{"openid"},
+		Secrets:    []string{"my secret"},
+		GrantTypes: []string{"authorization_code", "refresh_token"},
+	}
+	testAuthorizationCode := &dal.AuthorizationCode{
+		Code:        testCode,
+		ClientID:    testClientId,
+		UserID:      "testUserId",
+		RedirectUri: testRedirectUri,
+		Scopes:      []string{"openid"},
+	}
+
+	var testRefreshToken *dal.RefreshToken
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockCodes := dalMock.NewMockAuthorizationCodeStore(ctrl)
+	mockCodes.EXPECT().Redeem(gomock.Any(), testCode).Return(testAuthorizationCode, nil)
+
+	mockRefresh := dalMock.NewMockRefreshTokenStore(ctrl)
+	mockRefresh.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, rt *dal.RefreshToken) error {
+		testRefreshToken = rt
+		return nil
+	})
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, testClientSecret, "authorization_code", nil).Return(nil)
+	mockValidator.EXPECT().ValidateAuthorizationCode(testClient, testAuthorizationCode, testRedirectUri).Return(nil)
+	mockValidator.EXPECT().ValidateCodeVerifier(testClient, testAuthorizationCode, "").Return(nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&token.Token{AccessToken: "my.jwt.token"}, nil)
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&token.Token{AccessToken: "my.id.token"}, nil)
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    mockTokenService,
+		clients:   mockProvider,
+		validator: mockValidator,
+		codes:     mockCodes,
+		refresh:   mockRefresh,
+	}
+
+	testBody := url.Values{
+		"client_id":     {testClientId},
+		"client_secret": {testClientSecret},
+		"grant_type":    {"authorization_code"},
+		"code":          {testCode},
+		"redirect_uri":  {testRedirectUri},
+	}
+
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+		},
+		Body: testBody.Encode(),
+		StageVariables: map[string]string{
+			"JWT_KEY_ID": "test key id",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+
+	refreshToken := data["refresh_token"].(string)
+	assert.Equal(t, util.Sha256(refreshToken), testRefreshToken.ID)
+	assert.Equal(t, testClientId, testRefreshToken.ClientID)
+	assert.Equal(t, "testUserId", testRefreshToken.UserID)
+	assert.Equal(t, []string{"openid"}, testRefreshToken.Scopes)
+	assert.NotEmpty(t, testRefreshToken.FamilyID)
+}
+
+func TestHandler_GivenRefreshTokenGrant_ReturnsRotatedTokens(t *testing.T) {
+	testClientId := "3247023"
+	testClientSecret := "2934uldnf"
+	testRefreshTokenValue := "o2i3u4o2i3u4o2i3u4"
+
+	testClient := &dal.Client{
+		ID:         testClientId,
+		Secrets:    []string{"my secret"},
+		GrantTypes: []string{"authorization_code", "refresh_token"},
+	}
+	testRefreshToken := &dal.RefreshToken{
+		ID:       util.Sha256(testRefreshTokenValue),
+		FamilyID: "23k4j2l3k4",
+		ClientID: testClientId,
+		UserID:   "testUserId",
+		Scopes:   []string{"openid", "email"},
+		Expires:  1622505600,
+	}
+
+	var testRotatedToken *dal.RefreshToken
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockRefresh := dalMock.NewMockRefreshTokenStore(ctrl)
+	mockRefresh.EXPECT().Get(gomock.Any(), testRefreshToken.ID).Return(testRefreshToken, nil)
+	mockRefresh.EXPECT().MarkUsed(gomock.Any(), testRefreshToken.ID).Return(nil)
+	mockRefresh.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, rt *dal.RefreshToken) error {
+		testRotatedToken = rt
+		return nil
+	})
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, testClientSecret, "refresh_token", nil).Return(nil)
+	mockValidator.EXPECT().ValidateRefreshToken(testClient, testRefreshToken, []string{"email"}).Return(nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), int64(3600), "goidc").
+		DoAndReturn(func(_ interface{}, claims map[string]interface{}, _ int64, _ string) (*token.Token, error) {
+			assert.Equal(t, "testUserId", claims["sub"])
+			assert.Equal(t, []string{"email"}, claims["scopes"])
+
+			return &token.Token{AccessToken: "my.jwt.token"}, nil
+		})
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    mockTokenService,
+		clients:   mockProvider,
+		validator: mockValidator,
+		refresh:   mockRefresh,
+	}
+
+	testBody := url.Values{
+		"client_id":     {testClientId},
+		"client_secret": {testClientSecret},
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {testRefreshTokenValue},
+		"scope":         {"email"},
+	}
+
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+		},
+		Body: testBody.Encode(),
+		StageVariables: map[string]string{
+			"JWT_KEY_ID": "test key id",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+
+	assert.Equal(t, "my.jwt.token", data["access_token"])
+	assert.Equal(t, util.Sha256(data["refresh_token"].(string)), testRotatedToken.ID)
+	assert.Equal(t, testRefreshToken.FamilyID, testRotatedToken.FamilyID)
+	assert.Equal(t, testRefreshToken.Scopes, testRotatedToken.Scopes)
+	assert.Equal(t, testRefreshToken.Expires, testRotatedToken.Expires)
+}
+
+func TestHandler_GivenUsedRefreshToken_RevokesFamily(t *testing.T) {
+	testClientId := "3247023"
+	testClientSecret := "2934uldnf"
+	testRefreshTokenValue := "o2i3u4o2i3u4o2i3u4"
+
+	testClient := &dal.Client{
+		ID:         testClientId,
+		Secrets:    []string{"my secret"},
+		GrantTypes: []string{"refresh_token"},
+	}
+	testRefreshToken := &dal.RefreshToken{
+		ID:       util.Sha256(testRefreshTokenValue),
+		FamilyID: "23k4j2l3k4",
+		ClientID: testClientId,
+		Used:     true,
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockRefresh := dalMock.NewMockRefreshTokenStore(ctrl)
+	mockRefresh.EXPECT().Get(gomock.Any(), testRefreshToken.ID).Return(testRefreshToken, nil)
+	mockRefresh.EXPECT().RevokeFamily(gomock.Any(), testRefreshToken.FamilyID).Return(nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, testClientSecret, "refresh_token", nil).Return(nil)
+	mockValidator.EXPECT().ValidateRefreshToken(testClient, testRefreshToken, nil).Return(nil)
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockProvider,
+		validator: mockValidator,
+		refresh:   mockRefresh,
+	}
+
+	testBody := url.Values{
+		"client_id":     {testClientId},
+		"client_secret": {testClientSecret},
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {testRefreshTokenValue},
+	}
+
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+		},
+		Body: testBody.Encode(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	bytes, _ := json.Marshal(validator.ErrInvalidRefreshToken)
+	assert.Equal(t, string(bytes), resp.Body)
+}
+
+func TestHandler_WhereRefreshTokenIsUsedConcurrently_RevokesFamily(t *testing.T) {
+	testClientId := "3247023"
+	testClientSecret := "2934uldnf"
+	testRefreshTokenValue := "o2i3u4o2i3u4o2i3u4"
+
+	testClient := &dal.Client{
+		ID:         testClientId,
+		Secrets:    []string{"my secret"},
+		GrantTypes: []string{"refresh_token"},
+	}
+	testRefreshToken := &dal.RefreshToken{
+		ID:       util.Sha256(testRefreshTokenValue),
+		FamilyID: "23k4j2l3k4",
+		ClientID: testClientId,
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockRefresh := dalMock.NewMockRefreshTokenStore(ctrl)
+	mockRefresh.EXPECT().Get(gomock.Any(), testRefreshToken.ID).Return(testRefreshToken, nil)
+	mockRefresh.EXPECT().MarkUsed(gomock.Any(), testRefreshToken.ID).Return(dal.ErrRefreshTokenUsed)
+	mockRefresh.EXPECT().RevokeFamily(gomock.Any(), testRefreshToken.FamilyID).Return(nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, testClientSecret, "refresh_token", nil).Return(nil)
+	mockValidator.EXPECT().ValidateRefreshToken(testClient, testRefreshToken, nil).Return(nil)
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockProvider,
+		validator: mockValidator,
+		refresh:   mockRefresh,
+	}
+
+	testBody := url.Values{
+		"client_id":     {testClientId},
+		"client_secret": {testClientSecret},
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {testRefreshTokenValue},
+	}
+
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+		},
+		Body: testBody.Encode(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestHandler_GivenUnknownRefreshToken_ReturnsBadRequest(t *testing.T) {
+	testClientId := "3247023"
+	testClientSecret := "2934uldnf"
+	testRefreshTokenValue := "o2i3u4o2i3u4o2i3u4"
+
+	testClient := &dal.Client{
+		ID:         testClientId,
+		Secrets:    []string{"my secret"},
+		GrantTypes: []string{"refresh_token"},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockRefresh := dalMock.NewMockRefreshTokenStore(ctrl)
+	mockRefresh.EXPECT().Get(gomock.Any(), util.Sha256(testRefreshTokenValue)).Return(nil, dal.ErrRefreshTokenNotFound)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, testClientSecret, "refresh_token", nil).Return(nil)
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockProvider,
+		validator: mockValidator,
+		refresh:   mockRefresh,
+	}
+
+	testBody := url.Values{
+		"client_id":     {testClientId},
+		"client_secret": {testClientSecret},
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {testRefreshTokenValue},
+	}
+
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+		},
+		Body: testBody.Encode(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	bytes, _ := json.Marshal(validator.ErrInvalidRefreshToken)
+	assert.Equal(t, string(bytes), resp.Body)
+}
+
+func buildDeviceCodeRequest(clientId, deviceCode string) events.APIGatewayProxyRequest {
+	testBody := url.Values{
+		"client_id":   {clientId},
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		"device_code": {deviceCode},
+	}
+
+	return events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+		},
+		Body: testBody.Encode(),
+		StageVariables: map[string]string{
+			"JWT_KEY_ID": "test key id",
+		},
+	}
+}
+
+func TestHandler_GivenApprovedDeviceCode_ReturnsTokens(t *testing.T) {
+	testClient := &dal.Client{
+		ID:         "3247023",
+		GrantTypes: []string{"urn:ietf:params:oauth:grant-type:device_code", "refresh_token"},
+	}
+	testDeviceCode := &dal.DeviceCode{
+		DeviceCode: "23o4iu2o3i4u",
+		ClientID:   testClient.ID,
+		UserID:     "testUserId",
+		Scopes:     []string{"openid"},
+		Interval:   5,
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockDevices := dalMock.NewMockDeviceCodeStore(ctrl)
+	mockDevices.EXPECT().Get(gomock.Any(), testDeviceCode.DeviceCode).Return(testDeviceCode, nil)
+	mockDevices.EXPECT().Redeem(gomock.Any(), testDeviceCode.DeviceCode).Return(testDeviceCode, nil)
+
+	mockRefresh := dalMock.NewMockRefreshTokenStore(ctrl)
+	mockRefresh.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, rt *dal.RefreshToken) error {
+		assert.Equal(t, "testUserId", rt.UserID)
+		assert.Equal(t, []string{"openid"}, rt.Scopes)
+		return nil
+	})
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, "", "urn:ietf:params:oauth:grant-type:device_code", nil).Return(nil)
+	mockValidator.EXPECT().ValidateDeviceCode(testClient, testDeviceCode).Return(nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), int64(3600), "goidc").
+		DoAndReturn(func(_ interface{}, claims map[string]interface{}, _ int64, _ string) (*token.Token, error) {
+			assert.Equal(t, "testUserId", claims["sub"])
+			assert.Equal(t, testClient.ID, claims["client_id"])
+			assert.Equal(t, []string{"openid"}, claims["scopes"])
+
+			return &token.Token{AccessToken: "my.jwt.token"}, nil
+		})
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), gomock.Any(), testClient.ID).
+		Return(&token.Token{AccessToken: "my.id.token"}, nil)
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    mockTokenService,
+		clients:   mockProvider,
+		validator: mockValidator,
+		refresh:   mockRefresh,
+		devices:   mockDevices,
+	}
+
+	resp, err := h.Handle(context.Background(), buildDeviceCodeRequest(testClient.ID, testDeviceCode.DeviceCode))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, "my.jwt.token", data["access_token"])
+	assert.Equal(t, "my.id.token", data["id_token"])
+	assert.NotEmpty(t, data["refresh_token"])
+}
+
+func TestHandler_GivenPendingDeviceCode_ReturnsAuthorizationPending(t *testing.T) {
+	util.Freeze()
+	defer util.Reset()
+
+	testClient := &dal.Client{ID: "3247023"}
+	testDeviceCode := &dal.DeviceCode{
+		DeviceCode:   "23o4iu2o3i4u",
+		ClientID:     testClient.ID,
+		Interval:     5,
+		LastPolledAt: util.Time().Unix() - 5,
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockDevices := dalMock.NewMockDeviceCodeStore(ctrl)
+	mockDevices.EXPECT().Get(gomock.Any(), testDeviceCode.DeviceCode).Return(testDeviceCode, nil)
+	mockDevices.EXPECT().UpdatePolling(gomock.Any(), testDeviceCode.DeviceCode, util.Time().Unix(), int64(5)).Return(nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, "", "urn:ietf:params:oauth:grant-type:device_code", nil).Return(nil)
+	mockValidator.EXPECT().ValidateDeviceCode(testClient, testDeviceCode).Return(nil)
+
+	h := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockProvider,
+		validator: mockValidator,
+		devices:   mockDevices,
+	}
+
+	resp, err := h.Handle(context.Background(), buildDeviceCodeRequest(testClient.ID, testDeviceCode.DeviceCode))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "{\"error\":\"authorization_pending\"}", resp.Body)
+}
+
+func TestHandler_GivenDeviceCodePolledTooFrequently_ReturnsSlowDown(t *testing.T) {
+	util.Freeze()
+	defer util.Reset()
+
+	testClient := &dal.Client{ID: "3247023"}
+	testDeviceCode := &dal.DeviceCode{
+		DeviceCode:   "23o4iu2o3i4u",
+		ClientID:     testClient.ID,
+		Interval:     5,
+		LastPolledAt: util.Time().Unix() - 2,
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	// The interval is increased by 5 seconds, for all subsequent requests.
+	mockDevices := dalMock.NewMockDeviceCodeStore(ctrl)
+	mockDevices.EXPECT().Get(gomock.Any(), testDeviceCode.DeviceCode).Return(testDeviceCode, nil)
+	mockDevices.EXPECT().UpdatePolling(gomock.Any(), testDeviceCode.DeviceCode, util.Time().Unix(), int64(10)).Return(nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, "", "urn:ietf:params:oauth:grant-type:device_code", nil).Return(nil)
+	mockValidator.EXPECT().ValidateDeviceCode(testClient, testDeviceCode).Return(nil)
+
+	h := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockProvider,
+		validator: mockValidator,
+		devices:   mockDevices,
+	}
+
+	resp, err := h.Handle(context.Background(), buildDeviceCodeRequest(testClient.ID, testDeviceCode.DeviceCode))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "{\"error\":\"slow_down\"}", resp.Body)
+}
+
+func TestHandler_GivenExpiredDeviceCode_ReturnsExpiredToken(t *testing.T) {
+	testClient := &dal.Client{ID: "3247023"}
+	testDeviceCode := &dal.DeviceCode{
+		DeviceCode: "23o4iu2o3i4u",
+		ClientID:   testClient.ID,
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockDevices := dalMock.NewMockDeviceCodeStore(ctrl)
+	mockDevices.EXPECT().Get(gomock.Any(), testDeviceCode.DeviceCode).Return(testDeviceCode, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, "", "urn:ietf:params:oauth:grant-type:device_code", nil).Return(nil)
+	mockValidator.EXPECT().ValidateDeviceCode(testClient, testDeviceCode).Return(validator.ErrDeviceCodeExpired)
+
+	h := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockProvider,
+		validator: mockValidator,
+		devices:   mockDevices,
+	}
+
+	resp, err := h.Handle(context.Background(), buildDeviceCodeRequest(testClient.ID, testDeviceCode.DeviceCode))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "{\"error\":\"expired_token\"}", resp.Body)
+}
+
+func TestHandler_GivenUnknownDeviceCode_ReturnsBadRequest(t *testing.T) {
+	testClient := &dal.Client{ID: "3247023"}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockDevices := dalMock.NewMockDeviceCodeStore(ctrl)
+	mockDevices.EXPECT().Get(gomock.Any(), "23o4iu2o3i4u").Return(nil, dal.ErrDeviceCodeNotFound)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, "", "urn:ietf:params:oauth:grant-type:device_code", nil).Return(nil)
+
+	h := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockProvider,
+		validator: mockValidator,
+		devices:   mockDevices,
+	}
+
+	resp, err := h.Handle(context.Background(), buildDeviceCodeRequest(testClient.ID, "23o4iu2o3i4u"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, validator.ErrInvalidDeviceCode.Code, data["error"])
+}
+
+func buildPasswordRequest(clientId, clientSecret, username, password string) events.APIGatewayProxyRequest {
+	testBody := url.Values{
+		"client_id":     {clientId},
+		"client_secret": {clientSecret},
+		"grant_type":    {"password"},
+		"username":      {username},
+		"password":      {password},
+		"scope":         {"openid email"},
+	}
+
+	return events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+		},
+		Body: testBody.Encode(),
+		StageVariables: map[string]string{
+			"JWT_KEY_ID": "test key id",
+		},
+	}
+}
+
+func TestHandler_GivenPasswordGrant_ReturnsTokens(t *testing.T) {
+	testClient := &dal.Client{
+		ID:         "3247023",
+		Secrets:    []string{"my secret"},
+		GrantTypes: []string{"password"},
+	}
+	testUser := &dal.User{ID: "testUserId"}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockUsers := dalMock.NewMockUserProvider(ctrl)
+	mockUsers.EXPECT().GetByEmail(gomock.Any(), "my@email.com").Return(testUser, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, "2934uldnf", "password", []string{"openid", "email"}).Return(nil)
+
+	mockUserValidator := valMock.NewMockUserValidator(ctrl)
+	mockUserValidator.EXPECT().ValidatePassword(testUser, "myPassword1").Return(nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), int64(3600), "goidc").
+		DoAndReturn(func(_ interface{}, claims map[string]interface{}, _ int64, _ string) (*token.Token, error) {
+			assert.Equal(t, testUser.ID, claims["sub"])
+			assert.Equal(t, testClient.ID, claims["client_id"])
+			assert.Equal(t, []string{"openid", "email"}, claims["scopes"])
+
+			return &token.Token{AccessToken: "my.jwt.token"}, nil
+		})
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), gomock.Any(), testClient.ID).
+		Return(&token.Token{AccessToken: "my.id.token"}, nil)
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    mockTokenService,
+		clients:   mockProvider,
+		validator: mockValidator,
+		users:     mockUsers,
+		userVal:   mockUserValidator,
+	}
+
+	resp, err := h.Handle(context.Background(), buildPasswordRequest(testClient.ID, "2934uldnf", "my@email.com", "myPassword1"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, "my.jwt.token", data["access_token"])
+	assert.Equal(t, "my.id.token", data["id_token"])
+	assert.Nil(t, data["refresh_token"])
+}
+
+func TestHandler_GivenPasswordGrantForRefreshingClient_ReturnsRefreshToken(t *testing.T) {
+	testClient := &dal.Client{
+		ID:         "3247023",
+		Secrets:    []string{"my secret"},
+		GrantTypes: []string{"password", "refresh_token"},
+	}
+	testUser := &dal.User{ID: "testUserId"}
+
+	var testRefreshToken *dal.RefreshToken
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockUsers := dalMock.NewMockUserProvider(ctrl)
+	mockUsers.EXPECT().GetByEmail(gomock.Any(), "my@email.com").Return(testUser, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, "2934uldnf", "password", []string{"openid", "email"}).Return(nil)
+
+	mockUserValidator := valMock.NewMockUserValidator(ctrl)
+	mockUserValidator.EXPECT().ValidatePassword(testUser, "myPassword1").Return(nil)
+
+	mockRefresh := dalMock.NewMockRefreshTokenStore(ctrl)
+	mockRefresh.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, rt *dal.RefreshToken) error {
+		testRefreshToken = rt
+		return nil
+	})
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), int64(3600), "goidc").
+		Return(&token.Token{AccessToken: "my.jwt.token"}, nil)
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), gomock.Any(), testClient.ID).
+		Return(&token.Token{AccessToken: "my.id.token"}, nil)
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    mockTokenService,
+		clients:   mockProvider,
+		validator: mockValidator,
+		users:     mockUsers,
+		userVal:   mockUserValidator,
+		refresh:   mockRefresh,
+	}
+
+	resp, err := h.Handle(context.Background(), buildPasswordRequest(testClient.ID, "2934uldnf", "my@email.com", "myPassword1"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+
+	refreshToken := data["refresh_token"].(string)
+	assert.Equal(t, util.Sha256(refreshToken), testRefreshToken.ID)
+	assert.Equal(t, testClient.ID, testRefreshToken.ClientID)
+	assert.Equal(t, testUser.ID, testRefreshToken.UserID)
+	assert.Equal(t, []string{"openid", "email"}, testRefreshToken.Scopes)
+	assert.NotEmpty(t, testRefreshToken.FamilyID)
+}
+
+func TestHandler_GivenPasswordGrantForClientWithoutGrant_ReturnsBadRequest(t *testing.T) {
+	testClient := &dal.Client{
+		ID:         "3247023",
+		Secrets:    []string{"my secret"},
+		GrantTypes: []string{"client_credentials"},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, "2934uldnf", "password", []string{"openid", "email"}).Return(validator.ErrInvalidGrantType)
+
+	h := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockProvider,
+		validator: mockValidator,
+	}
+
+	resp, err := h.Handle(context.Background(), buildPasswordRequest(testClient.ID, "2934uldnf", "my@email.com", "myPassword1"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "{\"error\":\"unauthorized_client\",\"error_description\":\"invalid grant type\"}", resp.Body)
+}
+
+func TestHandler_GivenPasswordGrantWithUnknownUser_ReturnsBadRequest(t *testing.T) {
+	testClient := &dal.Client{ID: "3247023"}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockUsers := dalMock.NewMockUserProvider(ctrl)
+	mockUsers.EXPECT().GetByEmail(gomock.Any(), "my@email.com").Return(nil, dal.ErrUserNotFound)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, "2934uldnf", "password", gomock.Any()).Return(nil)
+
+	h := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockProvider,
+		validator: mockValidator,
+		users:     mockUsers,
+	}
+
+	resp, err := h.Handle(context.Background(), buildPasswordRequest(testClient.ID, "2934uldnf", "my@email.com", "myPassword1"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, errInvalidCredentials.Code, data["error"])
+}
+
+func TestHandler_GivenPasswordGrantWithInvalidPassword_ReturnsBadRequest(t *testing.T) {
+	testClient := &dal.Client{ID: "3247023"}
+	testUser := &dal.User{ID: "testUserId"}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockUsers := dalMock.NewMockUserProvider(ctrl)
+	mockUsers.EXPECT().GetByEmail(gomock.Any(), "my@email.com").Return(testUser, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, "2934uldnf", "password", gomock.Any()).Return(nil)
+
+	mockUserValidator := valMock.NewMockUserValidator(ctrl)
+	mockUserValidator.EXPECT().ValidatePassword(testUser, "wrong").Return(validator.ErrInvalidPassword)
+
+	h := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockProvider,
+		validator: mockValidator,
+		users:     mockUsers,
+		userVal:   mockUserValidator,
+	}
+
+	resp, err := h.Handle(context.Background(), buildPasswordRequest(testClient.ID, "2934uldnf", "my@email.com", "wrong"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, errInvalidCredentials.Code, data["error"])
+}
+
+func TestHandler_GivenBasicAuthorization_AuthenticatesClient(t *testing.T) {
+	testClient := &dal.Client{
+		ID:         "my client",
+		Secrets:    []string{"my secret"},
+		GrantTypes: []string{"client_credentials"},
+		Scopes:     []string{"openid"},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), "my client").Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(testClient, "client_secret_basic").Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, "my:secret", "client_credentials", []string{"openid"}).Return(nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&token.Token{AccessToken: "my.jwt.token"}, nil)
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    mockTokenService,
+		clients:   mockProvider,
+		validator: mockValidator,
+	}
+
+	testBody := url.Values{
+		"grant_type": {"client_credentials"},
+		"scope":      {"openid"},
+	}
+
+	// The client id and secret are form-urlencoded, before being base64 encoded.
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Headers: map[string]string{
+			"Content-Type":  "application/x-www-form-urlencoded",
+			"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("my+client:my%3Asecret")),
+		},
+		Body: testBody.Encode(),
+		StageVariables: map[string]string{
+			"JWT_KEY_ID": "test key id",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestHandler_GivenUnsupportedGrantType_ReturnsBadRequest(t *testing.T) {
+	// Listing a grant type the endpoint doesn't support doesn't allow it to be used.
+	testClient := &dal.Client{
+		ID:         "3247023",
+		Secrets:    []string{"my secret"},
+		GrantTypes: []string{"implicit"},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+
+	h := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockProvider,
+		validator: mockValidator,
+	}
+
+	testBody := url.Values{
+		"client_id":     {testClient.ID},
+		"client_secret": {"2934uldnf"},
+		"grant_type":    {"implicit"},
+	}
+
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+		},
+		Body: testBody.Encode(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var data map[string]string
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, util.ErrorCodeUnsupportedGrantType, data["error"])
+}
+
+func TestHandler_GivenMultipleAuthMethods_ReturnsBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testBody := url.Values{
+		"client_id":     {"3247023"},
+		"client_secret": {"2934uldnf"},
+		"grant_type":    {"client_credentials"},
+	}
+
+	h := &Handler{tokens: tokenMock.NewIssuerService(ctrl, gomock.Any())}
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Headers: map[string]string{
+			"Content-Type":  "application/x-www-form-urlencoded",
+			"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("3247023:2934uldnf")),
+		},
+		Body: testBody.Encode(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, clientauth.ErrMultipleAuthMethods.Code, data["error"])
+}
+
+func TestHandler_GivenUnregisteredAuthMethod_ReturnsUnauthorized(t *testing.T) {
+	testClient := &dal.Client{
+		ID:                      "3247023",
+		TokenEndpointAuthMethod: "client_secret_basic",
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(testClient, "client_secret_post").Return(validator.ErrInvalidAuthMethod)
+
+	h := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockProvider,
+		validator: mockValidator,
+	}
+
+	testBody := url.Values{
+		"client_id":     {testClient.ID},
+		"client_secret": {"2934uldnf"},
+		"grant_type":    {"client_credentials"},
+	}
+
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+		},
+		Body: testBody.Encode(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, validator.ErrInvalidAuthMethod.Code, data["error"])
+}
+
+func buildAssertionRequest(clientId, assertion string) events.APIGatewayProxyRequest {
+	testBody := url.Values{
+		"grant_type":            {"client_credentials"},
+		"scope":                 {"openid"},
+		"client_assertion":      {assertion},
+		"client_assertion_type": {oauth.ClientAssertionTypeJWTBearer},
+	}
+	if clientId != "" {
+		testBody.Set("client_id", clientId)
+	}
+
+	return events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+			"Host":         "example.com",
+		},
+		Body: testBody.Encode(),
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Stage: "prod",
+		},
+		StageVariables: map[string]string{
+			"JWT_KEY_ID": "test key id",
+		},
+	}
+}
+
+// buildAssertion returns an unsigned assertion, as the signature is
+// verified by the token service, which is mocked.
+func buildAssertion(claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]interface{}{"alg": "RS256", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+
+	return base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload) + ".c2lnbmF0dXJl"
+}
+
+func TestHandler_GivenPrivateKeyJWTAssertion_AuthenticatesClient(t *testing.T) {
+	testSet := &jwk.Set{}
+	testClient := &dal.Client{
+		ID:                      "3247023",
+		GrantTypes:              []string{"client_credentials"},
+		Scopes:                  []string{"openid"},
+		TokenEndpointAuthMethod: oauth.TokenEndpointAuthMethodPrivateKeyJWT,
+		JWKSUri:                 "https://client.example.com/jwks",
+	}
+	testClaims := gojwt.Claims{
+		"iss": testClient.ID,
+		"sub": testClient.ID,
+		"jti": "2l3k4j2l3k4j",
+		"exp": float64(1622505600),
+	}
+	testAssertion := buildAssertion(testClaims)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockKeys := jwkMock.NewMockFetcher(ctrl)
+	mockKeys.EXPECT().Fetch(gomock.Any(), testClient.JWKSUri).Return(testSet, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateClientAssertion(testClient, testClaims).Return(nil)
+	mockValidator.EXPECT().ValidateGrantRequest(testClient, "client_credentials", []string{"openid"}).Return(nil)
+
+	mockAssertions := dalMock.NewMockAssertionStore(ctrl)
+	mockAssertions.EXPECT().Use(gomock.Any(), &dal.UsedAssertion{
+		ID:      "3247023/2l3k4j2l3k4j",
+		Expires: 1622505600,
+	}).Return(nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, "https://example.com/prod")
+	mockTokenService.EXPECT().VerifyAssertionWithKeySet(testSet, testAssertion, testClient.ID, "https://example.com/prod/oauth/token").
+		Return(testClaims, nil)
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&token.Token{AccessToken: "my.jwt.token"}, nil)
+
+	h := &Handler{
+		sess:       mock.Session,
+		tokens:     mockTokenService,
+		clients:    mockProvider,
+		validator:  mockValidator,
+		keys:       mockKeys,
+		assertions: mockAssertions,
+	}
+
+	// The client is identified by the assertion's subject.
+	resp, err := h.Handle(context.Background(), buildAssertionRequest("", testAssertion))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestHandler_GivenClientSecretJWTAssertion_AuthenticatesClient(t *testing.T) {
+	testClient := &dal.Client{
+		ID:                      "3247023",
+		GrantTypes:              []string{"client_credentials"},
+		Scopes:                  []string{"openid"},
+		TokenEndpointAuthMethod: oauth.TokenEndpointAuthMethodClientSecretJWT,
+		SharedKey:               "my shared key",
+	}
+	testClaims := gojwt.Claims{
+		"iss": testClient.ID,
+		"sub": testClient.ID,
+		"jti": "2l3k4j2l3k4j",
+		"exp": float64(1622505600),
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateClientAssertion(testClient, testClaims).Return(nil)
+	mockValidator.EXPECT().ValidateGrantRequest(testClient, "client_credentials", []string{"openid"}).Return(nil)
+
+	mockAssertions := dalMock.NewMockAssertionStore(ctrl)
+	mockAssertions.EXPECT().Use(gomock.Any(), gomock.Any()).Return(nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, "https://example.com/prod")
+	mockTokenService.EXPECT().VerifyAssertion(gomock.Any(), "my.client.assertion", testClient.ID, "https://example.com/prod/oauth/token").
+		Return(testClaims, nil)
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&token.Token{AccessToken: "my.jwt.token"}, nil)
+
+	h := &Handler{
+		sess:       mock.Session,
+		tokens:     mockTokenService,
+		clients:    mockProvider,
+		validator:  mockValidator,
+		assertions: mockAssertions,
+	}
+
+	resp, err := h.Handle(context.Background(), buildAssertionRequest(testClient.ID, "my.client.assertion"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestHandler_GivenReplayedAssertion_ReturnsUnauthorized(t *testing.T) {
+	testClient := &dal.Client{
+		ID:                      "3247023",
+		TokenEndpointAuthMethod: oauth.TokenEndpointAuthMethodPrivateKeyJWT,
+		JWKS:                    &jwk.Set{},
+	}
+	testClaims := gojwt.Claims{
+		"iss": testClient.ID,
+		"sub": testClient.ID,
+		"jti": "2l3k4j2l3k4j",
+		"exp": float64(1622505600),
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateClientAssertion(testClient, testClaims).Return(nil)
+
+	mockAssertions := dalMock.NewMockAssertionStore(ctrl)
+	mockAssertions.EXPECT().Use(gomock.Any(), gomock.Any()).Return(dal.ErrAssertionUsed)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, "https://example.com/prod")
+	mockTokenService.EXPECT().VerifyAssertionWithKeySet(testClient.JWKS, "my.client.assertion", testClient.ID, gomock.Any()).
+		Return(testClaims, nil)
+
+	h := &Handler{
+		tokens:     mockTokenService,
+		clients:    mockProvider,
+		validator:  mockValidator,
+		assertions: mockAssertions,
+	}
+
+	resp, err := h.Handle(context.Background(), buildAssertionRequest(testClient.ID, "my.client.assertion"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, validator.ErrInvalidAssertion.Code, data["error"])
+}
+
+func TestHandler_GivenAssertionForOtherAudience_ReturnsUnauthorized(t *testing.T) {
+	testClient := &dal.Client{
+		ID:                      "3247023",
+		TokenEndpointAuthMethod: oauth.TokenEndpointAuthMethodPrivateKeyJWT,
+		JWKS:                    &jwk.Set{},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, "https://example.com/prod")
+	mockTokenService.EXPECT().VerifyAssertionWithKeySet(testClient.JWKS, "my.client.assertion", testClient.ID, gomock.Any()).
+		Return(nil, token.ErrInvalidAudience)
+
+	h := &Handler{
+		tokens:  mockTokenService,
+		clients: mockProvider,
+	}
+
+	resp, err := h.Handle(context.Background(), buildAssertionRequest(testClient.ID, "my.client.assertion"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, validator.ErrInvalidAssertion.Code, data["error"])
+}
+
+func TestHandler_GivenAssertionForClientWithoutKeys_ReturnsUnauthorized(t *testing.T) {
+	testClient := &dal.Client{
+		ID:      "3247023",
+		Secrets: []string{"my secret"},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	h := &Handler{tokens: tokenMock.NewIssuerService(ctrl, "https://example.com/prod"), clients: mockProvider}
+
+	resp, err := h.Handle(context.Background(), buildAssertionRequest(testClient.ID, "my.client.assertion"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, validator.ErrInvalidAuthMethod.Code, data["error"])
+}
+
+func TestHandler_GivenInvalidAssertionType_ReturnsUnauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	req := buildAssertionRequest("3247023", "my.client.assertion")
+	req.Body = url.Values{
+		"client_id":             {"3247023"},
+		"client_assertion":      {"my.client.assertion"},
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:saml2-bearer"},
+	}.Encode()
+
+	resp, err := (&Handler{tokens: tokenMock.NewIssuerService(ctrl, "https://example.com/prod")}).Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, clientauth.ErrInvalidAssertionType.Code, data["error"])
+}
+
+func buildTokenExchangeRequest(values url.Values) events.APIGatewayProxyRequest {
+	values.Set("client_id", "3247023")
+	values.Set("client_secret", "2934uldnf")
+	values.Set("grant_type", oauth.GrantTypeTokenExchange)
+
+	return events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+		},
+		Body: values.Encode(),
+		StageVariables: map[string]string{
+			"JWT_KEY_ID": "test key id",
+		},
+	}
+}
+
+func TestHandler_GivenTokenExchange_ReturnsDownstreamToken(t *testing.T) {
+	util.Freeze()
+	defer util.Reset()
+
+	testClient := &dal.Client{
+		ID:                "3247023",
+		Secrets:           []string{"my secret"},
+		GrantTypes:        []string{oauth.GrantTypeTokenExchange},
+		ExchangeAudiences: []string{"orders"},
+	}
+	testSubject := gojwt.Claims{
+		"sub":       "user123",
+		"client_id": "web",
+		"jti":       "2l3k4j2l3k4j",
+		"scopes":    []interface{}{"openid", "orders:read", "orders:write"},
+		"exp":       float64(util.Time().Add(30 * time.Minute).Unix()),
+		"act":       map[string]interface{}{"sub": "gateway"},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(testClient, "2934uldnf", oauth.GrantTypeTokenExchange, nil).Return(nil)
+	mockValidator.EXPECT().ValidateTokenExchange(testClient, "orders", []string{"orders:read"}, []string{"openid", "orders:read", "orders:write"}).Return(nil)
+
+	mockRevocations := dalMock.NewMockRevocationStore(ctrl)
+	mockRevocations.EXPECT().IsRevoked(gomock.Any(), "2l3k4j2l3k4j").Return(false, nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), "my.subject.token", "goidc").Return(testSubject, nil)
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), gomock.Any(), int64(1800), "orders").
+		DoAndReturn(func(alg gojwt.Algorithm, claims map[string]interface{}, expiry int64, audience string) (*token.Token, error) {
+			assert.Equal(t, "user123", claims["sub"])
+			assert.Equal(t, testClient.ID, claims["client_id"])
+			assert.Equal(t, []string{"orders:read"}, claims["scopes"])
+			assert.Equal(t, map[string]interface{}{
+				"sub": testClient.ID,
+				"act": map[string]interface{}{"sub": "gateway"},
+			}, claims["act"])
+
+			return &token.Token{AccessToken: "my.exchanged.token"}, nil
+		})
+
+	h := &Handler{
+		sess:        mock.Session,
+		tokens:      mockTokenService,
+		clients:     mockProvider,
+		validator:   mockValidator,
+		revocations: mockRevocations,
+	}
+
+	resp, err := h.Handle(context.Background(), buildTokenExchangeRequest(url.Values{
+		"subject_token":      {"my.subject.token"},
+		"subject_token_type": {oauth.TokenTypeAccessToken},
+		"audience":           {"orders"},
+		"scope":              {"orders:read"},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data token.Token
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, "my.exchanged.token", data.AccessToken)
+	assert.Equal(t, oauth.TokenTypeAccessToken, data.IssuedTokenType)
+}
+
+func TestHandler_GivenTokenExchangeForUnlistedAudience_ReturnsBadRequest(t *testing.T) {
+	testClient := &dal.Client{
+		ID:                "3247023",
+		Secrets:           []string{"my secret"},
+		GrantTypes:        []string{oauth.GrantTypeTokenExchange},
+		ExchangeAudiences: []string{"orders"},
+	}
+	testSubject := gojwt.Claims{
+		"sub":    "user123",
+		"scopes": []interface{}{"openid"},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenExchange(testClient, "payments", []string{"openid"}, []string{"openid"}).Return(validator.ErrInvalidTarget)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), "my.subject.token", "goidc").Return(testSubject, nil)
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    mockTokenService,
+		clients:   mockProvider,
+		validator: mockValidator,
+	}
+
+	resp, err := h.Handle(context.Background(), buildTokenExchangeRequest(url.Values{
+		"subject_token":      {"my.subject.token"},
+		"subject_token_type": {oauth.TokenTypeAccessToken},
+		"audience":           {"payments"},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, validator.ErrInvalidTarget.Code, data["error"])
+}
+
+func TestHandler_GivenInvalidSubjectToken_ReturnsBadRequest(t *testing.T) {
+	testClient := &dal.Client{
+		ID:         "3247023",
+		Secrets:    []string{"my secret"},
+		GrantTypes: []string{oauth.GrantTypeTokenExchange},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), "my.subject.token", "goidc").Return(nil, token.ErrTokenExpired)
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    mockTokenService,
+		clients:   mockProvider,
+		validator: mockValidator,
+	}
+
+	resp, err := h.Handle(context.Background(), buildTokenExchangeRequest(url.Values{
+		"subject_token":      {"my.subject.token"},
+		"subject_token_type": {oauth.TokenTypeAccessToken},
+		"audience":           {"orders"},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, errInvalidSubjectToken.Code, data["error"])
+}
+
+func TestHandler_GivenRevokedSubjectToken_ReturnsBadRequest(t *testing.T) {
+	testClient := &dal.Client{
+		ID:         "3247023",
+		Secrets:    []string{"my secret"},
+		GrantTypes: []string{oauth.GrantTypeTokenExchange},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+	mockRevocations := dalMock.NewMockRevocationStore(ctrl)
+	mockRevocations.EXPECT().IsRevoked(gomock.Any(), "2l3k4j2l3k4j").Return(true, nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), "my.subject.token", "goidc").
+		Return(gojwt.Claims{"sub": "user123", "jti": "2l3k4j2l3k4j"}, nil)
+
+	h := &Handler{
+		sess:        mock.Session,
+		tokens:      mockTokenService,
+		clients:     mockProvider,
+		validator:   mockValidator,
+		revocations: mockRevocations,
+	}
+
+	resp, err := h.Handle(context.Background(), buildTokenExchangeRequest(url.Values{
+		"subject_token":      {"my.subject.token"},
+		"subject_token_type": {oauth.TokenTypeAccessToken},
+		"audience":           {"orders"},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, errInvalidSubjectToken.Code, data["error"])
+}
+
+func TestHandler_GivenSubjectTokenWithinClockSkew_ReturnsInvalidGrant(t *testing.T) {
+	util.Freeze()
+	defer util.Reset()
+
+	testClient := &dal.Client{
+		ID:                "3247023",
+		Secrets:           []string{"my secret"},
+		GrantTypes:        []string{oauth.GrantTypeTokenExchange},
+		ExchangeAudiences: []string{"orders"},
+	}
+
+	// The token expired, but was accepted within the clock skew.
+	testSubject := gojwt.Claims{
+		"sub":    "user123",
+		"scopes": []interface{}{"openid"},
+		"exp":    float64(util.Time().Add(-10 * time.Second).Unix()),
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenExchange(testClient, "orders", []string{"openid"}, []string{"openid"}).Return(nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), "my.subject.token", "goidc").Return(testSubject, nil)
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    mockTokenService,
+		clients:   mockProvider,
+		validator: mockValidator,
+	}
+
+	resp, err := h.Handle(context.Background(), buildTokenExchangeRequest(url.Values{
+		"subject_token":      {"my.subject.token"},
+		"subject_token_type": {oauth.TokenTypeAccessToken},
+		"audience":           {"orders"},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, util.ErrorCodeInvalidGrant, data["error"])
+}
+
+func TestHandler_GivenUnsupportedSubjectTokenType_ReturnsBadRequest(t *testing.T) {
+	testClient := &dal.Client{
+		ID:         "3247023",
+		Secrets:    []string{"my secret"},
+		GrantTypes: []string{oauth.GrantTypeTokenExchange},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(gomock.Any(), gomock.Any()).Return(nil)
+	mockValidator.EXPECT().ValidateTokenRequest(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+	h := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockProvider,
+		validator: mockValidator,
+	}
+
+	resp, err := h.Handle(context.Background(), buildTokenExchangeRequest(url.Values{
+		"subject_token":      {"my.subject.token"},
+		"subject_token_type": {"urn:ietf:params:oauth:token-type:saml2"},
+		"audience":           {"orders"},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, errUnsupportedTokenType.Code, data["error"])
+}
+
+func buildJWTBearerRequest(assertion string) events.APIGatewayProxyRequest {
+	testBody := url.Values{
+		"grant_type": {oauth.GrantTypeJWTBearer},
+		"assertion":  {assertion},
+		"scope":      {"deploy"},
+	}
+
+	return events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+			"Host":         "example.com",
+		},
+		Body: testBody.Encode(),
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Stage: "prod",
+		},
+		StageVariables: map[string]string{
+			"JWT_KEY_ID": "test key id",
+		},
+	}
+}
+
+func TestHandler_GivenJWTBearerGrant_ReturnsToken(t *testing.T) {
+	testSet := &jwk.Set{}
+	testIssuer := &dal.TrustedIssuer{
+		Issuer:  "https://ci.example.com",
+		JWKSUri: "https://ci.example.com/jwks",
+		Subjects: map[string]string{
+			"repo:example/app": "3247023",
+		},
+	}
+	testClient := &dal.Client{
+		ID:         "3247023",
+		GrantTypes: []string{oauth.GrantTypeJWTBearer},
+		Scopes:     []string{"deploy"},
+	}
+	testClaims := gojwt.Claims{
+		"iss": testIssuer.Issuer,
+		"sub": "repo:example/app",
+		"jti": "29384",
+		"exp": float64(1622505600),
+	}
+	testAssertion := buildAssertion(testClaims)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIssuers := dalMock.NewMockTrustedIssuerProvider(ctrl)
+	mockIssuers.EXPECT().Get(gomock.Any(), testIssuer.Issuer).Return(testIssuer, nil)
+
+	mockKeys := jwkMock.NewMockFetcher(ctrl)
+	mockKeys.EXPECT().Fetch(gomock.Any(), testIssuer.JWKSUri).Return(testSet, nil)
+
+	mockAssertions := dalMock.NewMockAssertionStore(ctrl)
+	mockAssertions.EXPECT().Use(gomock.Any(), &dal.UsedAssertion{ID: "https://ci.example.com/29384", Expires: 1622505600}).Return(nil)
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClient.ID).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateGrantRequest(testClient, oauth.GrantTypeJWTBearer, []string{"deploy"}).Return(nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, "https://example.com/prod")
+	mockTokenService.EXPECT().VerifyAssertionWithKeySet(testSet, testAssertion, testIssuer.Issuer, "https://example.com/prod/oauth/token").
+		Return(testClaims, nil)
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), map[string]interface{}{
+		"sub":       testClient.ID,
+		"client_id": testClient.ID,
+		"scopes":    []string{"deploy"},
+	}, int64(3600), "goidc").Return(&token.Token{AccessToken: "my.jwt.token"}, nil)
+
+	h := &Handler{
+		sess:       mock.Session,
+		tokens:     mockTokenService,
+		clients:    mockProvider,
+		validator:  mockValidator,
+		keys:       mockKeys,
+		issuers:    mockIssuers,
+		assertions: mockAssertions,
+	}
+
+	resp, err := h.Handle(context.Background(), buildJWTBearerRequest(testAssertion))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestHandler_GivenUsedJWTBearerAssertion_ReturnsBadRequest(t *testing.T) {
+	testIssuer := &dal.TrustedIssuer{
+		Issuer: "https://ci.example.com",
+		JWKS:   &jwk.Set{},
+		Subjects: map[string]string{
+			"repo:example/app": "3247023",
+		},
+	}
+	testClaims := gojwt.Claims{
+		"iss": testIssuer.Issuer,
+		"sub": "repo:example/app",
+		"jti": "29384",
+		"exp": float64(1622505600),
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIssuers := dalMock.NewMockTrustedIssuerProvider(ctrl)
+	mockIssuers.EXPECT().Get(gomock.Any(), testIssuer.Issuer).Return(testIssuer, nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, "https://example.com/prod")
+	mockTokenService.EXPECT().VerifyAssertionWithKeySet(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(testClaims, nil)
+
+	mockAssertions := dalMock.NewMockAssertionStore(ctrl)
+	mockAssertions.EXPECT().Use(gomock.Any(), gomock.Any()).Return(dal.ErrAssertionUsed)
+
+	h := &Handler{
+		tokens:     mockTokenService,
+		issuers:    mockIssuers,
+		assertions: mockAssertions,
+	}
+
+	resp, err := h.Handle(context.Background(), buildJWTBearerRequest(buildAssertion(testClaims)))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, errInvalidAssertion.Code, data["error"])
+}
+
+func TestHandler_GivenJWTBearerAssertionWithoutJti_ReturnsBadRequest(t *testing.T) {
+	testIssuer := &dal.TrustedIssuer{
+		Issuer: "https://ci.example.com",
+		JWKS:   &jwk.Set{},
+		Subjects: map[string]string{
+			"repo:example/app": "3247023",
+		},
+	}
+	testClaims := gojwt.Claims{
+		"iss": testIssuer.Issuer,
+		"sub": "repo:example/app",
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIssuers := dalMock.NewMockTrustedIssuerProvider(ctrl)
+	mockIssuers.EXPECT().Get(gomock.Any(), testIssuer.Issuer).Return(testIssuer, nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, "https://example.com/prod")
+	mockTokenService.EXPECT().VerifyAssertionWithKeySet(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(testClaims, nil)
+
+	h := &Handler{
+		tokens:  mockTokenService,
+		issuers: mockIssuers,
+	}
+
+	resp, err := h.Handle(context.Background(), buildJWTBearerRequest(buildAssertion(testClaims)))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, errInvalidAssertion.Code, data["error"])
+}
+
+func TestHandler_GivenJWTBearerGrantFromIssuerWithoutKeys_ReturnsBadRequest(t *testing.T) {
+	testIssuer := &dal.TrustedIssuer{Issuer: "https://ci.example.com"}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIssuers := dalMock.NewMockTrustedIssuerProvider(ctrl)
+	mockIssuers.EXPECT().Get(gomock.Any(), testIssuer.Issuer).Return(testIssuer, nil)
+
+	h := &Handler{
+		tokens:  tokenMock.NewIssuerService(ctrl, "https://example.com/prod"),
+		issuers: mockIssuers,
+		keys:    jwkMock.NewMockFetcher(ctrl),
+	}
+
+	resp, err := h.Handle(context.Background(), buildJWTBearerRequest(buildAssertion(map[string]interface{}{
+		"iss": testIssuer.Issuer,
+		"sub": "repo:example/app",
+	})))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, errInvalidAssertion.Code, data["error"])
+}
+
+func TestHandler_GivenJWTBearerGrantFromUntrustedIssuer_ReturnsBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIssuers := dalMock.NewMockTrustedIssuerProvider(ctrl)
+	mockIssuers.EXPECT().Get(gomock.Any(), "https://untrusted.example.com").Return(nil, dal.ErrTrustedIssuerNotFound)
+
+	h := &Handler{tokens: tokenMock.NewIssuerService(ctrl, "https://example.com/prod"), issuers: mockIssuers}
+
+	resp, err := h.Handle(context.Background(), buildJWTBearerRequest(buildAssertion(map[string]interface{}{
+		"iss": "https://untrusted.example.com",
+		"sub": "repo:example/app",
+	})))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, errInvalidAssertion.Code, data["error"])
+}
+
+func TestHandler_GivenJWTBearerGrantWithInvalidSignature_ReturnsBadRequest(t *testing.T) {
+	testIssuer := &dal.TrustedIssuer{
+		Issuer:   "https://ci.example.com",
+		JWKS:     &jwk.Set{},
+		Audience: "goidc",
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIssuers := dalMock.NewMockTrustedIssuerProvider(ctrl)
+	mockIssuers.EXPECT().Get(gomock.Any(), testIssuer.Issuer).Return(testIssuer, nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, "https://example.com/prod")
+	mockTokenService.EXPECT().VerifyAssertionWithKeySet(testIssuer.JWKS, gomock.Any(), testIssuer.Issuer, "goidc").
+		Return(nil, token.ErrInvalidSignature)
+
+	h := &Handler{
+		tokens:  mockTokenService,
+		issuers: mockIssuers,
+	}
+
+	resp, err := h.Handle(context.Background(), buildJWTBearerRequest(buildAssertion(map[string]interface{}{
+		"iss": testIssuer.Issuer,
+		"sub": "repo:example/app",
+	})))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, errInvalidAssertion.Code, data["error"])
+}
+
+func TestHandler_GivenJWTBearerGrantForUnmappedSubject_ReturnsBadRequest(t *testing.T) {
+	testIssuer := &dal.TrustedIssuer{
+		Issuer: "https://ci.example.com",
+		JWKS:   &jwk.Set{},
+		Subjects: map[string]string{
+			"repo:example/app": "3247023",
+		},
+	}
+	testClaims := gojwt.Claims{
+		"iss": testIssuer.Issuer,
+		"sub": "repo:example/other",
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIssuers := dalMock.NewMockTrustedIssuerProvider(ctrl)
+	mockIssuers.EXPECT().Get(gomock.Any(), testIssuer.Issuer).Return(testIssuer, nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, "https://example.com/prod")
+	mockTokenService.EXPECT().VerifyAssertionWithKeySet(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(testClaims, nil)
+
+	h := &Handler{
+		tokens:  mockTokenService,
+		issuers: mockIssuers,
+	}
+
+	resp, err := h.Handle(context.Background(), buildJWTBearerRequest(buildAssertion(testClaims)))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, errInvalidAssertion.Code, data["error"])
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws/session"
+
+	"github.com/reecerussell/goidc"
+	"github.com/reecerussell/goidc/clientauth"
+	"github.com/reecerussell/goidc/dal"
+	"github.com/reecerussell/goidc/dal/dynamo"
+	"github.com/reecerussell/goidc/jwk"
+	"github.com/reecerussell/goidc/oauth"
+	"github.com/reecerussell/goidc/token"
+	"github.com/reecerussell/goidc/util"
+	"github.com/reecerussell/goidc/validator"
+)
+
+var (
+	errInvalidClient = util.NewOAuthError(http.StatusUnauthorized, util.ErrorCodeInvalidClient, "invalid client")
+	errMissingToken  = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidRequest, "missing token")
+)
+
+func main() {
+	log.Println("Starting...")
+
+	sess := session.Must(session.NewSession())
+
+	hdlr := &Handler{
+		sess:        sess,
+		tokens:      token.New(""), // The issuer is set per request.
+		clients:     dynamo.NewClientProvider(sess),
+		validator:   validator.NewClientValidator(),
+		refresh:     dynamo.NewRefreshTokenStore(sess),
+		revocations: dynamo.NewRevocationStore(sess),
+		keys:        jwk.NewFetcher(),
+		assertions:  dynamo.NewAssertionStore(sess),
+	}
+
+	lambda.Start(hdlr.Handle)
+}
+
+// Handler is used to provide a Lambda handler function.
+type Handler struct {
+	sess        *session.Session
+	tokens      token.Service
+	clients     dal.ClientProvider
+	validator   validator.ClientValidator
+	refresh     dal.RefreshTokenStore
+	revocations dal.RevocationStore
+	keys        jwk.Fetcher
+	assertions  dal.AssertionStore
+}
+
+// ResponseModel is an introspection response, as defined by RFC 7662.
+// Only Active is set for tokens which are not active.
+type ResponseModel struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+}
+
+func (h *Handler) Handle(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if req.HTTPMethod != http.MethodPost {
+		return util.RespondMethodNotAllowed(errors.New("method not allowed")), nil
+	}
+
+	if util.Header(req, "Content-Type") != "application/x-www-form-urlencoded" {
+		return util.RespondOAuthError(errors.New("invalid content type")), nil
+	}
+
+	data := util.ReadForm(req)
+
+	ctx = goidc.NewContext(ctx, &req)
+	c := *h
+	c.tokens = oauth.IssuerTokens(ctx, req, h.tokens)
+	h = &c
+
+	err := h.authenticate(ctx, req, data)
+	if err != nil {
+		if _, ok := err.(*util.OAuthError); ok {
+			return util.RespondOAuthError(errInvalidClient), nil
+		}
+
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	if data.Get("token") == "" {
+		return util.RespondOAuthError(errMissingToken), nil
+	}
+
+	return h.introspect(ctx, data)
+}
+
+// authenticate authenticates the client which sent req, in the same way as the
+// token endpoint. Only confidential clients may introspect tokens, so clients
+// which didn't send an assertion must send a valid secret.
+func (h *Handler) authenticate(ctx context.Context, req events.APIGatewayProxyRequest, data url.Values) error {
+	auth := clientauth.New(h.clients, h.validator, h.tokens, h.keys, h.assertions)
+	client, creds, err := auth.Authenticate(ctx, req, data)
+	if err != nil {
+		return err
+	}
+
+	if creds.Assertion != "" {
+		return nil
+	}
+
+	return h.validator.ValidateClientSecret(client, creds.Secret)
+}
+
+// introspect determines whether the token is an active access or refresh token.
+// The token_type_hint is used to determine which type of token to try first.
+func (h *Handler) introspect(ctx context.Context, data url.Values) (events.APIGatewayProxyResponse, error) {
+	raw := data.Get("token")
+
+	if data.Get("token_type_hint") == "refresh_token" {
+		resp, err := h.introspectRefreshToken(ctx, raw)
+		if err != nil || resp.Active {
+			return respond(resp, err)
+		}
+
+		return respond(h.introspectAccessToken(ctx, raw))
+	}
+
+	resp, err := h.introspectAccessToken(ctx, raw)
+	if err != nil || resp.Active {
+		return respond(resp, err)
+	}
+
+	return respond(h.introspectRefreshToken(ctx, raw))
+}
+
+func (h *Handler) introspectAccessToken(ctx context.Context, raw string) (*ResponseModel, error) {
+	alg, _ := token.NewKMSAlgorithm(h.sess, goidc.StageVariable(ctx, "JWT_KEY_ID"))
+	claims, err := h.tokens.VerifyToken(alg, raw, "goidc")
+	if err != nil {
+		return &ResponseModel{Active: false}, nil
+	}
+
+	if jti, ok := claims.String("jti"); ok {
+		revoked, err := h.revocations.IsRevoked(ctx, jti)
+		if err != nil {
+			return nil, err
+		}
+
+		if revoked {
+			return &ResponseModel{Active: false}, nil
+		}
+	}
+
+	clientId, _ := claims.String("client_id")
+	sub, _ := claims.String("sub")
+	exp, _ := claims.Int("exp")
+
+	return &ResponseModel{
+		Active:    true,
+		Scope:     strings.Join(token.Scopes(claims), " "),
+		ClientID:  clientId,
+		Sub:       sub,
+		Exp:       int64(exp),
+		TokenType: "Bearer",
+	}, nil
+}
+
+func (h *Handler) introspectRefreshToken(ctx context.Context, raw string) (*ResponseModel, error) {
+	rt, err := h.refresh.Get(ctx, util.Sha256(raw))
+	if err != nil {
+		if err == dal.ErrRefreshTokenNotFound {
+			return &ResponseModel{Active: false}, nil
+		}
+
+		return nil, err
+	}
+
+	// A used refresh token has been rotated, so is no longer active.
+	if rt.Revoked || rt.Used || rt.Expires <= util.Time().Unix() {
+		return &ResponseModel{Active: false}, nil
+	}
+
+	return &ResponseModel{
+		Active:   true,
+		Scope:    strings.Join(rt.Scopes, " "),
+		ClientID: rt.ClientID,
+		Sub:      rt.UserID,
+		Exp:      rt.Expires,
+	}, nil
+}
+
+func respond(resp *ResponseModel, err error) (events.APIGatewayProxyResponse, error) {
+	if err != nil {
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	return util.RespondOk(resp), nil
+}
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/awstesting/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/reecerussell/gojwt"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc/dal"
+	dalMock "github.com/reecerussell/goidc/dal/mock"
+	"github.com/reecerussell/goidc/oauth"
+	tokenMock "github.com/reecerussell/goidc/token/mock"
+	"github.com/reecerussell/goidc/util"
+	"github.com/reecerussell/goidc/validator"
+	valMock "github.com/reecerussell/goidc/validator/mock"
+)
+
+const (
+	testClientId     = "3247023"
+	testClientSecret = "2934uldnf"
+	testToken        = "my.test.token"
+)
+
+var testClient = &dal.Client{
+	ID:      testClientId,
+	Secrets: []string{util.Sha256(testClientSecret)},
+}
+
+func buildRequest(values url.Values) events.APIGatewayProxyRequest {
+	values.Set("client_id", testClientId)
+	values.Set("client_secret", testClientSecret)
+
+	return events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+		},
+		Body: values.Encode(),
+		StageVariables: map[string]string{
+			"JWT_KEY_ID": "test key id",
+		},
+	}
+}
+
+func readResponse(resp events.APIGatewayProxyResponse) map[string]interface{} {
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+
+	return data
+}
+
+func TestHandler_GivenActiveAccessToken_ReturnsActive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(testClient, oauth.TokenEndpointAuthMethodClientSecretPost).Return(nil)
+	mockValidator.EXPECT().ValidateClientSecret(testClient, testClientSecret).Return(nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), testToken, "goidc").Return(gojwt.Claims{
+		"sub":       "testUserId",
+		"client_id": "otherClient",
+		"scopes":    []interface{}{"openid", "email"},
+		"exp":       float64(1622505600),
+	}, nil)
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    mockTokenService,
+		clients:   mockProvider,
+		validator: mockValidator,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(url.Values{"token": {testToken}}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, map[string]interface{}{
+		"active":     true,
+		"scope":      "openid email",
+		"client_id":  "otherClient",
+		"sub":        "testUserId",
+		"exp":        float64(1622505600),
+		"token_type": "Bearer",
+	}, readResponse(resp))
+}
+
+func TestHandler_GivenActiveRefreshToken_ReturnsActive(t *testing.T) {
+	util.Freeze()
+	defer util.Reset()
+
+	testRefreshToken := &dal.RefreshToken{
+		ID:       util.Sha256(testToken),
+		ClientID: testClientId,
+		UserID:   "testUserId",
+		Scopes:   []string{"openid"},
+		Expires:  util.Time().Unix() + 60,
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(testClient, oauth.TokenEndpointAuthMethodClientSecretPost).Return(nil)
+	mockValidator.EXPECT().ValidateClientSecret(testClient, testClientSecret).Return(nil)
+
+	mockRefresh := dalMock.NewMockRefreshTokenStore(ctrl)
+	mockRefresh.EXPECT().Get(gomock.Any(), testRefreshToken.ID).Return(testRefreshToken, nil)
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockProvider,
+		validator: mockValidator,
+		refresh:   mockRefresh,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(url.Values{
+		"token":           {testToken},
+		"token_type_hint": {"refresh_token"},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, map[string]interface{}{
+		"active":    true,
+		"scope":     "openid",
+		"client_id": testClientId,
+		"sub":       "testUserId",
+		"exp":       float64(testRefreshToken.Expires),
+	}, readResponse(resp))
+}
+
+func TestHandler_GivenRevokedRefreshToken_ReturnsInactive(t *testing.T) {
+	testRefreshToken := &dal.RefreshToken{
+		ID:      util.Sha256(testToken),
+		Revoked: true,
+		Expires: util.Time().Unix() + 60,
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(testClient, oauth.TokenEndpointAuthMethodClientSecretPost).Return(nil)
+	mockValidator.EXPECT().ValidateClientSecret(testClient, testClientSecret).Return(nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), testToken, "goidc").Return(nil, errors.New("invalid token structure"))
+
+	mockRefresh := dalMock.NewMockRefreshTokenStore(ctrl)
+	mockRefresh.EXPECT().Get(gomock.Any(), testRefreshToken.ID).Return(testRefreshToken, nil)
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    mockTokenService,
+		clients:   mockProvider,
+		validator: mockValidator,
+		refresh:   mockRefresh,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(url.Values{"token": {testToken}}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"active":false}`, resp.Body)
+}
+
+func TestHandler_GivenUnknownToken_ReturnsInactive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(testClient, oauth.TokenEndpointAuthMethodClientSecretPost).Return(nil)
+	mockValidator.EXPECT().ValidateClientSecret(testClient, testClientSecret).Return(nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), testToken, "goidc").Return(nil, errors.New("token has expired"))
+
+	mockRefresh := dalMock.NewMockRefreshTokenStore(ctrl)
+	mockRefresh.EXPECT().Get(gomock.Any(), util.Sha256(testToken)).Return(nil, dal.ErrRefreshTokenNotFound)
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    mockTokenService,
+		clients:   mockProvider,
+		validator: mockValidator,
+		refresh:   mockRefresh,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(url.Values{"token": {testToken}}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"active":false}`, resp.Body)
+}
+
+func TestHandler_GivenInvalidClientSecret_ReturnsUnauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(testClient, oauth.TokenEndpointAuthMethodClientSecretPost).Return(nil)
+	mockValidator.EXPECT().ValidateClientSecret(testClient, testClientSecret).Return(validator.ErrInvalidSecret)
+
+	h := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockProvider,
+		validator: mockValidator,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(url.Values{"token": {testToken}}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestHandler_GivenBasicAuth_ReturnsActive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(testClient, oauth.TokenEndpointAuthMethodClientSecretBasic).Return(nil)
+	mockValidator.EXPECT().ValidateClientSecret(testClient, testClientSecret).Return(nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), testToken, "goidc").Return(gojwt.Claims{"sub": "testUserId"}, nil)
+
+	h := &Handler{
+		sess:      mock.Session,
+		tokens:    mockTokenService,
+		clients:   mockProvider,
+		validator: mockValidator,
+	}
+
+	req := buildRequest(url.Values{"token": {testToken}})
+	req.Body = url.Values{"token": {testToken}}.Encode()
+	req.Headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(testClientId+":"+testClientSecret))
+
+	resp, err := h.Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, true, readResponse(resp)["active"])
+}
+
+func TestHandler_GivenClientAssertion_ReturnsActive(t *testing.T) {
+	testAssertionClient := &dal.Client{
+		ID:                      testClientId,
+		TokenEndpointAuthMethod: oauth.TokenEndpointAuthMethodClientSecretJWT,
+		SharedKey:               "my shared key",
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testAssertionClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateClientAssertion(testAssertionClient, gomock.Any()).Return(nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyAssertion(gomock.Any(), "my.client.assertion", testClientId, "https://example.com/prod/oauth/token").
+		Return(gojwt.Claims{"jti": "29384", "exp": float64(1622505600)}, nil)
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), testToken, "goidc").Return(gojwt.Claims{"sub": "testUserId"}, nil)
+
+	mockAssertions := dalMock.NewMockAssertionStore(ctrl)
+	mockAssertions.EXPECT().Use(gomock.Any(), &dal.UsedAssertion{ID: testClientId + "/29384", Expires: 1622505600}).Return(nil)
+
+	h := &Handler{
+		sess:       mock.Session,
+		tokens:     mockTokenService,
+		clients:    mockProvider,
+		validator:  mockValidator,
+		assertions: mockAssertions,
+	}
+
+	req := buildRequest(url.Values{})
+	req.Body = url.Values{
+		"token":                 {testToken},
+		"client_id":             {testClientId},
+		"client_assertion":      {"my.client.assertion"},
+		"client_assertion_type": {oauth.ClientAssertionTypeJWTBearer},
+	}.Encode()
+	req.Headers["Host"] = "example.com"
+	req.RequestContext.Stage = "prod"
+
+	resp, err := h.Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, true, readResponse(resp)["active"])
+}
+
+func TestHandler_GivenUnknownClient_ReturnsUnauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(nil, dal.ErrClientNotFound)
+
+	h := &Handler{
+		tokens:  tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients: mockProvider,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(url.Values{"token": {testToken}}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "{\"error\":\"invalid_client\",\"error_description\":\"invalid client\"}", resp.Body)
+	assert.Equal(t, "Basic", resp.Headers["WWW-Authenticate"])
+}
+
+func TestHandler_GivenNoToken_ReturnsBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(testClient, oauth.TokenEndpointAuthMethodClientSecretPost).Return(nil)
+	mockValidator.EXPECT().ValidateClientSecret(testClient, testClientSecret).Return(nil)
+
+	h := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockProvider,
+		validator: mockValidator,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(url.Values{}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "{\"error\":\"invalid_request\",\"error_description\":\"missing token\"}", resp.Body)
+}
+
+func TestHandler_GivenInvalidMethod_ReturnsMethodNotAllowed(t *testing.T) {
+	h := &Handler{}
+
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestHandler_GivenRevokedAccessToken_ReturnsInactive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(testClient, oauth.TokenEndpointAuthMethodClientSecretPost).Return(nil)
+	mockValidator.EXPECT().ValidateClientSecret(testClient, testClientSecret).Return(nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), testToken, "goidc").Return(gojwt.Claims{
+		"sub": "testUserId",
+		"jti": "2o3i4u2o3i4u",
+	}, nil)
+
+	mockRevocations := dalMock.NewMockRevocationStore(ctrl)
+	mockRevocations.EXPECT().IsRevoked(gomock.Any(), "2o3i4u2o3i4u").Return(true, nil)
+
+	mockRefresh := dalMock.NewMockRefreshTokenStore(ctrl)
+	mockRefresh.EXPECT().Get(gomock.Any(), util.Sha256(testToken)).Return(nil, dal.ErrRefreshTokenNotFound)
+
+	h := &Handler{
+		sess:        mock.Session,
+		tokens:      mockTokenService,
+		clients:     mockProvider,
+		validator:   mockValidator,
+		refresh:     mockRefresh,
+		revocations: mockRevocations,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(url.Values{"token": {testToken}}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"active":false}`, resp.Body)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws/session"
+
+	"github.com/reecerussell/goidc"
+	"github.com/reecerussell/goidc/jwk"
+	"github.com/reecerussell/goidc/util"
+)
+
+func main() {
+	log.Println("Starting...")
+
+	sess := session.Must(session.NewSession())
+
+	hdlr := &Handler{
+		keys: jwk.NewProvider(sess),
+	}
+
+	lambda.Start(hdlr.Handle)
+}
+
+// Handler is used to provide a Lambda handler function.
+type Handler struct {
+	keys jwk.Provider
+}
+
+func (h *Handler) Handle(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if req.HTTPMethod != http.MethodGet {
+		return util.RespondMethodNotAllowed(errors.New("method not allowed")), nil
+	}
+
+	ctx = goidc.NewContext(ctx, &req)
+	set, err := h.keys.Get(ctx, goidc.StageVariable(ctx, "JWT_KEY_ID"))
+	if err != nil {
+		log.Printf("Failed to get public keys: %v\n", err)
+		return util.RespondError(err), nil
+	}
+
+	resp := util.RespondOk(set)
+	resp.Headers["Cache-Control"] = fmt.Sprintf("public, max-age=%d", int(jwk.DefaultCacheDuration.Seconds()))
+
+	return resp, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc/jwk"
+	jwkMock "github.com/reecerussell/goidc/jwk/mock"
+)
+
+func TestHandler_ReturnsKeySet(t *testing.T) {
+	testKeyId := "23847023"
+	testSet := &jwk.Set{
+		Keys: []*jwk.Key{
+			{KeyType: "RSA", Use: "sig", KeyID: jwk.KeyID(testKeyId), Algorithm: "RS256", N: "n", E: "AQAB"},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := jwkMock.NewMockProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testKeyId).Return(testSet, nil)
+
+	h := &Handler{keys: mockProvider}
+
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		StageVariables: map[string]string{
+			"JWT_KEY_ID": testKeyId,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "public, max-age=3600", resp.Headers["Cache-Control"])
+
+	var data jwk.Set
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, testSet, &data)
+}
+
+func TestHandler_WhereProviderFails_ReturnsInternalServerError(t *testing.T) {
+	testError := errors.New("kms error")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := jwkMock.NewMockProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil, testError)
+
+	h := &Handler{keys: mockProvider}
+
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		StageVariables: map[string]string{
+			"JWT_KEY_ID": "23847023",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Empty(t, resp.Headers["Cache-Control"])
+}
+
+func TestHandler_GivenInvalidMethod_ReturnsMethodNotAllowed(t *testing.T) {
+	h := &Handler{}
+
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws/session"
+
+	"github.com/reecerussell/goidc"
+	"github.com/reecerussell/goidc/clientauth"
+	"github.com/reecerussell/goidc/dal"
+	"github.com/reecerussell/goidc/dal/dynamo"
+	"github.com/reecerussell/goidc/jwk"
+	"github.com/reecerussell/goidc/oauth"
+	"github.com/reecerussell/goidc/token"
+	"github.com/reecerussell/goidc/util"
+	"github.com/reecerussell/goidc/validator"
+)
+
+var (
+	errInvalidClient = util.NewOAuthError(http.StatusUnauthorized, util.ErrorCodeInvalidClient, "invalid client")
+	errMissingToken  = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidRequest, "missing token")
+	errWrongClient   = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeUnauthorizedClient, "the token was not issued to the client")
+)
+
+func main() {
+	log.Println("Starting...")
+
+	sess := session.Must(session.NewSession())
+
+	hdlr := &Handler{
+		sess:        sess,
+		tokens:      token.New(""), // The issuer is set per request.
+		clients:     dynamo.NewClientProvider(sess),
+		validator:   validator.NewClientValidator(),
+		refresh:     dynamo.NewRefreshTokenStore(sess),
+		revocations: dynamo.NewRevocationStore(sess),
+		keys:        jwk.NewFetcher(),
+		assertions:  dynamo.NewAssertionStore(sess),
+	}
+
+	lambda.Start(hdlr.Handle)
+}
+
+// Handler is used to provide a Lambda handler function.
+type Handler struct {
+	sess        *session.Session
+	tokens      token.Service
+	clients     dal.ClientProvider
+	validator   validator.ClientValidator
+	refresh     dal.RefreshTokenStore
+	revocations dal.RevocationStore
+	keys        jwk.Fetcher
+	assertions  dal.AssertionStore
+}
+
+// revokeFunc attempts to revoke a token of a specific type, returning
+// false if the token is not of that type.
+type revokeFunc func(ctx context.Context, c *dal.Client, raw string) (bool, error)
+
+func (h *Handler) Handle(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if req.HTTPMethod != http.MethodPost {
+		return util.RespondMethodNotAllowed(errors.New("method not allowed")), nil
+	}
+
+	if util.Header(req, "Content-Type") != "application/x-www-form-urlencoded" {
+		return util.RespondOAuthError(errors.New("invalid content type")), nil
+	}
+
+	data := util.ReadForm(req)
+
+	ctx = goidc.NewContext(ctx, &req)
+	c := *h
+	c.tokens = oauth.IssuerTokens(ctx, req, h.tokens)
+	h = &c
+
+	client, err := h.authenticate(ctx, req, data)
+	if err != nil {
+		if _, ok := err.(*util.OAuthError); ok {
+			return util.RespondOAuthError(errInvalidClient), nil
+		}
+
+		return util.RespondOAuthServerError(err), nil
+	}
+
+	raw := data.Get("token")
+	if raw == "" {
+		return util.RespondOAuthError(errMissingToken), nil
+	}
+
+	revokers := []revokeFunc{h.revokeAccessToken, h.revokeRefreshToken}
+	if data.Get("token_type_hint") == "refresh_token" {
+		revokers = []revokeFunc{h.revokeRefreshToken, h.revokeAccessToken}
+	}
+
+	for _, revoke := range revokers {
+		ok, err := revoke(ctx, client, raw)
+		if err != nil {
+			if err == errWrongClient {
+				return util.RespondOAuthError(err), nil
+			}
+
+			return util.RespondOAuthServerError(err), nil
+		}
+
+		if ok {
+			break
+		}
+	}
+
+	// As per RFC 7009, invalid and unknown tokens do not cause an error,
+	// as the client cannot handle such an error in a reasonable way.
+	return util.RespondOk(nil), nil
+}
+
+// authenticate authenticates the client which sent req, in the same way as the
+// token endpoint. Public clients cannot authenticate, so may revoke their own
+// tokens with only their client id, as per RFC 7009, however, confidential
+// clients which didn't send an assertion must send a valid secret.
+func (h *Handler) authenticate(ctx context.Context, req events.APIGatewayProxyRequest, data url.Values) (*dal.Client, error) {
+	auth := clientauth.New(h.clients, h.validator, h.tokens, h.keys, h.assertions)
+	client, creds, err := auth.Authenticate(ctx, req, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if creds.Assertion == "" {
+		err = h.validator.ValidateRevocationRequest(client, creds.Secret)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return client, nil
+}
+
+func (h *Handler) revokeAccessToken(ctx context.Context, c *dal.Client, raw string) (bool, error) {
+	alg, _ := token.NewKMSAlgorithm(h.sess, goidc.StageVariable(ctx, "JWT_KEY_ID"))
+	claims, err := h.tokens.VerifyToken(alg, raw, "goidc")
+	if err != nil {
+		return false, nil
+	}
+
+	if clientId, _ := claims.String("client_id"); clientId != c.ID {
+		return true, errWrongClient
+	}
+
+	jti, _ := claims.String("jti")
+	exp, _ := claims.Int("exp")
+	if jti == "" {
+		log.Println("Unable to revoke access token without a jti")
+		return true, nil
+	}
+
+	err = h.revocations.Revoke(ctx, &dal.RevokedToken{
+		ID:      jti,
+		Expires: int64(exp),
+	})
+	if err != nil {
+		return true, err
+	}
+
+	return true, nil
+}
+
+// revokeRefreshToken revokes the refresh token, along with every other token
+// in its family, so that tokens it has been rotated into are also revoked.
+func (h *Handler) revokeRefreshToken(ctx context.Context, c *dal.Client, raw string) (bool, error) {
+	rt, err := h.refresh.Get(ctx, util.Sha256(raw))
+	if err != nil {
+		if err == dal.ErrRefreshTokenNotFound {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	if rt.ClientID != c.ID {
+		return true, errWrongClient
+	}
+
+	err = h.refresh.RevokeFamily(ctx, rt.FamilyID)
+	if err != nil {
+		return true, err
+	}
+
+	err = h.revocations.Revoke(ctx, &dal.RevokedToken{
+		ID:      rt.ID,
+		Expires: rt.Expires,
+	})
+	if err != nil {
+		return true, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/awstesting/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/reecerussell/gojwt"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc/dal"
+	dalMock "github.com/reecerussell/goidc/dal/mock"
+	"github.com/reecerussell/goidc/oauth"
+	tokenMock "github.com/reecerussell/goidc/token/mock"
+	"github.com/reecerussell/goidc/util"
+	"github.com/reecerussell/goidc/validator"
+	valMock "github.com/reecerussell/goidc/validator/mock"
+)
+
+const (
+	testClientId     = "3247023"
+	testClientSecret = "2934uldnf"
+	testToken        = "my.test.token"
+)
+
+var testClient = &dal.Client{
+	ID:      testClientId,
+	Secrets: []string{util.Sha256(testClientSecret)},
+}
+
+func buildRequest(values url.Values) events.APIGatewayProxyRequest {
+	values.Set("client_id", testClientId)
+	values.Set("client_secret", testClientSecret)
+
+	return events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+		},
+		Body: values.Encode(),
+		StageVariables: map[string]string{
+			"JWT_KEY_ID": "test key id",
+		},
+	}
+}
+
+func buildMocks(ctrl *gomock.Controller) (*dalMock.MockClientProvider, *valMock.MockClientValidator) {
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(testClient, oauth.TokenEndpointAuthMethodClientSecretPost).Return(nil)
+	mockValidator.EXPECT().ValidateRevocationRequest(testClient, testClientSecret).Return(nil)
+
+	return mockProvider, mockValidator
+}
+
+func TestHandler_GivenAccessToken_RevokesJti(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider, mockValidator := buildMocks(ctrl)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), testToken, "goidc").Return(gojwt.Claims{
+		"client_id": testClientId,
+		"jti":       "2o3i4u2o3i4u",
+		"exp":       float64(1622505600),
+	}, nil)
+
+	mockRevocations := dalMock.NewMockRevocationStore(ctrl)
+	mockRevocations.EXPECT().Revoke(gomock.Any(), &dal.RevokedToken{ID: "2o3i4u2o3i4u", Expires: 1622505600}).Return(nil)
+
+	h := &Handler{
+		sess:        mock.Session,
+		tokens:      mockTokenService,
+		clients:     mockProvider,
+		validator:   mockValidator,
+		revocations: mockRevocations,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(url.Values{"token": {testToken}}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestHandler_GivenRefreshToken_RevokesFamily(t *testing.T) {
+	testRefreshToken := &dal.RefreshToken{
+		ID:       util.Sha256(testToken),
+		FamilyID: "23k4j2l3k4",
+		ClientID: testClientId,
+		Expires:  1622505600,
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider, mockValidator := buildMocks(ctrl)
+
+	mockRefresh := dalMock.NewMockRefreshTokenStore(ctrl)
+	mockRefresh.EXPECT().Get(gomock.Any(), testRefreshToken.ID).Return(testRefreshToken, nil)
+	mockRefresh.EXPECT().RevokeFamily(gomock.Any(), testRefreshToken.FamilyID).Return(nil)
+
+	mockRevocations := dalMock.NewMockRevocationStore(ctrl)
+	mockRevocations.EXPECT().Revoke(gomock.Any(), &dal.RevokedToken{ID: testRefreshToken.ID, Expires: 1622505600}).Return(nil)
+
+	h := &Handler{
+		sess:        mock.Session,
+		tokens:      tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:     mockProvider,
+		validator:   mockValidator,
+		refresh:     mockRefresh,
+		revocations: mockRevocations,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(url.Values{
+		"token":           {testToken},
+		"token_type_hint": {"refresh_token"},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestHandler_GivenUnknownToken_ReturnsOk(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider, mockValidator := buildMocks(ctrl)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), testToken, "goidc").Return(nil, errors.New("invalid token structure"))
+
+	mockRefresh := dalMock.NewMockRefreshTokenStore(ctrl)
+	mockRefresh.EXPECT().Get(gomock.Any(), util.Sha256(testToken)).Return(nil, dal.ErrRefreshTokenNotFound)
+
+	h := &Handler{
+		sess:        mock.Session,
+		tokens:      mockTokenService,
+		clients:     mockProvider,
+		validator:   mockValidator,
+		refresh:     mockRefresh,
+		revocations: dalMock.NewMockRevocationStore(ctrl),
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(url.Values{"token": {testToken}}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestHandler_GivenTokenIssuedToAnotherClient_ReturnsBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider, mockValidator := buildMocks(ctrl)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), testToken, "goidc").Return(gojwt.Claims{
+		"client_id": "otherClient",
+		"jti":       "2o3i4u2o3i4u",
+	}, nil)
+
+	h := &Handler{
+		sess:        mock.Session,
+		tokens:      mockTokenService,
+		clients:     mockProvider,
+		validator:   mockValidator,
+		revocations: dalMock.NewMockRevocationStore(ctrl),
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(url.Values{"token": {testToken}}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "{\"error\":\"unauthorized_client\",\"error_description\":\"the token was not issued to the client\"}", resp.Body)
+}
+
+func TestHandler_GivenInvalidClientSecret_ReturnsUnauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockValidator := valMock.NewMockClientValidator(ctrl)
+	mockValidator.EXPECT().ValidateTokenEndpointAuthMethod(testClient, oauth.TokenEndpointAuthMethodClientSecretPost).Return(nil)
+	mockValidator.EXPECT().ValidateRevocationRequest(testClient, testClientSecret).Return(validator.ErrInvalidSecret)
+
+	h := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockProvider,
+		validator: mockValidator,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(url.Values{"token": {testToken}}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "{\"error\":\"invalid_client\",\"error_description\":\"invalid client\"}", resp.Body)
+}
+
+func TestHandler_GivenClientIdOnlyForConfidentialClient_ReturnsUnauthorized(t *testing.T) {
+	testClient := &dal.Client{
+		ID:                      testClientId,
+		TokenEndpointAuthMethod: oauth.TokenEndpointAuthMethodPrivateKeyJWT,
+		JWKSUri:                 "https://client.example.com/jwks",
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	// The token must not be revoked.
+	h := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockProvider,
+		validator: validator.NewClientValidator(),
+		refresh:   dalMock.NewMockRefreshTokenStore(ctrl),
+	}
+
+	req := buildRequest(url.Values{})
+	req.Body = url.Values{"client_id": {testClientId}, "token": {testToken}}.Encode()
+
+	resp, err := h.Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "Basic", resp.Headers["WWW-Authenticate"])
+}
+
+func TestHandler_GivenPublicClient_RevokesToken(t *testing.T) {
+	testClient := &dal.Client{
+		ID:                      testClientId,
+		TokenEndpointAuthMethod: oauth.TokenEndpointAuthMethodNone,
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := dalMock.NewMockClientProvider(ctrl)
+	mockProvider.EXPECT().Get(gomock.Any(), testClientId).Return(testClient, nil)
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), testToken, "goidc").Return(gojwt.Claims{
+		"client_id": testClientId,
+		"jti":       "2o3i4u2o3i4u",
+		"exp":       float64(1622505600),
+	}, nil)
+
+	mockRevocations := dalMock.NewMockRevocationStore(ctrl)
+	mockRevocations.EXPECT().Revoke(gomock.Any(), &dal.RevokedToken{ID: "2o3i4u2o3i4u", Expires: 1622505600}).Return(nil)
+
+	h := &Handler{
+		sess:        mock.Session,
+		tokens:      mockTokenService,
+		clients:     mockProvider,
+		validator:   validator.NewClientValidator(),
+		revocations: mockRevocations,
+	}
+
+	req := buildRequest(url.Values{})
+	req.Body = url.Values{"client_id": {testClientId}, "token": {testToken}}.Encode()
+
+	resp, err := h.Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestHandler_GivenNoToken_ReturnsBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider, mockValidator := buildMocks(ctrl)
+
+	h := &Handler{
+		tokens:    tokenMock.NewIssuerService(ctrl, gomock.Any()),
+		clients:   mockProvider,
+		validator: mockValidator,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(url.Values{}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestHandler_GivenInvalidMethod_ReturnsMethodNotAllowed(t *testing.T) {
+	h := &Handler{}
+
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws/session"
+
+	"github.com/reecerussell/goidc"
+	"github.com/reecerussell/goidc/dal"
+	"github.com/reecerussell/goidc/dal/dynamo"
+	"github.com/reecerussell/goidc/oauth"
+	"github.com/reecerussell/goidc/token"
+	"github.com/reecerussell/goidc/util"
+)
+
+// userInfoExpiry is the lifetime, in seconds, of a signed UserInfo response.
+const userInfoExpiry = 300
+
+var (
+	errMissingToken      = errors.New("missing access token")
+	errInvalidToken      = util.NewOAuthError(http.StatusUnauthorized, util.ErrorCodeInvalidToken, "invalid access token")
+	errInsufficientScope = util.NewOAuthError(http.StatusForbidden, util.ErrorCodeInsufficientScope, "the access token was not granted the openid scope")
+)
+
+func main() {
+	log.Println("Starting...")
+
+	sess := session.Must(session.NewSession())
+
+	hdlr := &Handler{
+		sess:        sess,
+		tokens:      token.New(""), // The issuer is set per request.
+		users:       dynamo.NewUserProvider(sess),
+		revocations: dynamo.NewRevocationStore(sess),
+	}
+
+	lambda.Start(hdlr.Handle)
+}
+
+// Handler is used to provide a Lambda handler function.
+type Handler struct {
+	sess        *session.Session
+	tokens      token.Service
+	users       dal.UserProvider
+	revocations dal.RevocationStore
+}
+
+func (h *Handler) Handle(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if req.HTTPMethod != http.MethodGet && req.HTTPMethod != http.MethodPost {
+		return util.RespondMethodNotAllowed(errors.New("method not allowed")), nil
+	}
+
+	accessToken := util.BearerToken(req)
+	if accessToken == "" {
+		return unauthorized(errMissingToken), nil
+	}
+
+	ctx = goidc.NewContext(ctx, &req)
+	c := *h
+	c.tokens = oauth.IssuerTokens(ctx, req, h.tokens)
+	h = &c
+
+	alg, _ := token.NewKMSAlgorithm(h.sess, goidc.StageVariable(ctx, "JWT_KEY_ID"))
+	claims, err := h.tokens.VerifyToken(alg, accessToken, "goidc")
+	if err != nil {
+		log.Printf("Invalid access token: %v\n", err)
+		return unauthorized(errInvalidToken), nil
+	}
+
+	if jti, ok := claims.String("jti"); ok {
+		revoked, err := h.revocations.IsRevoked(ctx, jti)
+		if err != nil {
+			return util.RespondError(err), nil
+		}
+
+		if revoked {
+			return unauthorized(errInvalidToken), nil
+		}
+	}
+
+	scopes := token.Scopes(claims)
+	if !hasScope(scopes, oauth.ScopeOpenID) {
+		resp := util.RespondOAuthError(errInsufficientScope)
+		resp.Headers["WWW-Authenticate"] = `Bearer error="insufficient_scope", scope="openid"`
+		return resp, nil
+	}
+
+	sub, _ := claims.String("sub")
+	user, err := h.users.Get(ctx, sub)
+	if err != nil {
+		if err == dal.ErrUserNotFound {
+			return unauthorized(errInvalidToken), nil
+		}
+
+		return util.RespondError(err), nil
+	}
+
+	info := oauth.UserClaims(user, scopes)
+
+	if strings.Contains(util.Header(req, "Accept"), "application/jwt") {
+		clientId, _ := claims.String("client_id")
+		jwt, err := h.tokens.GenerateToken(alg, info, userInfoExpiry, clientId)
+		if err != nil {
+			return util.RespondError(err), nil
+		}
+
+		return events.APIGatewayProxyResponse{
+			StatusCode: http.StatusOK,
+			Headers: map[string]string{
+				"Content-Type": "application/jwt",
+			},
+			Body: jwt.AccessToken,
+		}, nil
+	}
+
+	return util.RespondOk(info), nil
+}
+
+// unauthorized builds a 401 response, with a WWW-Authenticate header as
+// per RFC 6750. The error code is only included in the header for OAuth
+// errors, as requests without a token are simply challenged.
+func unauthorized(err error) events.APIGatewayProxyResponse {
+	oerr, ok := err.(*util.OAuthError)
+	if !ok {
+		resp := util.RespondUnauthorized(err)
+		resp.Headers["WWW-Authenticate"] = "Bearer"
+		return resp
+	}
+
+	resp := util.RespondOAuthError(oerr)
+	resp.Headers["WWW-Authenticate"] = `Bearer error="` + oerr.Code + `"`
+
+	return resp
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
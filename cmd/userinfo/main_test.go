@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/awstesting/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/reecerussell/gojwt"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc/dal"
+	dalMock "github.com/reecerussell/goidc/dal/mock"
+	"github.com/reecerussell/goidc/token"
+	tokenMock "github.com/reecerussell/goidc/token/mock"
+)
+
+func buildRequest(accessToken string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		Headers: map[string]string{
+			"Authorization": "Bearer " + accessToken,
+		},
+		StageVariables: map[string]string{
+			"JWT_KEY_ID": "test key id",
+		},
+	}
+}
+
+func TestHandler_GivenValidToken_ReturnsClaims(t *testing.T) {
+	testAccessToken := "my.access.token"
+	testUser := &dal.User{
+		ID:            "23847",
+		Email:         "test@test.go",
+		EmailVerified: true,
+		Name:          "Test User",
+	}
+	testClaims := gojwt.Claims{
+		"sub":    testUser.ID,
+		"scopes": []interface{}{"openid", "email"},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), testAccessToken, "goidc").Return(testClaims, nil)
+
+	mockUserProvider := dalMock.NewMockUserProvider(ctrl)
+	mockUserProvider.EXPECT().Get(gomock.Any(), testUser.ID).Return(testUser, nil)
+
+	h := &Handler{
+		sess:   mock.Session,
+		tokens: mockTokenService,
+		users:  mockUserProvider,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest(testAccessToken))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(resp.Body), &data)
+	assert.Equal(t, map[string]interface{}{
+		"sub":            testUser.ID,
+		"email":          testUser.Email,
+		"email_verified": true,
+	}, data)
+}
+
+func TestHandler_VerifiesTokenWithRequestIssuer(t *testing.T) {
+	testAccessToken := "my.access.token"
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIssuerTokenService := tokenMock.NewMockService(ctrl)
+	mockIssuerTokenService.EXPECT().VerifyToken(gomock.Any(), testAccessToken, "goidc").Return(nil, token.ErrInvalidIssuer)
+
+	mockTokenService := tokenMock.NewMockService(ctrl)
+	mockTokenService.EXPECT().WithIssuer("https://example.com/prod").Return(mockIssuerTokenService)
+
+	h := &Handler{
+		sess:   mock.Session,
+		tokens: mockTokenService,
+	}
+
+	req := buildRequest(testAccessToken)
+	req.Headers["Host"] = "example.com"
+	req.RequestContext.Stage = "prod"
+
+	resp, err := h.Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestHandler_GivenJwtAcceptHeader_ReturnsSignedClaims(t *testing.T) {
+	testAccessToken := "my.access.token"
+	testClientId := "2o3i4u"
+	testUser := &dal.User{ID: "23847"}
+	testClaims := gojwt.Claims{
+		"sub":       testUser.ID,
+		"client_id": testClientId,
+		"scopes":    []interface{}{"openid"},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), testAccessToken, "goidc").Return(testClaims, nil)
+	mockTokenService.EXPECT().GenerateToken(gomock.Any(), map[string]interface{}{"sub": testUser.ID}, int64(userInfoExpiry), testClientId).
+		Return(&token.Token{AccessToken: "my.signed.userinfo"}, nil)
+
+	mockUserProvider := dalMock.NewMockUserProvider(ctrl)
+	mockUserProvider.EXPECT().Get(gomock.Any(), testUser.ID).Return(testUser, nil)
+
+	h := &Handler{
+		sess:   mock.Session,
+		tokens: mockTokenService,
+		users:  mockUserProvider,
+	}
+
+	req := buildRequest(testAccessToken)
+	req.Headers["Accept"] = "application/jwt"
+
+	resp, err := h.Handle(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/jwt", resp.Headers["Content-Type"])
+	assert.Equal(t, "my.signed.userinfo", resp.Body)
+}
+
+func TestHandler_GivenNoToken_ReturnsUnauthorized(t *testing.T) {
+	h := &Handler{}
+
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "Bearer", resp.Headers["WWW-Authenticate"])
+}
+
+func TestHandler_GivenInvalidToken_ReturnsUnauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("token has expired"))
+
+	h := &Handler{
+		sess:   mock.Session,
+		tokens: mockTokenService,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest("my.access.token"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, `Bearer error="invalid_token"`, resp.Headers["WWW-Authenticate"])
+	assert.Equal(t, "{\"error\":\"invalid_token\",\"error_description\":\"invalid access token\"}", resp.Body)
+}
+
+func TestHandler_WhereTokenHasNoOpenIDScope_ReturnsForbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(gojwt.Claims{"sub": "23847", "scopes": []interface{}{"email"}}, nil)
+
+	h := &Handler{
+		sess:   mock.Session,
+		tokens: mockTokenService,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest("my.access.token"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Contains(t, resp.Headers["WWW-Authenticate"], "insufficient_scope")
+}
+
+func TestHandler_WhereUserDoesNotExist_ReturnsUnauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(gojwt.Claims{"sub": "23847", "scopes": []interface{}{"openid"}}, nil)
+
+	mockUserProvider := dalMock.NewMockUserProvider(ctrl)
+	mockUserProvider.EXPECT().Get(gomock.Any(), "23847").Return(nil, dal.ErrUserNotFound)
+
+	h := &Handler{
+		sess:   mock.Session,
+		tokens: mockTokenService,
+		users:  mockUserProvider,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest("my.access.token"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestHandler_GivenInvalidMethod_ReturnsMethodNotAllowed(t *testing.T) {
+	h := &Handler{}
+
+	resp, err := h.Handle(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodDelete,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestHandler_GivenRevokedToken_ReturnsUnauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTokenService := tokenMock.NewIssuerService(ctrl, gomock.Any())
+	mockTokenService.EXPECT().VerifyToken(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(gojwt.Claims{"sub": "23847", "jti": "2o3i4u2o3i4u", "scopes": []interface{}{"openid"}}, nil)
+
+	mockRevocations := dalMock.NewMockRevocationStore(ctrl)
+	mockRevocations.EXPECT().IsRevoked(gomock.Any(), "2o3i4u2o3i4u").Return(true, nil)
+
+	h := &Handler{
+		sess:        mock.Session,
+		tokens:      mockTokenService,
+		revocations: mockRevocations,
+	}
+
+	resp, err := h.Handle(context.Background(), buildRequest("my.access.token"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, `Bearer error="invalid_token"`, resp.Headers["WWW-Authenticate"])
+}
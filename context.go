@@ -66,3 +66,12 @@ func StageVariable(ctx context.Context, key string) string {
 
 	return value.(string)
 }
+
+// LookupStageVariable returns a stage variable from the Context, and
+// whether it exists. This is used for optional stage variables.
+func LookupStageVariable(ctx context.Context, key string) (string, bool) {
+	ck := NewContextKey(fmt.Sprintf("STAGE:%s", key))
+	value, ok := ctx.Value(ck).(string)
+
+	return value, ok
+}
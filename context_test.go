@@ -100,3 +100,20 @@ func TestStageVariable_GivenInvalidKey_Panics(t *testing.T) {
 
 	_ = StageVariable(ctx, "env")
 }
+
+func TestLookupStageVariable_GivenVariable_ReturnsValue(t *testing.T) {
+	req := &events.APIGatewayProxyRequest{
+		StageVariables: map[string]string{
+			"env": "test",
+		},
+	}
+
+	ctx := NewContext(context.Background(), req)
+
+	value, ok := LookupStageVariable(ctx, "env")
+	assert.True(t, ok)
+	assert.Equal(t, "test", value)
+
+	_, ok = LookupStageVariable(ctx, "missing")
+	assert.False(t, ok)
+}
@@ -0,0 +1,109 @@
+package cookie
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// Codec errors.
+var (
+	ErrInvalidKey    = errors.New("cookie key must be at least 32 bytes")
+	ErrInvalidCookie = errors.New("invalid cookie")
+)
+
+// Codec is used to encode cookie values, so they can be neither read nor
+// modified by the user agent. Values are encrypted using AES-GCM, then
+// signed using HMAC-SHA256, along with the name of the cookie, so a value
+// cannot be moved from one cookie to another.
+type Codec struct {
+	aead    cipher.AEAD
+	hashKey []byte
+}
+
+// NewCodec returns a new Codec, with encryption and signing keys derived
+// from key, which should be at least 32 random bytes.
+func NewCodec(key []byte) (*Codec, error) {
+	if len(key) < 32 {
+		return nil, ErrInvalidKey
+	}
+
+	block, err := aes.NewCipher(deriveKey(key, "encryption"))
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Codec{
+		aead:    aead,
+		hashKey: deriveKey(key, "signing"),
+	}, nil
+}
+
+// deriveKey derives a key for the given purpose from key, so
+// the same key is never used for encryption and signing.
+func deriveKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+
+	return mac.Sum(nil)
+}
+
+// Encode returns value encrypted and signed, to be set as the cookie name.
+func (c *Codec) Encode(name, value string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext := c.aead.Seal(nonce, nonce, []byte(value), []byte(name))
+	payload := base64.RawURLEncoding.EncodeToString(ciphertext)
+
+	return payload + "." + c.sign(name, payload), nil
+}
+
+// Decode verifies and decrypts value, read from the cookie name. If the value
+// was not encoded by a Codec with the same key, or was encoded for another
+// cookie, ErrInvalidCookie will be returned as the error.
+func (c *Codec) Decode(name, value string) (string, error) {
+	i := strings.LastIndex(value, ".")
+	if i < 0 {
+		return "", ErrInvalidCookie
+	}
+
+	payload, signature := value[:i], value[i+1:]
+	if !hmac.Equal([]byte(signature), []byte(c.sign(name, payload))) {
+		return "", ErrInvalidCookie
+	}
+
+	ciphertext, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil || len(ciphertext) < c.aead.NonceSize() {
+		return "", ErrInvalidCookie
+	}
+
+	nonce, ciphertext := ciphertext[:c.aead.NonceSize()], ciphertext[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+
+	return string(plaintext), nil
+}
+
+// sign returns the signature of the payload for the cookie name.
+func (c *Codec) sign(name, payload string) string {
+	mac := hmac.New(sha256.New, c.hashKey)
+	mac.Write([]byte(name + "|" + payload))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,63 @@
+package cookie
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestNewCodec_GivenShortKey_ReturnsError(t *testing.T) {
+	c, err := NewCodec([]byte("short"))
+	assert.Nil(t, c)
+	assert.Equal(t, ErrInvalidKey, err)
+}
+
+func TestCodec_EncodeDecode(t *testing.T) {
+	c, err := NewCodec(testKey)
+	assert.NoError(t, err)
+
+	value, err := c.Encode("session", "my session id")
+	assert.NoError(t, err)
+
+	// The value must not be readable by the user agent.
+	assert.NotContains(t, value, "my session id")
+
+	decoded, err := c.Decode("session", value)
+	assert.NoError(t, err)
+	assert.Equal(t, "my session id", decoded)
+}
+
+func TestCodec_Decode_ReturnsError(t *testing.T) {
+	c, _ := NewCodec(testKey)
+	value, _ := c.Encode("session", "my session id")
+
+	t.Run("Given Other Cookie Name", func(t *testing.T) {
+		_, err := c.Decode("other", value)
+		assert.Equal(t, ErrInvalidCookie, err)
+	})
+
+	t.Run("Given Modified Value", func(t *testing.T) {
+		modified := "A" + value[1:]
+		if modified == value {
+			modified = "B" + value[1:]
+		}
+
+		_, err := c.Decode("session", modified)
+		assert.Equal(t, ErrInvalidCookie, err)
+	})
+
+	t.Run("Given Other Key", func(t *testing.T) {
+		other, _ := NewCodec([]byte(strings.Repeat("x", 32)))
+
+		_, err := other.Decode("session", value)
+		assert.Equal(t, ErrInvalidCookie, err)
+	})
+
+	t.Run("Given Unsigned Value", func(t *testing.T) {
+		_, err := c.Decode("session", "bXkgc2Vzc2lvbiBpZA")
+		assert.Equal(t, ErrInvalidCookie, err)
+	})
+}
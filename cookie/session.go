@@ -0,0 +1,23 @@
+package cookie
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/reecerussell/goidc"
+)
+
+// SessionName is the name of the cookie holding the id of
+// the user's single sign-on session.
+const SessionName = "goidc_session"
+
+// NewSessionCodec returns the codec used to encode session cookies, using
+// the base64 encoded key in the SESSION_KEY stage variable.
+func NewSessionCodec(ctx context.Context) (*Codec, error) {
+	key, err := base64.StdEncoding.DecodeString(goidc.StageVariable(ctx, "SESSION_KEY"))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCodec(key)
+}
@@ -0,0 +1,46 @@
+package cookie
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc"
+)
+
+func buildSessionContext(key string) context.Context {
+	req := events.APIGatewayProxyRequest{
+		StageVariables: map[string]string{
+			"SESSION_KEY": key,
+		},
+	}
+
+	return goidc.NewContext(context.Background(), &req)
+}
+
+func TestNewSessionCodec_GivenKey_ReturnsCodec(t *testing.T) {
+	ctx := buildSessionContext(base64.StdEncoding.EncodeToString(testKey))
+
+	c, err := NewSessionCodec(ctx)
+	assert.NoError(t, err)
+
+	// Values must be interchangeable with a codec using the same key.
+	value, _ := c.Encode(SessionName, "my session id")
+	other, _ := NewCodec(testKey)
+	decoded, err := other.Decode(SessionName, value)
+	assert.NoError(t, err)
+	assert.Equal(t, "my session id", decoded)
+}
+
+func TestNewSessionCodec_GivenInvalidKey_ReturnsError(t *testing.T) {
+	c, err := NewSessionCodec(buildSessionContext("not base64!"))
+	assert.Nil(t, c)
+	assert.Error(t, err)
+
+	c, err = NewSessionCodec(buildSessionContext(base64.StdEncoding.EncodeToString([]byte("short"))))
+	assert.Nil(t, c)
+	assert.Equal(t, ErrInvalidKey, err)
+}
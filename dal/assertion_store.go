@@ -0,0 +1,17 @@
+package dal
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrAssertionUsed is a common error used when an assertion has already been used.
+var ErrAssertionUsed = errors.New("assertion has already been used")
+
+// AssertionStore is used to record JWT assertions which have been used, so
+// that an intercepted assertion cannot be replayed before it expires.
+type AssertionStore interface {
+	// Use records the assertion as used. If the assertion has already been
+	// used, ErrAssertionUsed will be returned as the error.
+	Use(ctx context.Context, a *UsedAssertion) error
+}
@@ -0,0 +1,15 @@
+package dal
+
+import "context"
+
+// AttemptStore is used to count failed attempts at an action, such as entering
+// a user code, so that repeated attempts can be limited.
+type AttemptStore interface {
+	// Count returns the number of failed attempts recorded for key,
+	// which have not expired.
+	Count(ctx context.Context, key string) (int, error)
+
+	// Fail records a failed attempt for key. Attempts are counted together
+	// until expires, the unix timestamp at which the count is reset.
+	Fail(ctx context.Context, key string, expires int64) error
+}
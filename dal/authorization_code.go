@@ -0,0 +1,24 @@
+package dal
+
+// AuthorizationCode represents the structure of an authorization code in the database.
+type AuthorizationCode struct {
+	Code        string   `json:"code"`
+	ClientID    string   `json:"clientId"`
+	UserID      string   `json:"userId"`
+	RedirectUri string   `json:"redirectUri"`
+	Scopes      []string `json:"scopes"`
+	Nonce       string   `json:"nonce"`
+
+	// AuthTime is the unix timestamp at which the user authenticated.
+	AuthTime int64 `json:"authTime,omitempty"`
+
+	// SessionID is the id of the user's single sign-on session, which
+	// is given to the client as the "sid" claim of the ID token.
+	SessionID string `json:"sessionId,omitempty"`
+
+	CodeChallenge       string `json:"codeChallenge,omitempty"`
+	CodeChallengeMethod string `json:"codeChallengeMethod,omitempty"`
+
+	// Expires is the unix timestamp at which the code expires.
+	Expires int64 `json:"expires"`
+}
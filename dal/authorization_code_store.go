@@ -0,0 +1,21 @@
+package dal
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrAuthorizationCodeNotFound is a common error used when an authorization
+// code cannot be found, or has already been redeemed.
+var ErrAuthorizationCodeNotFound = errors.New("authorization code not found")
+
+// AuthorizationCodeStore is used to persist and redeem authorization codes.
+type AuthorizationCodeStore interface {
+	// Create inserts an authorization code into the data store.
+	Create(ctx context.Context, c *AuthorizationCode) error
+
+	// Redeem retrieves and removes the authorization code with the given code,
+	// ensuring a code can only be used once. If the code cannot be found,
+	// ErrAuthorizationCodeNotFound will be returned as the error.
+	Redeem(ctx context.Context, code string) (*AuthorizationCode, error)
+}
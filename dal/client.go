@@ -1,5 +1,7 @@
 package dal
 
+import "github.com/reecerussell/goidc/jwk"
+
 // Client represents the structure of a client in the database.
 type Client struct {
 	ID           string   `json:"clientId"`
@@ -8,4 +10,50 @@ type Client struct {
 	GrantTypes   []string `json:"grantTypes"`
 	Scopes       []string `json:"scopes"`
 	Secrets      []string `json:"secrets"`
+
+	// ResponseTypes are the response types the client may use with the
+	// authorization endpoint. If empty, the client may only use "code".
+	ResponseTypes []string `json:"responseTypes,omitempty"`
+
+	// RequirePkce determines whether the client must use PKCE when using
+	// the authorization code flow. Public clients, which cannot authenticate,
+	// are always required to use PKCE.
+	RequirePkce bool `json:"requirePkce"`
+
+	// TokenEndpointAuthMethod is the method the client must use to authenticate
+	// with the token endpoint, e.g. client_secret_basic. If empty, the client
+	// may use any method it has credentials for.
+	TokenEndpointAuthMethod string `json:"tokenEndpointAuthMethod,omitempty"`
+
+	// JWKS and JWKSUri are used to verify client assertions signed by clients
+	// using private_key_jwt. If both are set, the inline JWKS takes precedence.
+	JWKS    *jwk.Set `json:"jwks,omitempty"`
+	JWKSUri string   `json:"jwksUri,omitempty"`
+
+	// SharedKey is the key used to verify client assertions signed by clients
+	// using client_secret_jwt. Unlike secrets, this cannot be stored as a hash.
+	SharedKey string `json:"sharedKey,omitempty"`
+
+	// ThirdParty determines whether the client is operated by a third party,
+	// in which case users must consent to the scopes it is authorized with.
+	ThirdParty bool `json:"thirdParty,omitempty"`
+
+	// ExchangeAudiences are the audiences the client may request tokens for,
+	// when exchanging a user's access token using the token exchange grant.
+	ExchangeAudiences []string `json:"exchangeAudiences,omitempty"`
+
+	// PostLogoutRedirectUris are the uris the client may ask for the user
+	// to be redirected to, once they have been logged out.
+	PostLogoutRedirectUris []string `json:"postLogoutRedirectUris,omitempty"`
+
+	// BackchannelLogoutUri is where a logout token is posted when a user logs
+	// out, as defined by OpenID Connect Back-Channel Logout. Logout tokens
+	// always have a "sid" claim, so clients may always require it.
+	BackchannelLogoutUri string `json:"backchannelLogoutUri,omitempty"`
+
+	// FrontchannelLogoutUri is rendered in an iframe when a user logs out, as
+	// defined by OpenID Connect Front-Channel Logout. If
+	// FrontchannelLogoutSessionRequired is set, the iss and sid are given.
+	FrontchannelLogoutUri             string `json:"frontchannelLogoutUri,omitempty"`
+	FrontchannelLogoutSessionRequired bool   `json:"frontchannelLogoutSessionRequired,omitempty"`
 }
@@ -0,0 +1,12 @@
+package dal
+
+// Consent represents the structure of a user's consent in the database,
+// recording the scopes the user has allowed a client to be authorized with.
+type Consent struct {
+	UserID   string   `json:"userId"`
+	ClientID string   `json:"clientId"`
+	Scopes   []string `json:"scopes"`
+
+	// Granted is the unix timestamp at which the user last consented.
+	Granted int64 `json:"granted"`
+}
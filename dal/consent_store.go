@@ -0,0 +1,26 @@
+package dal
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrConsentNotFound is a common error used when a consent cannot be found.
+var ErrConsentNotFound = errors.New("consent not found")
+
+// ConsentStore is used to persist and retrieve users' consents.
+type ConsentStore interface {
+	// Save inserts or replaces the consent for c's user and client.
+	Save(ctx context.Context, c *Consent) error
+
+	// Get retrieves the consent userID has given to clientID. If the user has
+	// not consented, ErrConsentNotFound will be returned as the error.
+	Get(ctx context.Context, userID, clientID string) (*Consent, error)
+
+	// List retrieves every consent userID has given.
+	List(ctx context.Context, userID string) ([]*Consent, error)
+
+	// Delete removes the consent userID has given to clientID. Deleting
+	// a consent which doesn't exist is not an error.
+	Delete(ctx context.Context, userID, clientID string) error
+}
@@ -0,0 +1,24 @@
+package dal
+
+// DeviceCode represents the structure of a device authorization request in the
+// database, as defined by RFC 8628. The device polls the token endpoint with
+// the device code, while the user approves the request using the user code.
+type DeviceCode struct {
+	DeviceCode string   `json:"deviceCode"`
+	UserCode   string   `json:"userCode"`
+	ClientID   string   `json:"clientId"`
+	Scopes     []string `json:"scopes"`
+
+	// UserID is the id of the user who approved the request, which
+	// is empty until the user has entered the user code and logged in.
+	UserID string `json:"userId,omitempty"`
+
+	// Interval is the minimum number of seconds the device must wait
+	// between polling requests, and LastPolledAt the unix timestamp
+	// of its last request.
+	Interval     int64 `json:"interval"`
+	LastPolledAt int64 `json:"lastPolledAt,omitempty"`
+
+	// Expires is the unix timestamp at which the codes expire.
+	Expires int64 `json:"expires"`
+}
@@ -0,0 +1,38 @@
+package dal
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrDeviceCodeNotFound is a common error used when a device code cannot
+// be found, or is no longer in a state which allows the operation.
+var ErrDeviceCodeNotFound = errors.New("device code not found")
+
+// DeviceCodeStore is used to persist, approve and redeem device codes.
+type DeviceCodeStore interface {
+	// Create inserts a device code into the data store.
+	Create(ctx context.Context, c *DeviceCode) error
+
+	// Get retrieves the device code with the given code. If the code cannot
+	// be found, ErrDeviceCodeNotFound will be returned as the error.
+	Get(ctx context.Context, deviceCode string) (*DeviceCode, error)
+
+	// GetByUserCode retrieves the device code with the given user code. If the
+	// code cannot be found, ErrDeviceCodeNotFound will be returned as the error.
+	GetByUserCode(ctx context.Context, userCode string) (*DeviceCode, error)
+
+	// Authorize records that the user with the given id has approved the
+	// device code. If the code cannot be found, or has already been approved,
+	// ErrDeviceCodeNotFound will be returned as the error.
+	Authorize(ctx context.Context, deviceCode, userID string) error
+
+	// UpdatePolling records the time the device last polled the token
+	// endpoint, along with the interval it must now wait between requests.
+	UpdatePolling(ctx context.Context, deviceCode string, polledAt, interval int64) error
+
+	// Redeem retrieves and removes the device code with the given code,
+	// ensuring a code can only be exchanged for tokens once. If the code cannot
+	// be found, ErrDeviceCodeNotFound will be returned as the error.
+	Redeem(ctx context.Context, deviceCode string) (*DeviceCode, error)
+}
@@ -0,0 +1,55 @@
+package dynamo
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+
+	"github.com/reecerussell/goidc/dal"
+)
+
+// AssertionStore is an implementation of dal.AssertionStore for DynamoDB.
+// Records are removed by the table's TTL, once the assertion has expired.
+type AssertionStore struct {
+	svc *dynamodb.DynamoDB
+}
+
+// NewAssertionStore returns a new instance of AssertionStore,
+// for the given session, sess.
+func NewAssertionStore(sess *session.Session) dal.AssertionStore {
+	return &AssertionStore{
+		svc: dynamodb.New(sess),
+	}
+}
+
+// Use conditionally inserts a into the used assertions table, failing with
+// dal.ErrAssertionUsed if the assertion is already in the table.
+func (s *AssertionStore) Use(ctx context.Context, a *dal.UsedAssertion) error {
+	item, _ := dynamodbattribute.MarshalMap(a)
+	cond := expression.AttributeNotExists(expression.Name("id"))
+	expr, err := expression.NewBuilder().WithCondition(cond).Build()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.svc.PutItem(&dynamodb.PutItemInput{
+		TableName:                aws.String(UsedAssertionsTableName(ctx)),
+		Item:                     item,
+		ConditionExpression:      expr.Condition(),
+		ExpressionAttributeNames: expr.Names(),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return dal.ErrAssertionUsed
+		}
+
+		return err
+	}
+
+	return nil
+}
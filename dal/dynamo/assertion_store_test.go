@@ -0,0 +1,62 @@
+package dynamo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc"
+	"github.com/reecerussell/goidc/dal"
+)
+
+func buildUsedAssertionsContext() context.Context {
+	req := events.APIGatewayProxyRequest{
+		StageVariables: map[string]string{
+			"USED_ASSERTIONS_TABLE_NAME": "goidc-used-assertions-test",
+		},
+	}
+
+	return goidc.NewContext(context.Background(), &req)
+}
+
+func TestAssertionStore(t *testing.T) {
+	ctx := buildUsedAssertionsContext()
+	sess := session.Must(session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	}))
+	db := dynamodb.New(sess)
+
+	testAssertion := &dal.UsedAssertion{
+		ID:      "9238ulfdsfre/2o3i4u2o3i4u",
+		Expires: 1622505600,
+	}
+
+	t.Cleanup(func() {
+		_, err := db.DeleteItem(&dynamodb.DeleteItemInput{
+			TableName: aws.String(UsedAssertionsTableName(ctx)),
+			Key: map[string]*dynamodb.AttributeValue{
+				"id": {
+					S: aws.String(testAssertion.ID),
+				},
+			},
+		})
+		if err != nil {
+			panic(err)
+		}
+	})
+
+	s := NewAssertionStore(sess)
+
+	t.Run("Assertion Should Only Be Used Once", func(t *testing.T) {
+		err := s.Use(ctx, testAssertion)
+		assert.NoError(t, err)
+
+		err = s.Use(ctx, testAssertion)
+		assert.Equal(t, dal.ErrAssertionUsed, err)
+	})
+}
@@ -0,0 +1,113 @@
+package dynamo
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+
+	"github.com/reecerussell/goidc/dal"
+	"github.com/reecerussell/goidc/util"
+)
+
+// AttemptStore is an implementation of dal.AttemptStore for DynamoDB.
+// Records are removed by the table's TTL, once the count has been reset.
+type AttemptStore struct {
+	svc *dynamodb.DynamoDB
+}
+
+// NewAttemptStore returns a new instance of AttemptStore,
+// for the given session, sess.
+func NewAttemptStore(sess *session.Session) dal.AttemptStore {
+	return &AttemptStore{
+		svc: dynamodb.New(sess),
+	}
+}
+
+// Count gets the failed attempts for key from the failed attempts table. The
+// TTL doesn't remove records as soon as they expire, so expired records
+// are treated as having no attempts.
+func (s *AttemptStore) Count(ctx context.Context, key string) (int, error) {
+	res, err := s.svc.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(FailedAttemptsTableName(ctx)),
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {
+				S: aws.String(key),
+			},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if res.Item == nil {
+		return 0, nil
+	}
+
+	var a dal.FailedAttempts
+	_ = dynamodbattribute.UnmarshalMap(res.Item, &a)
+
+	if a.Expires <= util.Time().Unix() {
+		return 0, nil
+	}
+
+	return a.Attempts, nil
+}
+
+// Fail atomically increments the failed attempts for key, keeping the expiry of
+// the existing record. If the existing record has expired, it is replaced.
+func (s *AttemptStore) Fail(ctx context.Context, key string, expires int64) error {
+	update := expression.Add(expression.Name("attempts"), expression.Value(1)).
+		Set(expression.Name("expires"), expression.IfNotExists(expression.Name("expires"), expression.Value(expires)))
+	cond := expression.AttributeNotExists(expression.Name("id")).
+		Or(expression.Name("expires").GreaterThan(expression.Value(util.Time().Unix())))
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(cond).Build()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.svc.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName: aws.String(FailedAttemptsTableName(ctx)),
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {
+				S: aws.String(key),
+			},
+		},
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return s.reset(ctx, key, expires)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// reset replaces the expired record for key with a single failed attempt.
+func (s *AttemptStore) reset(ctx context.Context, key string, expires int64) error {
+	item, _ := dynamodbattribute.MarshalMap(&dal.FailedAttempts{
+		ID:       key,
+		Attempts: 1,
+		Expires:  expires,
+	})
+
+	_, err := s.svc.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(FailedAttemptsTableName(ctx)),
+		Item:      item,
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
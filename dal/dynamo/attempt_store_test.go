@@ -0,0 +1,98 @@
+package dynamo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc"
+	"github.com/reecerussell/goidc/util"
+)
+
+func buildFailedAttemptsContext() context.Context {
+	req := events.APIGatewayProxyRequest{
+		StageVariables: map[string]string{
+			"FAILED_ATTEMPTS_TABLE_NAME": "goidc-failed-attempts-test",
+		},
+	}
+
+	return goidc.NewContext(context.Background(), &req)
+}
+
+func TestAttemptStore(t *testing.T) {
+	ctx := buildFailedAttemptsContext()
+	sess := session.Must(session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	}))
+	db := dynamodb.New(sess)
+
+	testKey := "user-code/23847"
+
+	t.Cleanup(func() {
+		_, err := db.DeleteItem(&dynamodb.DeleteItemInput{
+			TableName: aws.String(FailedAttemptsTableName(ctx)),
+			Key: map[string]*dynamodb.AttributeValue{
+				"id": {
+					S: aws.String(testKey),
+				},
+			},
+		})
+		if err != nil {
+			panic(err)
+		}
+	})
+
+	s := NewAttemptStore(sess)
+
+	t.Run("Should Have No Attempts", func(t *testing.T) {
+		attempts, err := s.Count(ctx, testKey)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, attempts)
+	})
+
+	t.Run("Should Count Attempts", func(t *testing.T) {
+		expires := util.Time().Unix() + 60
+
+		assert.NoError(t, s.Fail(ctx, testKey, expires))
+		assert.NoError(t, s.Fail(ctx, testKey, expires+30))
+
+		attempts, err := s.Count(ctx, testKey)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("Should Reset Expired Attempts", func(t *testing.T) {
+		// Expire the existing record, which the TTL hasn't removed yet.
+		_, err := db.UpdateItem(&dynamodb.UpdateItemInput{
+			TableName: aws.String(FailedAttemptsTableName(ctx)),
+			Key: map[string]*dynamodb.AttributeValue{
+				"id": {
+					S: aws.String(testKey),
+				},
+			},
+			UpdateExpression: aws.String("SET expires = :expires"),
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":expires": {
+					N: aws.String("1"),
+				},
+			},
+		})
+		assert.NoError(t, err)
+
+		attempts, err := s.Count(ctx, testKey)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, attempts)
+
+		err = s.Fail(ctx, testKey, util.Time().Unix()+60)
+		assert.NoError(t, err)
+
+		attempts, err = s.Count(ctx, testKey)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+}
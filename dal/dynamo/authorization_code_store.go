@@ -0,0 +1,70 @@
+package dynamo
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+
+	"github.com/reecerussell/goidc/dal"
+)
+
+// AuthorizationCodeStore is an implementation of dal.AuthorizationCodeStore for DynamoDB.
+type AuthorizationCodeStore struct {
+	svc *dynamodb.DynamoDB
+}
+
+// NewAuthorizationCodeStore returns a new instance of AuthorizationCodeStore,
+// for the given session, sess.
+func NewAuthorizationCodeStore(sess *session.Session) dal.AuthorizationCodeStore {
+	return &AuthorizationCodeStore{
+		svc: dynamodb.New(sess),
+	}
+}
+
+// Create inserts c into the authorization codes table.
+func (s *AuthorizationCodeStore) Create(ctx context.Context, c *dal.AuthorizationCode) error {
+	item, _ := dynamodbattribute.MarshalMap(c)
+
+	_, err := s.svc.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(AuthorizationCodesTableName(ctx)),
+		Item:      item,
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Redeem deletes the authorization code with the given code from the table,
+// returning the deleted item. As the get and delete are a single operation,
+// concurrent redemptions of the same code will only succeed once.
+func (s *AuthorizationCodeStore) Redeem(ctx context.Context, code string) (*dal.AuthorizationCode, error) {
+	res, err := s.svc.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName: aws.String(AuthorizationCodesTableName(ctx)),
+		Key: map[string]*dynamodb.AttributeValue{
+			"code": {
+				S: aws.String(code),
+			},
+		},
+		ReturnValues: aws.String(dynamodb.ReturnValueAllOld),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Attributes) < 1 {
+		return nil, dal.ErrAuthorizationCodeNotFound
+	}
+
+	var c dal.AuthorizationCode
+	err = dynamodbattribute.UnmarshalMap(res.Attributes, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
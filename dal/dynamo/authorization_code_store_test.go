@@ -0,0 +1,133 @@
+package dynamo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc"
+	"github.com/reecerussell/goidc/dal"
+)
+
+func buildAuthorizationCodesContext() context.Context {
+	req := events.APIGatewayProxyRequest{
+		StageVariables: map[string]string{
+			"AUTHORIZATION_CODES_TABLE_NAME": "goidc-authorization-codes-test",
+		},
+	}
+
+	return goidc.NewContext(context.Background(), &req)
+}
+
+func TestCreateAuthorizationCode(t *testing.T) {
+	ctx := buildAuthorizationCodesContext()
+	sess := session.Must(session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	}))
+	db := dynamodb.New(sess)
+
+	testCode := &dal.AuthorizationCode{
+		Code:        "3lk4j2l3kj4o2i3",
+		ClientID:    "9238ulfdsfre",
+		UserID:      "wlerhewrlw",
+		RedirectUri: "http://localhost:3000",
+		Scopes:      []string{"openid"},
+		Nonce:       "2304820340lskfle",
+		Expires:     1622505600,
+	}
+
+	t.Cleanup(func() {
+		_, err := db.DeleteItem(&dynamodb.DeleteItemInput{
+			TableName: aws.String(AuthorizationCodesTableName(ctx)),
+			Key: map[string]*dynamodb.AttributeValue{
+				"code": {
+					S: aws.String(testCode.Code),
+				},
+			},
+		})
+		if err != nil {
+			panic(err)
+		}
+	})
+
+	s := NewAuthorizationCodeStore(sess)
+	err := s.Create(ctx, testCode)
+	assert.NoError(t, err)
+
+	t.Run("Code Should Be Created", func(t *testing.T) {
+		res, err := db.GetItem(&dynamodb.GetItemInput{
+			TableName: aws.String(AuthorizationCodesTableName(ctx)),
+			Key: map[string]*dynamodb.AttributeValue{
+				"code": {
+					S: aws.String(testCode.Code),
+				},
+			},
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		var code dal.AuthorizationCode
+		err = dynamodbattribute.UnmarshalMap(res.Item, &code)
+		if err != nil {
+			panic(err)
+		}
+
+		assert.Equal(t, testCode, &code)
+	})
+}
+
+func TestRedeemAuthorizationCode(t *testing.T) {
+	ctx := buildAuthorizationCodesContext()
+	sess := session.Must(session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	}))
+	db := dynamodb.New(sess)
+
+	testCode := "o23i4uwoeirj"
+	testData := map[string]interface{}{
+		"code":        testCode,
+		"clientId":    "9238ulfdsfre",
+		"userId":      "wlerhewrlw",
+		"redirectUri": "http://localhost:3000",
+		"scopes":      []string{"openid"},
+		"expires":     1622505600,
+	}
+
+	av, err := dynamodbattribute.MarshalMap(testData)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = db.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(AuthorizationCodesTableName(ctx)),
+		Item:      av,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	s := NewAuthorizationCodeStore(sess)
+
+	t.Run("Code Should Be Returned", func(t *testing.T) {
+		code, err := s.Redeem(ctx, testCode)
+		assert.NoError(t, err)
+		assert.Equal(t, testCode, code.Code)
+		assert.Equal(t, testData["clientId"], code.ClientID)
+		assert.Equal(t, testData["userId"], code.UserID)
+		assert.Equal(t, testData["redirectUri"], code.RedirectUri)
+		assert.Equal(t, testData["scopes"], code.Scopes)
+	})
+
+	t.Run("Code Should Only Be Redeemed Once", func(t *testing.T) {
+		code, err := s.Redeem(ctx, testCode)
+		assert.Nil(t, code)
+		assert.Equal(t, dal.ErrAuthorizationCodeNotFound, err)
+	})
+}
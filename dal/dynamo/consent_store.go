@@ -0,0 +1,128 @@
+package dynamo
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+
+	"github.com/reecerussell/goidc/dal"
+)
+
+// ConsentStore is an implementation of dal.ConsentStore for DynamoDB.
+type ConsentStore struct {
+	svc *dynamodb.DynamoDB
+}
+
+// NewConsentStore returns a new instance of ConsentStore,
+// for the given session, sess.
+func NewConsentStore(sess *session.Session) dal.ConsentStore {
+	return &ConsentStore{
+		svc: dynamodb.New(sess),
+	}
+}
+
+// Save puts c into the consents table, replacing any existing
+// consent for the same user and client.
+func (s *ConsentStore) Save(ctx context.Context, c *dal.Consent) error {
+	item, _ := dynamodbattribute.MarshalMap(c)
+
+	_, err := s.svc.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(ConsentsTableName(ctx)),
+		Item:      item,
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get retrieves the consent userID has given to clientID from the consents table.
+func (s *ConsentStore) Get(ctx context.Context, userID, clientID string) (*dal.Consent, error) {
+	res, err := s.svc.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(ConsentsTableName(ctx)),
+		Key: map[string]*dynamodb.AttributeValue{
+			"userId": {
+				S: aws.String(userID),
+			},
+			"clientId": {
+				S: aws.String(clientID),
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Item == nil {
+		return nil, dal.ErrConsentNotFound
+	}
+
+	var c dal.Consent
+	err = dynamodbattribute.UnmarshalMap(res.Item, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// List queries the consents table for every consent userID has given.
+func (s *ConsentStore) List(ctx context.Context, userID string) ([]*dal.Consent, error) {
+	keyCond := expression.Key("userId").Equal(expression.Value(userID))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	consents := []*dal.Consent{}
+	var unmarshalErr error
+	err = s.svc.QueryPages(&dynamodb.QueryInput{
+		TableName:                 aws.String(ConsentsTableName(ctx)),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	}, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		var c []*dal.Consent
+		unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &c)
+		if unmarshalErr != nil {
+			return false
+		}
+
+		consents = append(consents, c...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+
+	return consents, nil
+}
+
+// Delete removes the consent userID has given to clientID from the consents table.
+func (s *ConsentStore) Delete(ctx context.Context, userID, clientID string) error {
+	_, err := s.svc.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName: aws.String(ConsentsTableName(ctx)),
+		Key: map[string]*dynamodb.AttributeValue{
+			"userId": {
+				S: aws.String(userID),
+			},
+			"clientId": {
+				S: aws.String(clientID),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package dynamo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc"
+	"github.com/reecerussell/goidc/dal"
+)
+
+func buildConsentsContext() context.Context {
+	req := events.APIGatewayProxyRequest{
+		StageVariables: map[string]string{
+			"CONSENTS_TABLE_NAME": "goidc-consents-test",
+		},
+	}
+
+	return goidc.NewContext(context.Background(), &req)
+}
+
+func TestConsentStore(t *testing.T) {
+	ctx := buildConsentsContext()
+	sess := session.Must(session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	}))
+	db := dynamodb.New(sess)
+
+	testConsent := &dal.Consent{
+		UserID:   "9238ulfdsfre",
+		ClientID: "23493234",
+		Scopes:   []string{"openid", "profile"},
+		Granted:  1622505000,
+	}
+
+	t.Cleanup(func() {
+		_, err := db.DeleteItem(&dynamodb.DeleteItemInput{
+			TableName: aws.String(ConsentsTableName(ctx)),
+			Key: map[string]*dynamodb.AttributeValue{
+				"userId": {
+					S: aws.String(testConsent.UserID),
+				},
+				"clientId": {
+					S: aws.String(testConsent.ClientID),
+				},
+			},
+		})
+		if err != nil {
+			panic(err)
+		}
+	})
+
+	s := NewConsentStore(sess)
+	err := s.Save(ctx, testConsent)
+	assert.NoError(t, err)
+
+	t.Run("Consent Should Be Returned", func(t *testing.T) {
+		c, err := s.Get(ctx, testConsent.UserID, testConsent.ClientID)
+		assert.NoError(t, err)
+		assert.Equal(t, testConsent, c)
+	})
+
+	t.Run("Consent For Unknown Client Should Not Be Found", func(t *testing.T) {
+		c, err := s.Get(ctx, testConsent.UserID, "unknown")
+		assert.Nil(t, c)
+		assert.Equal(t, dal.ErrConsentNotFound, err)
+	})
+
+	t.Run("Saved Consent Should Replace Existing", func(t *testing.T) {
+		updated := *testConsent
+		updated.Scopes = []string{"openid", "profile", "email"}
+		updated.Granted = 1622591400
+
+		err := s.Save(ctx, &updated)
+		assert.NoError(t, err)
+
+		c, err := s.Get(ctx, testConsent.UserID, testConsent.ClientID)
+		assert.NoError(t, err)
+		assert.Equal(t, &updated, c)
+	})
+
+	t.Run("Consents Should Be Listed", func(t *testing.T) {
+		consents, err := s.List(ctx, testConsent.UserID)
+		assert.NoError(t, err)
+		assert.Len(t, consents, 1)
+		assert.Equal(t, testConsent.ClientID, consents[0].ClientID)
+	})
+
+	t.Run("User Without Consents Should Have None Listed", func(t *testing.T) {
+		consents, err := s.List(ctx, "unknown")
+		assert.NoError(t, err)
+		assert.Empty(t, consents)
+	})
+
+	t.Run("Deleted Consent Should Not Be Found", func(t *testing.T) {
+		err := s.Delete(ctx, testConsent.UserID, testConsent.ClientID)
+		assert.NoError(t, err)
+
+		c, err := s.Get(ctx, testConsent.UserID, testConsent.ClientID)
+		assert.Nil(t, c)
+		assert.Equal(t, dal.ErrConsentNotFound, err)
+	})
+}
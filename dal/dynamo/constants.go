@@ -13,3 +13,39 @@ func ClientsTableName(ctx context.Context) string {
 func UsersTableName(ctx context.Context) string {
 	return goidc.StageVariable(ctx, "USERS_TABLE_NAME")
 }
+
+func AuthorizationCodesTableName(ctx context.Context) string {
+	return goidc.StageVariable(ctx, "AUTHORIZATION_CODES_TABLE_NAME")
+}
+
+func RefreshTokensTableName(ctx context.Context) string {
+	return goidc.StageVariable(ctx, "REFRESH_TOKENS_TABLE_NAME")
+}
+
+func RevokedTokensTableName(ctx context.Context) string {
+	return goidc.StageVariable(ctx, "REVOKED_TOKENS_TABLE_NAME")
+}
+
+func DeviceCodesTableName(ctx context.Context) string {
+	return goidc.StageVariable(ctx, "DEVICE_CODES_TABLE_NAME")
+}
+
+func UsedAssertionsTableName(ctx context.Context) string {
+	return goidc.StageVariable(ctx, "USED_ASSERTIONS_TABLE_NAME")
+}
+
+func TrustedIssuersTableName(ctx context.Context) string {
+	return goidc.StageVariable(ctx, "TRUSTED_ISSUERS_TABLE_NAME")
+}
+
+func SessionsTableName(ctx context.Context) string {
+	return goidc.StageVariable(ctx, "SESSIONS_TABLE_NAME")
+}
+
+func ConsentsTableName(ctx context.Context) string {
+	return goidc.StageVariable(ctx, "CONSENTS_TABLE_NAME")
+}
+
+func FailedAttemptsTableName(ctx context.Context) string {
+	return goidc.StageVariable(ctx, "FAILED_ATTEMPTS_TABLE_NAME")
+}
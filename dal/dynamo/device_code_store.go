@@ -0,0 +1,203 @@
+package dynamo
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+
+	"github.com/reecerussell/goidc/dal"
+)
+
+// deviceCodeUserCodeIndex is the name of the global secondary
+// index on the device codes table, keyed by user code.
+const deviceCodeUserCodeIndex = "userCode-index"
+
+// DeviceCodeStore is an implementation of dal.DeviceCodeStore for DynamoDB.
+type DeviceCodeStore struct {
+	svc *dynamodb.DynamoDB
+}
+
+// NewDeviceCodeStore returns a new instance of DeviceCodeStore,
+// for the given session, sess.
+func NewDeviceCodeStore(sess *session.Session) dal.DeviceCodeStore {
+	return &DeviceCodeStore{
+		svc: dynamodb.New(sess),
+	}
+}
+
+// Create inserts c into the device codes table.
+func (s *DeviceCodeStore) Create(ctx context.Context, c *dal.DeviceCode) error {
+	item, _ := dynamodbattribute.MarshalMap(c)
+
+	_, err := s.svc.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(DeviceCodesTableName(ctx)),
+		Item:      item,
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get queries the device codes table for a code with the given device code.
+func (s *DeviceCodeStore) Get(ctx context.Context, deviceCode string) (*dal.DeviceCode, error) {
+	res, err := s.svc.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(DeviceCodesTableName(ctx)),
+		Key: map[string]*dynamodb.AttributeValue{
+			"deviceCode": {
+				S: aws.String(deviceCode),
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Item == nil {
+		return nil, dal.ErrDeviceCodeNotFound
+	}
+
+	var c dal.DeviceCode
+	err = dynamodbattribute.UnmarshalMap(res.Item, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// GetByUserCode queries the user code index for a code with the given user code.
+func (s *DeviceCodeStore) GetByUserCode(ctx context.Context, userCode string) (*dal.DeviceCode, error) {
+	keyCond := expression.Key("userCode").Equal(expression.Value(userCode))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.svc.Query(&dynamodb.QueryInput{
+		TableName:                 aws.String(DeviceCodesTableName(ctx)),
+		IndexName:                 aws.String(deviceCodeUserCodeIndex),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Items) < 1 {
+		return nil, dal.ErrDeviceCodeNotFound
+	}
+
+	var c dal.DeviceCode
+	err = dynamodbattribute.UnmarshalMap(res.Items[0], &c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// Authorize conditionally sets the user id of the given device code, failing
+// with dal.ErrDeviceCodeNotFound if the code does not exist, or has already
+// been approved by a user.
+func (s *DeviceCodeStore) Authorize(ctx context.Context, deviceCode, userID string) error {
+	update := expression.Set(expression.Name("userId"), expression.Value(userID))
+	cond := expression.AttributeExists(expression.Name("deviceCode")).
+		And(expression.AttributeNotExists(expression.Name("userId")))
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(cond).Build()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.svc.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName: aws.String(DeviceCodesTableName(ctx)),
+		Key: map[string]*dynamodb.AttributeValue{
+			"deviceCode": {
+				S: aws.String(deviceCode),
+			},
+		},
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return dal.ErrDeviceCodeNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// UpdatePolling sets the last polled timestamp and interval of the given device code.
+func (s *DeviceCodeStore) UpdatePolling(ctx context.Context, deviceCode string, polledAt, interval int64) error {
+	update := expression.Set(expression.Name("lastPolledAt"), expression.Value(polledAt)).
+		Set(expression.Name("interval"), expression.Value(interval))
+	cond := expression.AttributeExists(expression.Name("deviceCode"))
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(cond).Build()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.svc.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName: aws.String(DeviceCodesTableName(ctx)),
+		Key: map[string]*dynamodb.AttributeValue{
+			"deviceCode": {
+				S: aws.String(deviceCode),
+			},
+		},
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return dal.ErrDeviceCodeNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Redeem deletes the device code with the given code from the table, returning
+// the deleted item. As the get and delete are a single operation, concurrent
+// redemptions of the same code will only succeed once.
+func (s *DeviceCodeStore) Redeem(ctx context.Context, deviceCode string) (*dal.DeviceCode, error) {
+	res, err := s.svc.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName: aws.String(DeviceCodesTableName(ctx)),
+		Key: map[string]*dynamodb.AttributeValue{
+			"deviceCode": {
+				S: aws.String(deviceCode),
+			},
+		},
+		ReturnValues: aws.String(dynamodb.ReturnValueAllOld),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Attributes) < 1 {
+		return nil, dal.ErrDeviceCodeNotFound
+	}
+
+	var c dal.DeviceCode
+	err = dynamodbattribute.UnmarshalMap(res.Attributes, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
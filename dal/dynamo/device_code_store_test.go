@@ -0,0 +1,106 @@
+package dynamo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc"
+	"github.com/reecerussell/goidc/dal"
+)
+
+func buildDeviceCodesContext() context.Context {
+	req := events.APIGatewayProxyRequest{
+		StageVariables: map[string]string{
+			"DEVICE_CODES_TABLE_NAME": "goidc-device-codes-test",
+		},
+	}
+
+	return goidc.NewContext(context.Background(), &req)
+}
+
+func TestDeviceCodeStore(t *testing.T) {
+	ctx := buildDeviceCodesContext()
+	sess := session.Must(session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	}))
+	db := dynamodb.New(sess)
+
+	testCode := &dal.DeviceCode{
+		DeviceCode: "3lk4j2l3kj4o2i3",
+		UserCode:   "BCDF-GHJK",
+		ClientID:   "9238ulfdsfre",
+		Scopes:     []string{"openid"},
+		Interval:   5,
+		Expires:    1622505600,
+	}
+
+	t.Cleanup(func() {
+		_, err := db.DeleteItem(&dynamodb.DeleteItemInput{
+			TableName: aws.String(DeviceCodesTableName(ctx)),
+			Key: map[string]*dynamodb.AttributeValue{
+				"deviceCode": {
+					S: aws.String(testCode.DeviceCode),
+				},
+			},
+		})
+		if err != nil {
+			panic(err)
+		}
+	})
+
+	s := NewDeviceCodeStore(sess)
+	err := s.Create(ctx, testCode)
+	assert.NoError(t, err)
+
+	t.Run("Code Should Be Returned", func(t *testing.T) {
+		c, err := s.Get(ctx, testCode.DeviceCode)
+		assert.NoError(t, err)
+		assert.Equal(t, testCode, c)
+	})
+
+	t.Run("Code Should Be Returned By User Code", func(t *testing.T) {
+		c, err := s.GetByUserCode(ctx, testCode.UserCode)
+		assert.NoError(t, err)
+		assert.Equal(t, testCode, c)
+	})
+
+	t.Run("Unknown Code Should Not Be Found", func(t *testing.T) {
+		c, err := s.GetByUserCode(ctx, "XXXX-XXXX")
+		assert.Nil(t, c)
+		assert.Equal(t, dal.ErrDeviceCodeNotFound, err)
+	})
+
+	t.Run("Polling Should Be Updated", func(t *testing.T) {
+		err := s.UpdatePolling(ctx, testCode.DeviceCode, 1622505000, 10)
+		assert.NoError(t, err)
+
+		c, err := s.Get(ctx, testCode.DeviceCode)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1622505000), c.LastPolledAt)
+		assert.Equal(t, int64(10), c.Interval)
+	})
+
+	t.Run("Code Should Only Be Authorized Once", func(t *testing.T) {
+		err := s.Authorize(ctx, testCode.DeviceCode, "wlerhewrlw")
+		assert.NoError(t, err)
+
+		err = s.Authorize(ctx, testCode.DeviceCode, "wlerhewrlw")
+		assert.Equal(t, dal.ErrDeviceCodeNotFound, err)
+	})
+
+	t.Run("Code Should Only Be Redeemed Once", func(t *testing.T) {
+		c, err := s.Redeem(ctx, testCode.DeviceCode)
+		assert.NoError(t, err)
+		assert.Equal(t, "wlerhewrlw", c.UserID)
+
+		c, err = s.Redeem(ctx, testCode.DeviceCode)
+		assert.Nil(t, c)
+		assert.Equal(t, dal.ErrDeviceCodeNotFound, err)
+	})
+}
@@ -0,0 +1,186 @@
+package dynamo
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+
+	"github.com/reecerussell/goidc/dal"
+)
+
+// refreshTokenFamilyIndex is the name of the global secondary
+// index on the refresh tokens table, keyed by family id.
+const refreshTokenFamilyIndex = "familyId-index"
+
+// refreshTokenUserIndex is the name of the global secondary index on
+// the refresh tokens table, keyed by user id and client id.
+const refreshTokenUserIndex = "userId-clientId-index"
+
+// RefreshTokenStore is an implementation of dal.RefreshTokenStore for DynamoDB.
+type RefreshTokenStore struct {
+	svc *dynamodb.DynamoDB
+}
+
+// NewRefreshTokenStore returns a new instance of RefreshTokenStore,
+// for the given session, sess.
+func NewRefreshTokenStore(sess *session.Session) dal.RefreshTokenStore {
+	return &RefreshTokenStore{
+		svc: dynamodb.New(sess),
+	}
+}
+
+// Create inserts t into the refresh tokens table.
+func (s *RefreshTokenStore) Create(ctx context.Context, t *dal.RefreshToken) error {
+	item, _ := dynamodbattribute.MarshalMap(t)
+
+	_, err := s.svc.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(RefreshTokensTableName(ctx)),
+		Item:      item,
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get queries the refresh tokens table for a token with the given id.
+func (s *RefreshTokenStore) Get(ctx context.Context, id string) (*dal.RefreshToken, error) {
+	res, err := s.svc.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(RefreshTokensTableName(ctx)),
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {
+				S: aws.String(id),
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Item == nil {
+		return nil, dal.ErrRefreshTokenNotFound
+	}
+
+	var t dal.RefreshToken
+	err = dynamodbattribute.UnmarshalMap(res.Item, &t)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// MarkUsed conditionally sets the used flag of the token with the given id,
+// failing with dal.ErrRefreshTokenUsed if the flag has already been set.
+func (s *RefreshTokenStore) MarkUsed(ctx context.Context, id string) error {
+	update := expression.Set(expression.Name("used"), expression.Value(true))
+	cond := expression.Name("used").Equal(expression.Value(false))
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(cond).Build()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.svc.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName: aws.String(RefreshTokensTableName(ctx)),
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {
+				S: aws.String(id),
+			},
+		},
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return dal.ErrRefreshTokenUsed
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// RevokeFamily queries the family id index for every token in the
+// family, then sets the revoked flag of each token.
+func (s *RefreshTokenStore) RevokeFamily(ctx context.Context, familyID string) error {
+	keyCond := expression.Key("familyId").Equal(expression.Value(familyID))
+
+	return s.revokeAll(ctx, refreshTokenFamilyIndex, keyCond)
+}
+
+// RevokeClient marks every refresh token issued to clientID for userID
+// as revoked, querying the tokens using the user id index.
+func (s *RefreshTokenStore) RevokeClient(ctx context.Context, userID, clientID string) error {
+	keyCond := expression.Key("userId").Equal(expression.Value(userID)).
+		And(expression.Key("clientId").Equal(expression.Value(clientID)))
+
+	return s.revokeAll(ctx, refreshTokenUserIndex, keyCond)
+}
+
+// revokeAll revokes every refresh token matching keyCond in the given index.
+func (s *RefreshTokenStore) revokeAll(ctx context.Context, index string, keyCond expression.KeyConditionBuilder) error {
+	projection := expression.NamesList(expression.Name("id"))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).WithProjection(projection).Build()
+	if err != nil {
+		return err
+	}
+
+	var ids []string
+	err = s.svc.QueryPages(&dynamodb.QueryInput{
+		TableName:                 aws.String(RefreshTokensTableName(ctx)),
+		IndexName:                 aws.String(index),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ProjectionExpression:      expr.Projection(),
+	}, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			ids = append(ids, aws.StringValue(item["id"].S))
+		}
+
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		err = s.revoke(ctx, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *RefreshTokenStore) revoke(ctx context.Context, id string) error {
+	update := expression.Set(expression.Name("revoked"), expression.Value(true))
+	expr, err := expression.NewBuilder().WithUpdate(update).Build()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.svc.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName: aws.String(RefreshTokensTableName(ctx)),
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {
+				S: aws.String(id),
+			},
+		},
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+	})
+
+	return err
+}
@@ -0,0 +1,127 @@
+package dynamo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc"
+	"github.com/reecerussell/goidc/dal"
+)
+
+func buildRefreshTokensContext() context.Context {
+	req := events.APIGatewayProxyRequest{
+		StageVariables: map[string]string{
+			"REFRESH_TOKENS_TABLE_NAME": "goidc-refresh-tokens-test",
+		},
+	}
+
+	return goidc.NewContext(context.Background(), &req)
+}
+
+func TestRefreshTokenStore(t *testing.T) {
+	ctx := buildRefreshTokensContext()
+	sess := session.Must(session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	}))
+	db := dynamodb.New(sess)
+
+	testTokens := []*dal.RefreshToken{
+		{
+			ID:       "23lk4j2l3k4j",
+			FamilyID: "o2i3u4o2i3u4",
+			ClientID: "9238ulfdsfre",
+			UserID:   "wlerhewrlw",
+			Scopes:   []string{"openid"},
+			Expires:  1622505600,
+		},
+		{
+			ID:       "98ewhf9w8ehf",
+			FamilyID: "o2i3u4o2i3u4",
+			ClientID: "9238ulfdsfre",
+			UserID:   "wlerhewrlw",
+			Scopes:   []string{"openid"},
+			Expires:  1622505600,
+		},
+		{
+			ID:       "lk3j4lk2j3h4",
+			FamilyID: "x8c7v6x8c7v6",
+			ClientID: "p0o9i8u7y6t5",
+			UserID:   "wlerhewrlw",
+			Scopes:   []string{"openid"},
+			Expires:  1622505600,
+		},
+	}
+
+	t.Cleanup(func() {
+		for _, rt := range testTokens {
+			_, err := db.DeleteItem(&dynamodb.DeleteItemInput{
+				TableName: aws.String(RefreshTokensTableName(ctx)),
+				Key: map[string]*dynamodb.AttributeValue{
+					"id": {
+						S: aws.String(rt.ID),
+					},
+				},
+			})
+			if err != nil {
+				panic(err)
+			}
+		}
+	})
+
+	s := NewRefreshTokenStore(sess)
+	for _, rt := range testTokens {
+		err := s.Create(ctx, rt)
+		assert.NoError(t, err)
+	}
+
+	t.Run("Token Should Be Returned", func(t *testing.T) {
+		rt, err := s.Get(ctx, testTokens[0].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, testTokens[0], rt)
+	})
+
+	t.Run("Unknown Token Should Not Be Found", func(t *testing.T) {
+		rt, err := s.Get(ctx, "not a token")
+		assert.Nil(t, rt)
+		assert.Equal(t, dal.ErrRefreshTokenNotFound, err)
+	})
+
+	t.Run("Token Should Only Be Marked Used Once", func(t *testing.T) {
+		err := s.MarkUsed(ctx, testTokens[0].ID)
+		assert.NoError(t, err)
+
+		err = s.MarkUsed(ctx, testTokens[0].ID)
+		assert.Equal(t, dal.ErrRefreshTokenUsed, err)
+	})
+
+	t.Run("Client Should Be Revoked", func(t *testing.T) {
+		err := s.RevokeClient(ctx, testTokens[2].UserID, testTokens[2].ClientID)
+		assert.NoError(t, err)
+
+		revoked, err := s.Get(ctx, testTokens[2].ID)
+		assert.NoError(t, err)
+		assert.True(t, revoked.Revoked)
+
+		// Tokens issued to other clients are kept.
+		kept, err := s.Get(ctx, testTokens[0].ID)
+		assert.NoError(t, err)
+		assert.False(t, kept.Revoked)
+	})
+
+	t.Run("Family Should Be Revoked", func(t *testing.T) {
+		err := s.RevokeFamily(ctx, testTokens[0].FamilyID)
+		assert.NoError(t, err)
+
+		for _, rt := range testTokens[:2] {
+			revoked, err := s.Get(ctx, rt.ID)
+			assert.NoError(t, err)
+			assert.True(t, revoked.Revoked)
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package dynamo
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+
+	"github.com/reecerussell/goidc/dal"
+)
+
+// RevocationStore is an implementation of dal.RevocationStore for DynamoDB.
+// Records are removed by the table's TTL, once the token has expired.
+type RevocationStore struct {
+	svc *dynamodb.DynamoDB
+}
+
+// NewRevocationStore returns a new instance of RevocationStore,
+// for the given session, sess.
+func NewRevocationStore(sess *session.Session) dal.RevocationStore {
+	return &RevocationStore{
+		svc: dynamodb.New(sess),
+	}
+}
+
+// Revoke inserts t into the revoked tokens table.
+func (s *RevocationStore) Revoke(ctx context.Context, t *dal.RevokedToken) error {
+	item, _ := dynamodbattribute.MarshalMap(t)
+
+	_, err := s.svc.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(RevokedTokensTableName(ctx)),
+		Item:      item,
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// IsRevoked queries the revoked tokens table for a token with the given id.
+func (s *RevocationStore) IsRevoked(ctx context.Context, id string) (bool, error) {
+	res, err := s.svc.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(RevokedTokensTableName(ctx)),
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {
+				S: aws.String(id),
+			},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return res.Item != nil, nil
+}
@@ -0,0 +1,69 @@
+package dynamo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc"
+	"github.com/reecerussell/goidc/dal"
+)
+
+func buildRevokedTokensContext() context.Context {
+	req := events.APIGatewayProxyRequest{
+		StageVariables: map[string]string{
+			"REVOKED_TOKENS_TABLE_NAME": "goidc-revoked-tokens-test",
+		},
+	}
+
+	return goidc.NewContext(context.Background(), &req)
+}
+
+func TestRevocationStore(t *testing.T) {
+	ctx := buildRevokedTokensContext()
+	sess := session.Must(session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	}))
+	db := dynamodb.New(sess)
+
+	testToken := &dal.RevokedToken{
+		ID:      "2o3i4u2o3i4u",
+		Expires: 1622505600,
+	}
+
+	t.Cleanup(func() {
+		_, err := db.DeleteItem(&dynamodb.DeleteItemInput{
+			TableName: aws.String(RevokedTokensTableName(ctx)),
+			Key: map[string]*dynamodb.AttributeValue{
+				"id": {
+					S: aws.String(testToken.ID),
+				},
+			},
+		})
+		if err != nil {
+			panic(err)
+		}
+	})
+
+	s := NewRevocationStore(sess)
+
+	t.Run("Token Should Not Be Revoked", func(t *testing.T) {
+		revoked, err := s.IsRevoked(ctx, testToken.ID)
+		assert.NoError(t, err)
+		assert.False(t, revoked)
+	})
+
+	t.Run("Token Should Be Revoked", func(t *testing.T) {
+		err := s.Revoke(ctx, testToken)
+		assert.NoError(t, err)
+
+		revoked, err := s.IsRevoked(ctx, testToken.ID)
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+	})
+}
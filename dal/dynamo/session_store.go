@@ -0,0 +1,119 @@
+package dynamo
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+
+	"github.com/reecerussell/goidc/dal"
+)
+
+// SessionStore is an implementation of dal.SessionStore for DynamoDB.
+type SessionStore struct {
+	svc *dynamodb.DynamoDB
+}
+
+// NewSessionStore returns a new instance of SessionStore,
+// for the given session, sess.
+func NewSessionStore(sess *session.Session) dal.SessionStore {
+	return &SessionStore{
+		svc: dynamodb.New(sess),
+	}
+}
+
+// Create inserts sess into the sessions table.
+func (s *SessionStore) Create(ctx context.Context, sess *dal.Session) error {
+	item, _ := dynamodbattribute.MarshalMap(sess)
+
+	_, err := s.svc.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(SessionsTableName(ctx)),
+		Item:      item,
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get retrieves the session with the given id from the sessions table.
+func (s *SessionStore) Get(ctx context.Context, id string) (*dal.Session, error) {
+	res, err := s.svc.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(SessionsTableName(ctx)),
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {
+				S: aws.String(id),
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Item == nil {
+		return nil, dal.ErrSessionNotFound
+	}
+
+	var sess dal.Session
+	err = dynamodbattribute.UnmarshalMap(res.Item, &sess)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sess, nil
+}
+
+// AddClient appends clientID to the client ids of the given session.
+func (s *SessionStore) AddClient(ctx context.Context, id, clientID string) error {
+	_, err := s.svc.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName: aws.String(SessionsTableName(ctx)),
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {
+				S: aws.String(id),
+			},
+		},
+		ConditionExpression: aws.String("attribute_exists(id)"),
+		UpdateExpression:    aws.String("SET #clientIds = list_append(if_not_exists(#clientIds, :empty), :clientIds)"),
+		ExpressionAttributeNames: map[string]*string{
+			"#clientIds": aws.String("clientIds"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":empty": {
+				L: []*dynamodb.AttributeValue{},
+			},
+			":clientIds": {
+				L: []*dynamodb.AttributeValue{{S: aws.String(clientID)}},
+			},
+		},
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return dal.ErrSessionNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Delete removes the session with the given id from the sessions table.
+func (s *SessionStore) Delete(ctx context.Context, id string) error {
+	_, err := s.svc.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName: aws.String(SessionsTableName(ctx)),
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {
+				S: aws.String(id),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
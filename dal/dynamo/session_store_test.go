@@ -0,0 +1,93 @@
+package dynamo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc"
+	"github.com/reecerussell/goidc/dal"
+)
+
+func buildSessionsContext() context.Context {
+	req := events.APIGatewayProxyRequest{
+		StageVariables: map[string]string{
+			"SESSIONS_TABLE_NAME": "goidc-sessions-test",
+		},
+	}
+
+	return goidc.NewContext(context.Background(), &req)
+}
+
+func TestSessionStore(t *testing.T) {
+	ctx := buildSessionsContext()
+	sess := session.Must(session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	}))
+	db := dynamodb.New(sess)
+
+	testSession := &dal.Session{
+		ID:       "2l3k4j2l3k4j2l3k4",
+		UserID:   "9238ulfdsfre",
+		AuthTime: 1622505000,
+		Expires:  1622591400,
+	}
+
+	t.Cleanup(func() {
+		_, err := db.DeleteItem(&dynamodb.DeleteItemInput{
+			TableName: aws.String(SessionsTableName(ctx)),
+			Key: map[string]*dynamodb.AttributeValue{
+				"id": {
+					S: aws.String(testSession.ID),
+				},
+			},
+		})
+		if err != nil {
+			panic(err)
+		}
+	})
+
+	s := NewSessionStore(sess)
+	err := s.Create(ctx, testSession)
+	assert.NoError(t, err)
+
+	t.Run("Session Should Be Returned", func(t *testing.T) {
+		ss, err := s.Get(ctx, testSession.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, testSession, ss)
+	})
+
+	t.Run("Unknown Session Should Not Be Found", func(t *testing.T) {
+		ss, err := s.Get(ctx, "unknown")
+		assert.Nil(t, ss)
+		assert.Equal(t, dal.ErrSessionNotFound, err)
+	})
+
+	t.Run("Client Should Be Added", func(t *testing.T) {
+		err := s.AddClient(ctx, testSession.ID, "my client id")
+		assert.NoError(t, err)
+
+		ss, err := s.Get(ctx, testSession.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"my client id"}, ss.ClientIDs)
+	})
+
+	t.Run("Client Of Unknown Session Should Not Be Added", func(t *testing.T) {
+		err := s.AddClient(ctx, "unknown", "my client id")
+		assert.Equal(t, dal.ErrSessionNotFound, err)
+	})
+
+	t.Run("Deleted Session Should Not Be Found", func(t *testing.T) {
+		err := s.Delete(ctx, testSession.ID)
+		assert.NoError(t, err)
+
+		ss, err := s.Get(ctx, testSession.ID)
+		assert.Nil(t, ss)
+		assert.Equal(t, dal.ErrSessionNotFound, err)
+	})
+}
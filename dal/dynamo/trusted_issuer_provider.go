@@ -0,0 +1,52 @@
+package dynamo
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+
+	"github.com/reecerussell/goidc/dal"
+)
+
+// TrustedIssuerProvider is an implementation of dal.TrustedIssuerProvider for DynamoDB.
+type TrustedIssuerProvider struct {
+	svc *dynamodb.DynamoDB
+}
+
+// NewTrustedIssuerProvider returns a new instance of TrustedIssuerProvider,
+// for the given session, sess.
+func NewTrustedIssuerProvider(sess *session.Session) dal.TrustedIssuerProvider {
+	return &TrustedIssuerProvider{
+		svc: dynamodb.New(sess),
+	}
+}
+
+// Get queries the trusted issuers table in DynamoDB for the given issuer.
+func (p *TrustedIssuerProvider) Get(ctx context.Context, issuer string) (*dal.TrustedIssuer, error) {
+	res, err := p.svc.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(TrustedIssuersTableName(ctx)),
+		Key: map[string]*dynamodb.AttributeValue{
+			"issuer": {
+				S: aws.String(issuer),
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Item == nil {
+		return nil, dal.ErrTrustedIssuerNotFound
+	}
+
+	var ti dal.TrustedIssuer
+	err = dynamodbattribute.UnmarshalMap(res.Item, &ti)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ti, nil
+}
@@ -0,0 +1,83 @@
+package dynamo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc"
+	"github.com/reecerussell/goidc/dal"
+)
+
+func buildTrustedIssuersContext() context.Context {
+	req := events.APIGatewayProxyRequest{
+		StageVariables: map[string]string{
+			"TRUSTED_ISSUERS_TABLE_NAME": "goidc-trusted-issuers-test",
+		},
+	}
+
+	return goidc.NewContext(context.Background(), &req)
+}
+
+func TestGetTrustedIssuer(t *testing.T) {
+	ctx := buildTrustedIssuersContext()
+	sess := session.Must(session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	}))
+	db := dynamodb.New(sess)
+
+	testIssuer := &dal.TrustedIssuer{
+		Issuer:  "https://token.actions.example.com",
+		JWKSUri: "https://token.actions.example.com/.well-known/jwks",
+		Subjects: map[string]string{
+			"repo:example/app:ref:refs/heads/main": "9238ulfdsfre",
+		},
+	}
+
+	av, err := dynamodbattribute.MarshalMap(testIssuer)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = db.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(TrustedIssuersTableName(ctx)),
+		Item:      av,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	t.Cleanup(func() {
+		_, err := db.DeleteItem(&dynamodb.DeleteItemInput{
+			TableName: aws.String(TrustedIssuersTableName(ctx)),
+			Key: map[string]*dynamodb.AttributeValue{
+				"issuer": {
+					S: aws.String(testIssuer.Issuer),
+				},
+			},
+		})
+		if err != nil {
+			panic(err)
+		}
+	})
+
+	p := NewTrustedIssuerProvider(sess)
+
+	t.Run("Issuer Should Be Returned", func(t *testing.T) {
+		ti, err := p.Get(ctx, testIssuer.Issuer)
+		assert.NoError(t, err)
+		assert.Equal(t, testIssuer, ti)
+	})
+
+	t.Run("Unknown Issuer Should Not Be Found", func(t *testing.T) {
+		ti, err := p.Get(ctx, "https://untrusted.example.com")
+		assert.Nil(t, ti)
+		assert.Equal(t, dal.ErrTrustedIssuerNotFound, err)
+	})
+}
@@ -24,6 +24,33 @@ func NewUserProvider(sess *session.Session) dal.UserProvider {
 	}
 }
 
+// Get queries the users DynamoDB table for a user with the given id.
+func (p *UserProvider) Get(ctx context.Context, id string) (*dal.User, error) {
+	res, err := p.svc.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(UsersTableName(ctx)),
+		Key: map[string]*dynamodb.AttributeValue{
+			"userId": {
+				S: aws.String(id),
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Item == nil {
+		return nil, dal.ErrUserNotFound
+	}
+
+	var user dal.User
+	err = dynamodbattribute.UnmarshalMap(res.Item, &user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
 // GetByEmail queries the users DynamoDB table for a user with the given email.
 func (p *UserProvider) GetByEmail(ctx context.Context, email string) (*dal.User, error) {
 	filter := expression.Name("email").Equal(expression.Value(email))
@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/reecerussell/goidc"
+	"github.com/reecerussell/goidc/dal"
 )
 
 func buildUsersContext() context.Context {
@@ -74,3 +75,64 @@ func TestGetUserByEmail(t *testing.T) {
 		assert.Equal(t, testData["passwordHash"], user.PasswordHash)
 	})
 }
+
+func TestGetUser(t *testing.T) {
+	ctx := buildUsersContext()
+	sess := session.Must(session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	}))
+	db := dynamodb.New(sess)
+
+	testUserId := "2o3i4u2o3i"
+	testData := map[string]interface{}{
+		"userId":        testUserId,
+		"email":         "test@test.go",
+		"emailVerified": true,
+		"passwordHash":  "3wirwhc8o",
+		"name":          "Test User",
+	}
+
+	av, err := dynamodbattribute.MarshalMap(testData)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = db.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(UsersTableName(ctx)),
+		Item:      av,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	t.Cleanup(func() {
+		_, err := db.DeleteItem(&dynamodb.DeleteItemInput{
+			TableName: aws.String(UsersTableName(ctx)),
+			Key: map[string]*dynamodb.AttributeValue{
+				"userId": {
+					S: aws.String(testUserId),
+				},
+			},
+		})
+		if err != nil {
+			panic(err)
+		}
+	})
+
+	t.Run("User Should Be Returned", func(t *testing.T) {
+		p := NewUserProvider(sess)
+		user, err := p.Get(ctx, testUserId)
+		assert.NoError(t, err)
+		assert.Equal(t, testUserId, user.ID)
+		assert.Equal(t, testData["email"], user.Email)
+		assert.True(t, user.EmailVerified)
+		assert.Equal(t, testData["name"], user.Name)
+	})
+
+	t.Run("Missing User Should Return Error", func(t *testing.T) {
+		p := NewUserProvider(sess)
+		user, err := p.Get(ctx, "not-a-user")
+		assert.Nil(t, user)
+		assert.Equal(t, dal.ErrUserNotFound, err)
+	})
+}
@@ -0,0 +1,13 @@
+package dal
+
+// FailedAttempts represents the number of times an action, such as entering
+// a user code, has failed since the count was last reset.
+type FailedAttempts struct {
+	// ID identifies the action and who attempted it, e.g. a user's id.
+	ID       string `json:"id"`
+	Attempts int    `json:"attempts"`
+
+	// Expires is the unix timestamp at which the count is reset,
+	// after which the record can be removed.
+	Expires int64 `json:"expires"`
+}
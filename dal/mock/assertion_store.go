@@ -0,0 +1,49 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../assertion_store.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	gomock "github.com/golang/mock/gomock"
+	dal "github.com/reecerussell/goidc/dal"
+	reflect "reflect"
+)
+
+// MockAssertionStore is a mock of AssertionStore interface.
+type MockAssertionStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockAssertionStoreMockRecorder
+}
+
+// MockAssertionStoreMockRecorder is the mock recorder for MockAssertionStore.
+type MockAssertionStoreMockRecorder struct {
+	mock *MockAssertionStore
+}
+
+// NewMockAssertionStore creates a new mock instance.
+func NewMockAssertionStore(ctrl *gomock.Controller) *MockAssertionStore {
+	mock := &MockAssertionStore{ctrl: ctrl}
+	mock.recorder = &MockAssertionStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAssertionStore) EXPECT() *MockAssertionStoreMockRecorder {
+	return m.recorder
+}
+
+// Use mocks base method.
+func (m *MockAssertionStore) Use(ctx context.Context, a *dal.UsedAssertion) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Use", ctx, a)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Use indicates an expected call of Use.
+func (mr *MockAssertionStoreMockRecorder) Use(ctx, a interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Use", reflect.TypeOf((*MockAssertionStore)(nil).Use), ctx, a)
+}
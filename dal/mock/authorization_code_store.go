@@ -0,0 +1,64 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../authorization_code_store.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	gomock "github.com/golang/mock/gomock"
+	dal "github.com/reecerussell/goidc/dal"
+	reflect "reflect"
+)
+
+// MockAuthorizationCodeStore is a mock of AuthorizationCodeStore interface.
+type MockAuthorizationCodeStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthorizationCodeStoreMockRecorder
+}
+
+// MockAuthorizationCodeStoreMockRecorder is the mock recorder for MockAuthorizationCodeStore.
+type MockAuthorizationCodeStoreMockRecorder struct {
+	mock *MockAuthorizationCodeStore
+}
+
+// NewMockAuthorizationCodeStore creates a new mock instance.
+func NewMockAuthorizationCodeStore(ctrl *gomock.Controller) *MockAuthorizationCodeStore {
+	mock := &MockAuthorizationCodeStore{ctrl: ctrl}
+	mock.recorder = &MockAuthorizationCodeStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthorizationCodeStore) EXPECT() *MockAuthorizationCodeStoreMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockAuthorizationCodeStore) Create(ctx context.Context, c *dal.AuthorizationCode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAuthorizationCodeStoreMockRecorder) Create(ctx, c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAuthorizationCodeStore)(nil).Create), ctx, c)
+}
+
+// Redeem mocks base method.
+func (m *MockAuthorizationCodeStore) Redeem(ctx context.Context, code string) (*dal.AuthorizationCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Redeem", ctx, code)
+	ret0, _ := ret[0].(*dal.AuthorizationCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Redeem indicates an expected call of Redeem.
+func (mr *MockAuthorizationCodeStoreMockRecorder) Redeem(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Redeem", reflect.TypeOf((*MockAuthorizationCodeStore)(nil).Redeem), ctx, code)
+}
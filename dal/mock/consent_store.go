@@ -0,0 +1,93 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../consent_store.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	gomock "github.com/golang/mock/gomock"
+	dal "github.com/reecerussell/goidc/dal"
+	reflect "reflect"
+)
+
+// MockConsentStore is a mock of ConsentStore interface.
+type MockConsentStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockConsentStoreMockRecorder
+}
+
+// MockConsentStoreMockRecorder is the mock recorder for MockConsentStore.
+type MockConsentStoreMockRecorder struct {
+	mock *MockConsentStore
+}
+
+// NewMockConsentStore creates a new mock instance.
+func NewMockConsentStore(ctrl *gomock.Controller) *MockConsentStore {
+	mock := &MockConsentStore{ctrl: ctrl}
+	mock.recorder = &MockConsentStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockConsentStore) EXPECT() *MockConsentStoreMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockConsentStore) Delete(ctx context.Context, userID, clientID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userID, clientID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockConsentStoreMockRecorder) Delete(ctx, userID, clientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockConsentStore)(nil).Delete), ctx, userID, clientID)
+}
+
+// Get mocks base method.
+func (m *MockConsentStore) Get(ctx context.Context, userID, clientID string) (*dal.Consent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, userID, clientID)
+	ret0, _ := ret[0].(*dal.Consent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockConsentStoreMockRecorder) Get(ctx, userID, clientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockConsentStore)(nil).Get), ctx, userID, clientID)
+}
+
+// List mocks base method.
+func (m *MockConsentStore) List(ctx context.Context, userID string) ([]*dal.Consent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, userID)
+	ret0, _ := ret[0].([]*dal.Consent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockConsentStoreMockRecorder) List(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockConsentStore)(nil).List), ctx, userID)
+}
+
+// Save mocks base method.
+func (m *MockConsentStore) Save(ctx context.Context, c *dal.Consent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockConsentStoreMockRecorder) Save(ctx, c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockConsentStore)(nil).Save), ctx, c)
+}
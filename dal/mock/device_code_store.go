@@ -0,0 +1,122 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../device_code_store.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	gomock "github.com/golang/mock/gomock"
+	dal "github.com/reecerussell/goidc/dal"
+	reflect "reflect"
+)
+
+// MockDeviceCodeStore is a mock of DeviceCodeStore interface.
+type MockDeviceCodeStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeviceCodeStoreMockRecorder
+}
+
+// MockDeviceCodeStoreMockRecorder is the mock recorder for MockDeviceCodeStore.
+type MockDeviceCodeStoreMockRecorder struct {
+	mock *MockDeviceCodeStore
+}
+
+// NewMockDeviceCodeStore creates a new mock instance.
+func NewMockDeviceCodeStore(ctrl *gomock.Controller) *MockDeviceCodeStore {
+	mock := &MockDeviceCodeStore{ctrl: ctrl}
+	mock.recorder = &MockDeviceCodeStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeviceCodeStore) EXPECT() *MockDeviceCodeStoreMockRecorder {
+	return m.recorder
+}
+
+// Authorize mocks base method.
+func (m *MockDeviceCodeStore) Authorize(ctx context.Context, deviceCode, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authorize", ctx, deviceCode, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Authorize indicates an expected call of Authorize.
+func (mr *MockDeviceCodeStoreMockRecorder) Authorize(ctx, deviceCode, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authorize", reflect.TypeOf((*MockDeviceCodeStore)(nil).Authorize), ctx, deviceCode, userID)
+}
+
+// Create mocks base method.
+func (m *MockDeviceCodeStore) Create(ctx context.Context, c *dal.DeviceCode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockDeviceCodeStoreMockRecorder) Create(ctx, c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockDeviceCodeStore)(nil).Create), ctx, c)
+}
+
+// Get mocks base method.
+func (m *MockDeviceCodeStore) Get(ctx context.Context, deviceCode string) (*dal.DeviceCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, deviceCode)
+	ret0, _ := ret[0].(*dal.DeviceCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockDeviceCodeStoreMockRecorder) Get(ctx, deviceCode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockDeviceCodeStore)(nil).Get), ctx, deviceCode)
+}
+
+// GetByUserCode mocks base method.
+func (m *MockDeviceCodeStore) GetByUserCode(ctx context.Context, userCode string) (*dal.DeviceCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUserCode", ctx, userCode)
+	ret0, _ := ret[0].(*dal.DeviceCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUserCode indicates an expected call of GetByUserCode.
+func (mr *MockDeviceCodeStoreMockRecorder) GetByUserCode(ctx, userCode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUserCode", reflect.TypeOf((*MockDeviceCodeStore)(nil).GetByUserCode), ctx, userCode)
+}
+
+// Redeem mocks base method.
+func (m *MockDeviceCodeStore) Redeem(ctx context.Context, deviceCode string) (*dal.DeviceCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Redeem", ctx, deviceCode)
+	ret0, _ := ret[0].(*dal.DeviceCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Redeem indicates an expected call of Redeem.
+func (mr *MockDeviceCodeStoreMockRecorder) Redeem(ctx, deviceCode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Redeem", reflect.TypeOf((*MockDeviceCodeStore)(nil).Redeem), ctx, deviceCode)
+}
+
+// UpdatePolling mocks base method.
+func (m *MockDeviceCodeStore) UpdatePolling(ctx context.Context, deviceCode string, polledAt, interval int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePolling", ctx, deviceCode, polledAt, interval)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePolling indicates an expected call of UpdatePolling.
+func (mr *MockDeviceCodeStoreMockRecorder) UpdatePolling(ctx, deviceCode, polledAt, interval interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePolling", reflect.TypeOf((*MockDeviceCodeStore)(nil).UpdatePolling), ctx, deviceCode, polledAt, interval)
+}
@@ -1,4 +1,13 @@
+//go:generate mockgen -package=mock -source=../attempt_store.go -destination=attempt_store.go
+//go:generate mockgen -package=mock -source=../assertion_store.go -destination=assertion_store.go
+//go:generate mockgen -package=mock -source=../authorization_code_store.go -destination=authorization_code_store.go
 //go:generate mockgen -package=mock -source=../client_provider.go -destination=client_provider.go
+//go:generate mockgen -package=mock -source=../consent_store.go -destination=consent_store.go
+//go:generate mockgen -package=mock -source=../device_code_store.go -destination=device_code_store.go
+//go:generate mockgen -package=mock -source=../refresh_token_store.go -destination=refresh_token_store.go
+//go:generate mockgen -package=mock -source=../revocation_store.go -destination=revocation_store.go
+//go:generate mockgen -package=mock -source=../session_store.go -destination=session_store.go
+//go:generate mockgen -package=mock -source=../trusted_issuer_provider.go -destination=trusted_issuer_provider.go
 //go:generate mockgen -package=mock -source=../user_provider.go -destination=user_provider.go
 //go:generate mockgen -package=mock -source=../user_service.go -destination=user_service.go
 
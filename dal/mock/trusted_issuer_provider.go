@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../trusted_issuer_provider.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	gomock "github.com/golang/mock/gomock"
+	dal "github.com/reecerussell/goidc/dal"
+	reflect "reflect"
+)
+
+// MockTrustedIssuerProvider is a mock of TrustedIssuerProvider interface.
+type MockTrustedIssuerProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockTrustedIssuerProviderMockRecorder
+}
+
+// MockTrustedIssuerProviderMockRecorder is the mock recorder for MockTrustedIssuerProvider.
+type MockTrustedIssuerProviderMockRecorder struct {
+	mock *MockTrustedIssuerProvider
+}
+
+// NewMockTrustedIssuerProvider creates a new mock instance.
+func NewMockTrustedIssuerProvider(ctrl *gomock.Controller) *MockTrustedIssuerProvider {
+	mock := &MockTrustedIssuerProvider{ctrl: ctrl}
+	mock.recorder = &MockTrustedIssuerProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTrustedIssuerProvider) EXPECT() *MockTrustedIssuerProviderMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockTrustedIssuerProvider) Get(ctx context.Context, issuer string) (*dal.TrustedIssuer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, issuer)
+	ret0, _ := ret[0].(*dal.TrustedIssuer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockTrustedIssuerProviderMockRecorder) Get(ctx, issuer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockTrustedIssuerProvider)(nil).Get), ctx, issuer)
+}
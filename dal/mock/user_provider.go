@@ -34,6 +34,21 @@ func (m *MockUserProvider) EXPECT() *MockUserProviderMockRecorder {
 	return m.recorder
 }
 
+// Get mocks base method.
+func (m *MockUserProvider) Get(ctx context.Context, id string) (*dal.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*dal.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockUserProviderMockRecorder) Get(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockUserProvider)(nil).Get), ctx, id)
+}
+
 // GetByEmail mocks base method.
 func (m *MockUserProvider) GetByEmail(ctx context.Context, email string) (*dal.User, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,20 @@
+package dal
+
+// RefreshToken represents the structure of a refresh token in the database.
+type RefreshToken struct {
+	// ID is a hash of the refresh token, meaning the
+	// token itself is never persisted.
+	ID string `json:"id"`
+
+	// FamilyID groups together every refresh token issued by rotating
+	// an original refresh token, allowing them to be revoked together.
+	FamilyID string   `json:"familyId"`
+	ClientID string   `json:"clientId"`
+	UserID   string   `json:"userId"`
+	Scopes   []string `json:"scopes"`
+	Used     bool     `json:"used"`
+	Revoked  bool     `json:"revoked"`
+
+	// Expires is the unix timestamp at which the token expires.
+	Expires int64 `json:"expires"`
+}
@@ -0,0 +1,34 @@
+package dal
+
+import (
+	"context"
+	"errors"
+)
+
+// Common refresh token errors.
+var (
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrRefreshTokenUsed     = errors.New("refresh token has already been used")
+)
+
+// RefreshTokenStore is used to persist, rotate and revoke refresh tokens.
+type RefreshTokenStore interface {
+	// Create inserts a refresh token into the data store.
+	Create(ctx context.Context, t *RefreshToken) error
+
+	// Get retrieves a refresh token from the data store, with the given id.
+	// If the token cannot be found, ErrRefreshTokenNotFound will be returned
+	// as the error.
+	Get(ctx context.Context, id string) (*RefreshToken, error)
+
+	// MarkUsed flags the refresh token with the given id as used. If the
+	// token has already been used, ErrRefreshTokenUsed will be returned as
+	// the error, allowing concurrent use of a token to be detected.
+	MarkUsed(ctx context.Context, id string) error
+
+	// RevokeFamily revokes every refresh token in the given family.
+	RevokeFamily(ctx context.Context, familyID string) error
+
+	// RevokeClient revokes every refresh token issued to clientID for userID.
+	RevokeClient(ctx context.Context, userID, clientID string) error
+}
@@ -0,0 +1,13 @@
+package dal
+
+import "context"
+
+// RevocationStore is used to record tokens which have been revoked, so that
+// they can be rejected before they expire.
+type RevocationStore interface {
+	// Revoke records the token as revoked, until it expires.
+	Revoke(ctx context.Context, t *RevokedToken) error
+
+	// IsRevoked determines whether the token with the given id has been revoked.
+	IsRevoked(ctx context.Context, id string) (bool, error)
+}
@@ -0,0 +1,11 @@
+package dal
+
+// RevokedToken represents a token which has been revoked before it expired.
+type RevokedToken struct {
+	// ID is the "jti" of an access token, or the hash of a refresh token.
+	ID string `json:"id"`
+
+	// Expires is the unix timestamp the token would have expired at, after
+	// which the record is no longer needed and can be removed.
+	Expires int64 `json:"expires"`
+}
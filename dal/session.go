@@ -0,0 +1,19 @@
+package dal
+
+// Session represents the structure of a user's single sign-on session in the
+// database. The session id is kept in the user's session cookie, allowing them
+// to authorize further requests without entering their credentials again.
+type Session struct {
+	ID     string `json:"id"`
+	UserID string `json:"userId"`
+
+	// AuthTime is the unix timestamp at which the user logged in.
+	AuthTime int64 `json:"authTime"`
+
+	// Expires is the unix timestamp at which the session expires.
+	Expires int64 `json:"expires"`
+
+	// ClientIDs are the clients which have been authorized during the
+	// session, which are notified when the user logs out.
+	ClientIDs []string `json:"clientIds,omitempty"`
+}
@@ -0,0 +1,28 @@
+package dal
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrSessionNotFound is a common error used when a session cannot be found.
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionStore is used to persist and retrieve single sign-on sessions.
+type SessionStore interface {
+	// Create inserts a session into the data store.
+	Create(ctx context.Context, s *Session) error
+
+	// Get retrieves the session with the given id. If the session cannot
+	// be found, ErrSessionNotFound will be returned as the error.
+	Get(ctx context.Context, id string) (*Session, error)
+
+	// AddClient records that clientID has been authorized during the session
+	// with the given id. If the session cannot be found, ErrSessionNotFound
+	// will be returned as the error.
+	AddClient(ctx context.Context, id, clientID string) error
+
+	// Delete removes the session with the given id, ending it. Deleting
+	// a session which doesn't exist is not an error.
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,23 @@
+package dal
+
+import "github.com/reecerussell/goidc/jwk"
+
+// TrustedIssuer represents a third-party token issuer, such as a CI system
+// or Kubernetes cluster, whose JWTs can be exchanged for goidc tokens using
+// the JWT bearer grant.
+type TrustedIssuer struct {
+	Issuer string `json:"issuer"`
+
+	// JWKS and JWKSUri are used to verify the issuer's tokens. If
+	// both are set, the inline JWKS takes precedence.
+	JWKS    *jwk.Set `json:"jwks,omitempty"`
+	JWKSUri string   `json:"jwksUri,omitempty"`
+
+	// Audience is the audience the issuer's tokens must be issued for. If
+	// empty, tokens must be issued for goidc's token endpoint.
+	Audience string `json:"audience,omitempty"`
+
+	// Subjects maps the subjects of the issuer's tokens to the
+	// id of the client they're issued goidc tokens as.
+	Subjects map[string]string `json:"subjects"`
+}
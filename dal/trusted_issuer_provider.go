@@ -0,0 +1,18 @@
+package dal
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrTrustedIssuerNotFound is a common error used when an
+// issuer cannot be found, meaning it is not trusted.
+var ErrTrustedIssuerNotFound = errors.New("trusted issuer not found")
+
+// TrustedIssuerProvider is used to retrieve trusted issuers from the database.
+type TrustedIssuerProvider interface {
+	// Get retrieves a trusted issuer from the database, with the given
+	// issuer. If the issuer cannot be found, ErrTrustedIssuerNotFound
+	// will be returned as the error.
+	Get(ctx context.Context, issuer string) (*TrustedIssuer, error)
+}
@@ -0,0 +1,12 @@
+package dal
+
+// UsedAssertion represents a JWT assertion, such as a client assertion,
+// which has already been used and must not be accepted again.
+type UsedAssertion struct {
+	// ID uniquely identifies the assertion, made up of its issuer and "jti".
+	ID string `json:"id"`
+
+	// Expires is the unix timestamp the assertion expires at, after
+	// which the record is no longer needed and can be removed.
+	Expires int64 `json:"expires"`
+}
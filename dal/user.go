@@ -2,7 +2,18 @@ package dal
 
 // User represents the structure of a user in the database.
 type User struct {
-	ID           string `json:"userId"`
-	Email        string `json:"email"`
-	PasswordHash string `json:"passwordHash"`
+	ID            string `json:"userId"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"emailVerified"`
+	PasswordHash  string `json:"passwordHash"`
+
+	// Profile claims, returned from the UserInfo endpoint
+	// when the "profile" scope has been granted.
+	Name              string `json:"name,omitempty"`
+	GivenName         string `json:"givenName,omitempty"`
+	FamilyName        string `json:"familyName,omitempty"`
+	PreferredUsername string `json:"preferredUsername,omitempty"`
+	Picture           string `json:"picture,omitempty"`
+	Locale            string `json:"locale,omitempty"`
+	UpdatedAt         int64  `json:"updatedAt,omitempty"`
 }
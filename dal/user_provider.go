@@ -10,5 +10,6 @@ var ErrUserNotFound = errors.New("user not found")
 
 // UserProvider is a DAL interface used to retrieve user data from the database.
 type UserProvider interface {
+	Get(ctx context.Context, id string) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
 }
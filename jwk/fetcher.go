@@ -0,0 +1,94 @@
+package jwk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/reecerussell/goidc/util"
+)
+
+// DefaultFetchTimeout is the timeout of requests made by the
+// fetcher returned from NewFetcher.
+const DefaultFetchTimeout = 5 * time.Second
+
+// Fetcher is used to get key sets published by third parties, such as
+// clients which authenticate using private_key_jwt.
+type Fetcher interface {
+	// Fetch returns the key set served from uri.
+	Fetch(ctx context.Context, uri string) (*Set, error)
+}
+
+type httpFetcher struct {
+	client *http.Client
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	cache map[string]*cachedSet
+}
+
+type cachedSet struct {
+	set     *Set
+	expires time.Time
+}
+
+// NewFetcher returns a new instance of Fetcher, which fetches key
+// sets over HTTP and caches them for DefaultCacheDuration.
+func NewFetcher() Fetcher {
+	return NewHTTPFetcher(&http.Client{Timeout: DefaultFetchTimeout}, DefaultCacheDuration)
+}
+
+// NewHTTPFetcher returns a new instance of Fetcher, using client to
+// fetch key sets, which are then cached for ttl.
+func NewHTTPFetcher(client *http.Client, ttl time.Duration) Fetcher {
+	return &httpFetcher{
+		client: client,
+		ttl:    ttl,
+		cache:  make(map[string]*cachedSet),
+	}
+}
+
+func (f *httpFetcher) Fetch(ctx context.Context, uri string) (*Set, error) {
+	f.mu.Lock()
+	item, ok := f.cache[uri]
+	f.mu.Unlock()
+
+	if ok && util.Time().Before(item.expires) {
+		return item.set, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwk: fetching %s returned status %d", uri, resp.StatusCode)
+	}
+
+	var set Set
+	err = json.NewDecoder(resp.Body).Decode(&set)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	f.cache[uri] = &cachedSet{
+		set:     &set,
+		expires: util.Time().Add(f.ttl),
+	}
+	f.mu.Unlock()
+
+	return &set, nil
+}
@@ -0,0 +1,67 @@
+package jwk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc/util"
+)
+
+func newTestServer(t *testing.T, status int, set *Set) (*httptest.Server, *int) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &calls
+}
+
+func TestFetcher_Fetch_ReturnsKeySet(t *testing.T) {
+	set := &Set{Keys: []*Key{{KeyType: "RSA", KeyID: "my-key", N: "AQAB", E: "AQAB"}}}
+	srv, _ := newTestServer(t, http.StatusOK, set)
+
+	f := NewHTTPFetcher(srv.Client(), time.Hour)
+	fetched, err := f.Fetch(context.Background(), srv.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, set, fetched)
+}
+
+func TestFetcher_Fetch_CachesKeySet(t *testing.T) {
+	util.Freeze()
+	defer util.Reset()
+
+	srv, calls := newTestServer(t, http.StatusOK, &Set{})
+
+	f := NewHTTPFetcher(srv.Client(), time.Hour)
+	first, _ := f.Fetch(context.Background(), srv.URL)
+	second, _ := f.Fetch(context.Background(), srv.URL)
+	assert.Equal(t, 1, *calls)
+	assert.Equal(t, first, second)
+}
+
+func TestFetcher_Fetch_WhereCacheHasExpired_RefetchesKeySet(t *testing.T) {
+	srv, calls := newTestServer(t, http.StatusOK, &Set{})
+
+	f := NewHTTPFetcher(srv.Client(), 0)
+	f.Fetch(context.Background(), srv.URL)
+	f.Fetch(context.Background(), srv.URL)
+	assert.Equal(t, 2, *calls)
+}
+
+func TestFetcher_Fetch_GivenErrorStatus_ReturnsError(t *testing.T) {
+	srv, _ := newTestServer(t, http.StatusNotFound, &Set{})
+
+	f := NewHTTPFetcher(srv.Client(), time.Hour)
+	set, err := f.Fetch(context.Background(), srv.URL)
+	assert.Nil(t, set)
+	assert.Error(t, err)
+}
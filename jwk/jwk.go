@@ -0,0 +1,124 @@
+// Package jwk is used to represent public signing keys as JSON Web Keys,
+// as defined by RFC 7517, so that tokens can be verified by resource servers.
+package jwk
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrUnsupportedKeyType is returned when a public key cannot be represented as a JWK.
+var ErrUnsupportedKeyType = errors.New("unsupported key type")
+
+// Key is a JSON Web Key, representing a public RSA or EC key.
+type Key struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+
+	// RSA public key parameters.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC public key parameters.
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// Set is a JSON Web Key Set, served from /.well-known/jwks.json.
+type Set struct {
+	Keys []*Key `json:"keys"`
+}
+
+// Key returns the key in s with the given kid, or nil if there isn't one.
+func (s *Set) Key(kid string) *Key {
+	for _, k := range s.Keys {
+		if k.KeyID == kid {
+			return k
+		}
+	}
+
+	return nil
+}
+
+// KeyID derives the "kid" of a key from the id of the key in KMS. The
+// kid is stable for the lifetime of the key, without exposing its id.
+func KeyID(id string) string {
+	hash := sha256.Sum256([]byte(id))
+
+	return base64.RawURLEncoding.EncodeToString(hash[:16])
+}
+
+// New returns a signing Key for pub, which must be an *rsa.PublicKey
+// or *ecdsa.PublicKey, where alg is the JWS algorithm the key is used with.
+func New(kid, alg string, pub interface{}) (*Key, error) {
+	k := &Key{
+		Use:       "sig",
+		KeyID:     kid,
+		Algorithm: alg,
+	}
+
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		k.KeyType = "RSA"
+		k.N = encode(pub.N.Bytes())
+		k.E = encode(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+
+		k.KeyType = "EC"
+		k.Curve = pub.Curve.Params().Name
+		k.X = encode(pad(pub.X.Bytes(), size))
+		k.Y = encode(pad(pub.Y.Bytes(), size))
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedKeyType, pub)
+	}
+
+	return k, nil
+}
+
+// PublicKey returns the *rsa.PublicKey or *ecdsa.PublicKey represented by k.
+func (k *Key) PublicKey() (interface{}, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedKeyType, k.KeyType)
+	}
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// pad left-pads b with zeros to size bytes, as EC coordinates are fixed-length.
+func pad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+
+	padded := make([]byte, size)
+	copy(padded[size-len(b):], b)
+
+	return padded
+}
@@ -0,0 +1,62 @@
+package jwk
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyID_ReturnsStableValue(t *testing.T) {
+	kid := KeyID("1234abcd-12ab-34cd-56ef-1234567890ab")
+
+	assert.Equal(t, kid, KeyID("1234abcd-12ab-34cd-56ef-1234567890ab"))
+	assert.NotEqual(t, kid, KeyID("4321abcd-12ab-34cd-56ef-1234567890ab"))
+	assert.NotContains(t, kid, "1234abcd")
+}
+
+func TestNew_GivenRSAKey_ReturnsKey(t *testing.T) {
+	pk, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	k, err := New("my-kid", "RS256", &pk.PublicKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "RSA", k.KeyType)
+	assert.Equal(t, "sig", k.Use)
+	assert.Equal(t, "my-kid", k.KeyID)
+	assert.Equal(t, "RS256", k.Algorithm)
+	assert.Equal(t, "AQAB", k.E)
+
+	pub, err := k.PublicKey()
+	assert.NoError(t, err)
+	assert.Equal(t, &pk.PublicKey, pub)
+}
+
+func TestNew_GivenECKey_ReturnsKey(t *testing.T) {
+	pk, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	k, err := New("my-kid", "ES256", &pk.PublicKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "EC", k.KeyType)
+	assert.Equal(t, "P-256", k.Curve)
+	assert.Len(t, k.X, 43)
+	assert.Len(t, k.Y, 43)
+}
+
+func TestNew_GivenUnsupportedKey_ReturnsError(t *testing.T) {
+	k, err := New("my-kid", "RS256", "not a key")
+	assert.Nil(t, k)
+	assert.True(t, errors.Is(err, ErrUnsupportedKeyType))
+}
+
+func TestSet_Key_ReturnsKeyWithMatchingKid(t *testing.T) {
+	s := &Set{
+		Keys: []*Key{{KeyID: "one"}, {KeyID: "two"}},
+	}
+
+	assert.Equal(t, "two", s.Key("two").KeyID)
+	assert.Nil(t, s.Key("three"))
+}
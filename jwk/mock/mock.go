@@ -0,0 +1,4 @@
+//go:generate mockgen -package=mock -source=../fetcher.go -destination=fetcher.go
+//go:generate mockgen -package=mock -source=../provider.go -destination=provider.go
+
+package mock
@@ -0,0 +1,112 @@
+package jwk
+
+import (
+	"context"
+	"crypto/x509"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+
+	"github.com/reecerussell/goidc/util"
+)
+
+// kmsAlgorithm is the JWS algorithm of the KMS signing keys, which are
+// used with the RSASSA_PKCS1_V1_5_SHA_256 signing algorithm.
+const kmsAlgorithm = "RS256"
+
+// DefaultCacheDuration is how long public keys are cached for, by
+// the provider returned from NewProvider.
+const DefaultCacheDuration = time.Hour
+
+// Provider is used to get the public signing keys.
+type Provider interface {
+	// Get returns a key set containing the public key
+	// for each of the given KMS key ids.
+	Get(ctx context.Context, keyIDs ...string) (*Set, error)
+}
+
+type kmsProvider struct {
+	svc kmsiface.KMSAPI
+	ttl time.Duration
+
+	mu    sync.Mutex
+	cache map[string]*cacheItem
+}
+
+type cacheItem struct {
+	key     *Key
+	expires time.Time
+}
+
+// NewProvider returns a new instance of Provider, which fetches public
+// keys from KMS and caches them for DefaultCacheDuration.
+func NewProvider(sess *session.Session) Provider {
+	return NewKMSProvider(kms.New(sess), DefaultCacheDuration)
+}
+
+// NewKMSProvider returns a new instance of Provider, using svc to fetch
+// public keys, which are then cached for ttl.
+func NewKMSProvider(svc kmsiface.KMSAPI, ttl time.Duration) Provider {
+	return &kmsProvider{
+		svc:   svc,
+		ttl:   ttl,
+		cache: make(map[string]*cacheItem),
+	}
+}
+
+func (p *kmsProvider) Get(ctx context.Context, keyIDs ...string) (*Set, error) {
+	set := &Set{
+		Keys: make([]*Key, len(keyIDs)),
+	}
+
+	for i, id := range keyIDs {
+		key, err := p.get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		set.Keys[i] = key
+	}
+
+	return set, nil
+}
+
+func (p *kmsProvider) get(ctx context.Context, id string) (*Key, error) {
+	p.mu.Lock()
+	item, ok := p.cache[id]
+	p.mu.Unlock()
+
+	if ok && util.Time().Before(item.expires) {
+		return item.key, nil
+	}
+
+	out, err := p.svc.GetPublicKeyWithContext(ctx, &kms.GetPublicKeyInput{
+		KeyId: aws.String(id),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(out.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := New(KeyID(id), kmsAlgorithm, pub)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.cache[id] = &cacheItem{
+		key:     key,
+		expires: util.Time().Add(p.ttl),
+	}
+	p.mu.Unlock()
+
+	return key, nil
+}
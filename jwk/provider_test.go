@@ -0,0 +1,99 @@
+package jwk
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc/util"
+)
+
+type fakeKMS struct {
+	kmsiface.KMSAPI
+
+	calls int
+	keys  map[string][]byte
+}
+
+func (f *fakeKMS) GetPublicKeyWithContext(_ aws.Context, in *kms.GetPublicKeyInput, _ ...request.Option) (*kms.GetPublicKeyOutput, error) {
+	f.calls++
+
+	pub, ok := f.keys[*in.KeyId]
+	if !ok {
+		return nil, errors.New("key not found")
+	}
+
+	return &kms.GetPublicKeyOutput{
+		KeyId:     in.KeyId,
+		PublicKey: pub,
+	}, nil
+}
+
+func newFakeKMS(t *testing.T, keyIDs ...string) *fakeKMS {
+	f := &fakeKMS{keys: make(map[string][]byte)}
+	for _, id := range keyIDs {
+		pk, _ := rsa.GenerateKey(rand.Reader, 2048)
+		der, err := x509.MarshalPKIXPublicKey(&pk.PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f.keys[id] = der
+	}
+
+	return f
+}
+
+func TestKMSProvider_Get_ReturnsKeys(t *testing.T) {
+	svc := newFakeKMS(t, "key-one", "key-two")
+	p := NewKMSProvider(svc, time.Hour)
+
+	set, err := p.Get(context.Background(), "key-one", "key-two")
+	assert.NoError(t, err)
+	assert.Len(t, set.Keys, 2)
+	assert.Equal(t, KeyID("key-one"), set.Keys[0].KeyID)
+	assert.Equal(t, KeyID("key-two"), set.Keys[1].KeyID)
+	assert.Equal(t, "RSA", set.Keys[0].KeyType)
+	assert.Equal(t, "RS256", set.Keys[0].Algorithm)
+}
+
+func TestKMSProvider_Get_CachesKeys(t *testing.T) {
+	util.Freeze()
+	defer util.Reset()
+
+	svc := newFakeKMS(t, "key-one")
+	p := NewKMSProvider(svc, time.Hour)
+
+	first, _ := p.Get(context.Background(), "key-one")
+	second, _ := p.Get(context.Background(), "key-one")
+	assert.Equal(t, 1, svc.calls)
+	assert.Equal(t, first, second)
+}
+
+func TestKMSProvider_Get_WhereCacheHasExpired_RefetchesKey(t *testing.T) {
+	svc := newFakeKMS(t, "key-one")
+	p := NewKMSProvider(svc, 0)
+
+	p.Get(context.Background(), "key-one")
+	p.Get(context.Background(), "key-one")
+	assert.Equal(t, 2, svc.calls)
+}
+
+func TestKMSProvider_Get_WhereKMSFails_ReturnsError(t *testing.T) {
+	svc := newFakeKMS(t)
+	p := NewKMSProvider(svc, time.Hour)
+
+	set, err := p.Get(context.Background(), "key-one")
+	assert.Nil(t, set)
+	assert.Error(t, err)
+}
@@ -0,0 +1,52 @@
+// Package logout is used to notify clients when a user logs out, as defined
+// by OpenID Connect Back-Channel Logout and Front-Channel Logout.
+package logout
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/reecerussell/goidc/dal"
+)
+
+// BackchannelLogoutEvent is the member of a logout token's "events"
+// claim, which identifies the token as a logout token.
+const BackchannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+// TokenExpiry is the lifetime of a logout token, in seconds.
+const TokenExpiry int64 = 120
+
+// TokenClaims returns the claims of a logout token, sent to clients when sub
+// ends the session with the given sid. Logout tokens must never contain
+// a nonce, so they cannot be used in place of an ID token.
+func TokenClaims(sub, sid string) map[string]interface{} {
+	return map[string]interface{}{
+		"sub": sub,
+		"sid": sid,
+		"events": map[string]interface{}{
+			BackchannelLogoutEvent: map[string]interface{}{},
+		},
+	}
+}
+
+// FrontchannelUri returns the front-channel logout uri of c, rendered by the
+// user's browser when they end the session with the given sid. Clients which
+// require the session are given the issuer and sid in the query.
+func FrontchannelUri(c *dal.Client, issuer, sid string) string {
+	if !c.FrontchannelLogoutSessionRequired {
+		return c.FrontchannelLogoutUri
+	}
+
+	params := url.Values{
+		"iss": {issuer},
+		"sid": {sid},
+	}
+
+	// The uri may already have a query component, which must be kept.
+	separator := "?"
+	if strings.Contains(c.FrontchannelLogoutUri, "?") {
+		separator = "&"
+	}
+
+	return c.FrontchannelLogoutUri + separator + params.Encode()
+}
@@ -0,0 +1,47 @@
+package logout
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc/dal"
+)
+
+func TestTokenClaims(t *testing.T) {
+	claims := TokenClaims("my user", "my session")
+
+	assert.Equal(t, "my user", claims["sub"])
+	assert.Equal(t, "my session", claims["sid"])
+	assert.Contains(t, claims["events"], BackchannelLogoutEvent)
+	assert.NotContains(t, claims, "nonce")
+}
+
+func TestFrontchannelUri(t *testing.T) {
+	t.Run("Given Session Not Required", func(t *testing.T) {
+		c := &dal.Client{FrontchannelLogoutUri: "https://client.example.com/logout"}
+
+		uri := FrontchannelUri(c, "https://example.com/prod", "my session")
+		assert.Equal(t, "https://client.example.com/logout", uri)
+	})
+
+	t.Run("Given Session Required", func(t *testing.T) {
+		c := &dal.Client{
+			FrontchannelLogoutUri:             "https://client.example.com/logout",
+			FrontchannelLogoutSessionRequired: true,
+		}
+
+		uri := FrontchannelUri(c, "https://example.com/prod", "my session")
+		assert.Equal(t, "https://client.example.com/logout?iss=https%3A%2F%2Fexample.com%2Fprod&sid=my+session", uri)
+	})
+
+	t.Run("Given Uri With Query", func(t *testing.T) {
+		c := &dal.Client{
+			FrontchannelLogoutUri:             "https://client.example.com/logout?app=1",
+			FrontchannelLogoutSessionRequired: true,
+		}
+
+		uri := FrontchannelUri(c, "https://example.com/prod", "my session")
+		assert.Equal(t, "https://client.example.com/logout?app=1&iss=https%3A%2F%2Fexample.com%2Fprod&sid=my+session", uri)
+	})
+}
@@ -0,0 +1,3 @@
+//go:generate mockgen -package=mock -source=../sender.go -destination=sender.go
+
+package mock
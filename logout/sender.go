@@ -0,0 +1,94 @@
+package logout
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Default options of the Sender returned by NewSender.
+const (
+	DefaultAttempts = 3
+	DefaultBackoff  = 500 * time.Millisecond
+	DefaultTimeout  = 5 * time.Second
+)
+
+// Sender is used to deliver logout tokens to the back-channel
+// logout uris of clients.
+type Sender interface {
+	// Send posts logoutToken to uri, returning an error if it
+	// could not be delivered.
+	Send(ctx context.Context, uri, logoutToken string) error
+}
+
+type httpSender struct {
+	client   *http.Client
+	attempts int
+	backoff  time.Duration
+}
+
+// NewSender returns a new instance of Sender, which delivers logout tokens
+// using an HTTP client with DefaultTimeout, retrying DefaultAttempts times.
+func NewSender() Sender {
+	return NewSenderWithRetries(&http.Client{Timeout: DefaultTimeout}, DefaultAttempts, DefaultBackoff)
+}
+
+// NewSenderWithRetries returns a new instance of Sender, which delivers logout
+// tokens using client. Tokens which cannot be delivered are retried until
+// they have been sent the given number of attempts, waiting longer between
+// each attempt, starting with backoff.
+func NewSenderWithRetries(client *http.Client, attempts int, backoff time.Duration) Sender {
+	return &httpSender{
+		client:   client,
+		attempts: attempts,
+		backoff:  backoff,
+	}
+}
+
+func (s *httpSender) Send(ctx context.Context, uri, logoutToken string) error {
+	var err error
+	for attempt := 1; attempt <= s.attempts; attempt++ {
+		var retry bool
+		retry, err = s.send(ctx, uri, logoutToken)
+		if err == nil || !retry || attempt == s.attempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.backoff * time.Duration(attempt)):
+		}
+	}
+
+	return err
+}
+
+// send makes a single attempt to post logoutToken to uri. If the attempt
+// fails, retry determines whether it may succeed if sent again. Clients
+// respond with a 400 to tokens they reject, which will never succeed.
+func (s *httpSender) send(ctx context.Context, uri, logoutToken string) (retry bool, err error) {
+	body := url.Values{"logout_token": {logoutToken}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, strings.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	err = fmt.Errorf("logout token was rejected by %s with status %d", uri, resp.StatusCode)
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests, err
+}
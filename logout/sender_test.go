@@ -0,0 +1,100 @@
+package logout
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestServer returns a server which responds to each request with the
+// next of the given status codes, repeating the last, and the number of
+// requests it has received.
+func newTestServer(t *testing.T, statusCodes ...int) (*httptest.Server, *int32) {
+	var requests int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&requests, 1))
+
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+		assert.Equal(t, "my.logout.token", r.FormValue("logout_token"))
+
+		if n > len(statusCodes) {
+			n = len(statusCodes)
+		}
+
+		w.WriteHeader(statusCodes[n-1])
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &requests
+}
+
+func newTestSender(srv *httptest.Server) Sender {
+	return NewSenderWithRetries(srv.Client(), 3, time.Millisecond)
+}
+
+func TestSend_GivenAcceptingClient_SendsOnce(t *testing.T) {
+	srv, requests := newTestServer(t, http.StatusOK)
+
+	err := newTestSender(srv).Send(context.Background(), srv.URL, "my.logout.token")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+}
+
+func TestSend_GivenFailingClient_Retries(t *testing.T) {
+	srv, requests := newTestServer(t, http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusNoContent)
+
+	err := newTestSender(srv).Send(context.Background(), srv.URL, "my.logout.token")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(requests))
+}
+
+func TestSend_GivenClientWhichAlwaysFails_ReturnsError(t *testing.T) {
+	srv, requests := newTestServer(t, http.StatusBadGateway)
+
+	err := newTestSender(srv).Send(context.Background(), srv.URL, "my.logout.token")
+	assert.Error(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(requests))
+}
+
+func TestSend_GivenRejectingClient_DoesNotRetry(t *testing.T) {
+	srv, requests := newTestServer(t, http.StatusBadRequest)
+
+	err := newTestSender(srv).Send(context.Background(), srv.URL, "my.logout.token")
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+}
+
+func TestSend_GivenUnreachableClient_ReturnsError(t *testing.T) {
+	srv, _ := newTestServer(t, http.StatusOK)
+	uri := srv.URL
+	srv.Close()
+
+	err := newTestSender(srv).Send(context.Background(), uri, "my.logout.token")
+	assert.Error(t, err)
+}
+
+func TestSend_GivenCancelledContext_StopsRetrying(t *testing.T) {
+	srv, requests := newTestServer(t, http.StatusInternalServerError)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sender := NewSenderWithRetries(srv.Client(), 3, time.Hour)
+
+	go func() {
+		for atomic.LoadInt32(requests) < 1 {
+			time.Sleep(time.Millisecond)
+		}
+
+		cancel()
+	}()
+
+	err := sender.Send(ctx, srv.URL, "my.logout.token")
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+}
@@ -0,0 +1,39 @@
+package oauth
+
+import "github.com/reecerussell/goidc/dal"
+
+// UserClaims returns the claims about u which the given scopes grant
+// access to. The "sub" claim is always present.
+func UserClaims(u *dal.User, scopes []string) map[string]interface{} {
+	claims := map[string]interface{}{
+		"sub": u.ID,
+	}
+
+	for _, scope := range scopes {
+		switch scope {
+		case ScopeEmail:
+			claims["email"] = u.Email
+			claims["email_verified"] = u.EmailVerified
+		case ScopeProfile:
+			setClaim(claims, "name", u.Name)
+			setClaim(claims, "given_name", u.GivenName)
+			setClaim(claims, "family_name", u.FamilyName)
+			setClaim(claims, "preferred_username", u.PreferredUsername)
+			setClaim(claims, "picture", u.Picture)
+			setClaim(claims, "locale", u.Locale)
+
+			if u.UpdatedAt > 0 {
+				claims["updated_at"] = u.UpdatedAt
+			}
+		}
+	}
+
+	return claims
+}
+
+// setClaim sets the claim with the given name, if value is not empty.
+func setClaim(claims map[string]interface{}, name, value string) {
+	if value != "" {
+		claims[name] = value
+	}
+}
@@ -0,0 +1,43 @@
+package oauth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc/dal"
+)
+
+func TestUserClaims(t *testing.T) {
+	testUser := &dal.User{
+		ID:            "238947",
+		Email:         "test@test.go",
+		EmailVerified: true,
+		PasswordHash:  "23o4iu2o3",
+		Name:          "Test User",
+		GivenName:     "Test",
+		FamilyName:    "User",
+	}
+
+	t.Run("Given OpenID Scope Only", func(t *testing.T) {
+		claims := UserClaims(testUser, []string{"openid"})
+		assert.Equal(t, map[string]interface{}{"sub": "238947"}, claims)
+	})
+
+	t.Run("Given Email Scope", func(t *testing.T) {
+		claims := UserClaims(testUser, []string{"openid", "email"})
+		assert.Equal(t, "test@test.go", claims["email"])
+		assert.Equal(t, true, claims["email_verified"])
+		assert.NotContains(t, claims, "name")
+	})
+
+	t.Run("Given Profile Scope", func(t *testing.T) {
+		claims := UserClaims(testUser, []string{"openid", "profile"})
+		assert.Equal(t, "Test User", claims["name"])
+		assert.Equal(t, "Test", claims["given_name"])
+		assert.Equal(t, "User", claims["family_name"])
+		assert.NotContains(t, claims, "picture")
+		assert.NotContains(t, claims, "updated_at")
+		assert.NotContains(t, claims, "email")
+	})
+}
@@ -0,0 +1,79 @@
+package oauth
+
+import "strings"
+
+// Endpoint paths, relative to the base URL of the API.
+const (
+	AuthorizationPath = "/oauth/authorize"
+	TokenPath         = "/oauth/token"
+	UserInfoPath      = "/oauth/userinfo"
+	IntrospectionPath = "/oauth/introspect"
+	RevocationPath    = "/oauth/revoke"
+	EndSessionPath    = "/oauth/end_session"
+	JWKSPath          = "/.well-known/jwks.json"
+
+	DeviceAuthorizationPath = "/oauth/device_authorization"
+)
+
+// Discovery is the OpenID Provider Metadata, served from
+// /.well-known/openid-configuration.
+type Discovery struct {
+	Issuer                                     string   `json:"issuer"`
+	AuthorizationEndpoint                      string   `json:"authorization_endpoint"`
+	TokenEndpoint                              string   `json:"token_endpoint"`
+	UserInfoEndpoint                           string   `json:"userinfo_endpoint"`
+	JWKSURI                                    string   `json:"jwks_uri"`
+	IntrospectionEndpoint                      string   `json:"introspection_endpoint"`
+	RevocationEndpoint                         string   `json:"revocation_endpoint"`
+	DeviceAuthorizationEndpoint                string   `json:"device_authorization_endpoint"`
+	EndSessionEndpoint                         string   `json:"end_session_endpoint"`
+	ResponseTypesSupported                     []string `json:"response_types_supported"`
+	ResponseModesSupported                     []string `json:"response_modes_supported"`
+	GrantTypesSupported                        []string `json:"grant_types_supported"`
+	SubjectTypesSupported                      []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported           []string `json:"id_token_signing_alg_values_supported"`
+	ScopesSupported                            []string `json:"scopes_supported"`
+	ClaimsSupported                            []string `json:"claims_supported"`
+	TokenEndpointAuthMethodsSupported          []string `json:"token_endpoint_auth_methods_supported"`
+	TokenEndpointAuthSigningAlgValuesSupported []string `json:"token_endpoint_auth_signing_alg_values_supported"`
+	CodeChallengeMethodsSupported              []string `json:"code_challenge_methods_supported"`
+	PromptValuesSupported                      []string `json:"prompt_values_supported"`
+	BackchannelLogoutSupported                 bool     `json:"backchannel_logout_supported"`
+	BackchannelLogoutSessionSupported          bool     `json:"backchannel_logout_session_supported"`
+	FrontchannelLogoutSupported                bool     `json:"frontchannel_logout_supported"`
+	FrontchannelLogoutSessionSupported         bool     `json:"frontchannel_logout_session_supported"`
+}
+
+// NewDiscovery builds the discovery document, where issuer is the issuer
+// identifier the API is served from, e.g. https://example.com/prod. The
+// endpoints are built from the issuer, so are served from the same URL.
+func NewDiscovery(issuer string) *Discovery {
+	baseUrl := strings.TrimSuffix(issuer, "/")
+
+	return &Discovery{
+		Issuer:                                     baseUrl,
+		AuthorizationEndpoint:                      baseUrl + AuthorizationPath,
+		TokenEndpoint:                              baseUrl + TokenPath,
+		UserInfoEndpoint:                           baseUrl + UserInfoPath,
+		JWKSURI:                                    baseUrl + JWKSPath,
+		IntrospectionEndpoint:                      baseUrl + IntrospectionPath,
+		RevocationEndpoint:                         baseUrl + RevocationPath,
+		DeviceAuthorizationEndpoint:                baseUrl + DeviceAuthorizationPath,
+		EndSessionEndpoint:                         baseUrl + EndSessionPath,
+		ResponseTypesSupported:                     ResponseTypes,
+		ResponseModesSupported:                     ResponseModes,
+		GrantTypesSupported:                        GrantTypes,
+		SubjectTypesSupported:                      []string{"public"},
+		IDTokenSigningAlgValuesSupported:           []string{SigningAlgorithm},
+		ScopesSupported:                            Scopes,
+		ClaimsSupported:                            Claims,
+		TokenEndpointAuthMethodsSupported:          TokenEndpointAuthMethods,
+		TokenEndpointAuthSigningAlgValuesSupported: TokenEndpointAuthSigningAlgs,
+		CodeChallengeMethodsSupported:              CodeChallengeMethods,
+		PromptValuesSupported:                      Prompts,
+		BackchannelLogoutSupported:                 true,
+		BackchannelLogoutSessionSupported:          true,
+		FrontchannelLogoutSupported:                true,
+		FrontchannelLogoutSessionSupported:         true,
+	}
+}
@@ -0,0 +1,33 @@
+package oauth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDiscovery(t *testing.T) {
+	d := NewDiscovery("https://example.com/prod/")
+
+	assert.Equal(t, "https://example.com/prod", d.Issuer)
+	assert.Equal(t, "https://example.com/prod/oauth/authorize", d.AuthorizationEndpoint)
+	assert.Equal(t, "https://example.com/prod/oauth/token", d.TokenEndpoint)
+	assert.Equal(t, "https://example.com/prod/oauth/userinfo", d.UserInfoEndpoint)
+	assert.Equal(t, "https://example.com/prod/.well-known/jwks.json", d.JWKSURI)
+	assert.Equal(t, "https://example.com/prod/oauth/introspect", d.IntrospectionEndpoint)
+	assert.Equal(t, "https://example.com/prod/oauth/revoke", d.RevocationEndpoint)
+	assert.Equal(t, "https://example.com/prod/oauth/device_authorization", d.DeviceAuthorizationEndpoint)
+	assert.Equal(t, "https://example.com/prod/oauth/end_session", d.EndSessionEndpoint)
+	assert.Equal(t, ResponseTypes, d.ResponseTypesSupported)
+	assert.Equal(t, GrantTypes, d.GrantTypesSupported)
+	assert.Equal(t, []string{"RS256"}, d.IDTokenSigningAlgValuesSupported)
+	assert.Equal(t, CodeChallengeMethods, d.CodeChallengeMethodsSupported)
+	assert.Equal(t, Prompts, d.PromptValuesSupported)
+	assert.True(t, d.BackchannelLogoutSupported)
+	assert.True(t, d.FrontchannelLogoutSupported)
+	assert.Equal(t, ResponseModes, d.ResponseModesSupported)
+	assert.Equal(t, TokenEndpointAuthMethods, d.TokenEndpointAuthMethodsSupported)
+	assert.Equal(t, []string{"RS256", "HS256"}, d.TokenEndpointAuthSigningAlgValuesSupported)
+	assert.Contains(t, d.ScopesSupported, "openid")
+	assert.Contains(t, d.SubjectTypesSupported, "public")
+}
@@ -0,0 +1,247 @@
+// Package oauth contains the OAuth 2.0 and OpenID Connect protocol values
+// supported by goidc. The handlers use these values to decide which flows
+// they accept, and the discovery document is built from them, so anything
+// advertised to relying parties is backed by a handler.
+package oauth
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/reecerussell/goidc"
+	"github.com/reecerussell/goidc/token"
+	"github.com/reecerussell/goidc/util"
+)
+
+// Issuer returns the issuer identifier of the server, which is the value of
+// the "iss" claim of the tokens it issues. As required by OpenID Connect
+// Discovery, this is the https URL the API is served from. This is the ISSUER
+// stage variable, if set, such as when the API is served from a custom
+// domain, otherwise it is the base URL of req.
+func Issuer(ctx context.Context, req events.APIGatewayProxyRequest) string {
+	issuer, ok := goidc.LookupStageVariable(ctx, "ISSUER")
+	if !ok || issuer == "" {
+		issuer = util.BaseURL(req)
+	}
+
+	return strings.TrimSuffix(issuer, "/")
+}
+
+// IssuerTokens returns a copy of tokens, which issues and verifies tokens as
+// the issuer of req. Handlers use this, rather than the service they were
+// built with, as the issuer isn't known until a request is received.
+func IssuerTokens(ctx context.Context, req events.APIGatewayProxyRequest, tokens token.Service) token.Service {
+	return tokens.WithIssuer(Issuer(ctx, req))
+}
+
+// Grant types supported by the token endpoint.
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
+	GrantTypePassword          = "password"
+	GrantTypeTokenExchange     = "urn:ietf:params:oauth:grant-type:token-exchange"
+	GrantTypeJWTBearer         = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+)
+
+// Token types used by the token exchange grant, as defined in RFC 8693.
+const (
+	TokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+// Response types supported by the authorization endpoint. Values are given in
+// the order returned by NormalizeResponseType.
+const (
+	ResponseTypeCode             = "code"
+	ResponseTypeIDToken          = "id_token"
+	ResponseTypeToken            = "token"
+	ResponseTypeCodeIDToken      = "code id_token"
+	ResponseTypeCodeToken        = "code token"
+	ResponseTypeIDTokenToken     = "id_token token"
+	ResponseTypeCodeIDTokenToken = "code id_token token"
+)
+
+// Response modes supported by the authorization endpoint, which determine how
+// the authorization response is returned to the client's redirect uri.
+const (
+	ResponseModeQuery    = "query"
+	ResponseModeFragment = "fragment"
+	ResponseModeFormPost = "form_post"
+)
+
+// Values of the prompt authorization parameter, as defined by OpenID Connect
+// Core, which control whether the user is asked to login again.
+const (
+	PromptNone          = "none"
+	PromptLogin         = "login"
+	PromptConsent       = "consent"
+	PromptSelectAccount = "select_account"
+)
+
+// PKCE code challenge methods, as defined in RFC 7636.
+const (
+	CodeChallengeMethodPlain = "plain"
+	CodeChallengeMethodS256  = "S256"
+)
+
+// Client authentication methods supported by the token endpoint.
+const (
+	TokenEndpointAuthMethodClientSecretBasic = "client_secret_basic"
+	TokenEndpointAuthMethodClientSecretPost  = "client_secret_post"
+	TokenEndpointAuthMethodNone              = "none"
+	TokenEndpointAuthMethodPrivateKeyJWT     = "private_key_jwt"
+	TokenEndpointAuthMethodClientSecretJWT   = "client_secret_jwt"
+)
+
+// ClientAssertionTypeJWTBearer is the client_assertion_type of JWT client
+// assertions, used by the private_key_jwt and client_secret_jwt methods.
+const ClientAssertionTypeJWTBearer = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// Scopes which grant access to claims about the user.
+const (
+	ScopeOpenID  = "openid"
+	ScopeEmail   = "email"
+	ScopeProfile = "profile"
+)
+
+// ScopeConnectedApps grants access to view and revoke the
+// apps the user has consented to.
+const ScopeConnectedApps = "connected_apps"
+
+// AuthenticationMethodPassword is the "amr" value of ID tokens for users
+// who authenticated using their password, as defined in RFC 8176.
+const AuthenticationMethodPassword = "pwd"
+
+// SigningAlgorithm is the JWS algorithm used to sign every token.
+const SigningAlgorithm = "RS256"
+
+var (
+	// GrantTypes contains every grant type supported by the token endpoint.
+	GrantTypes = []string{
+		GrantTypeAuthorizationCode,
+		GrantTypeClientCredentials,
+		GrantTypeRefreshToken,
+		GrantTypeDeviceCode,
+		GrantTypePassword,
+		GrantTypeTokenExchange,
+		GrantTypeJWTBearer,
+	}
+
+	// ResponseTypes contains every response type supported by the
+	// authorization endpoint.
+	ResponseTypes = []string{
+		ResponseTypeCode,
+		ResponseTypeIDToken,
+		ResponseTypeToken,
+		ResponseTypeCodeIDToken,
+		ResponseTypeCodeToken,
+		ResponseTypeIDTokenToken,
+		ResponseTypeCodeIDTokenToken,
+	}
+
+	// ResponseModes contains every response mode supported by the
+	// authorization endpoint.
+	ResponseModes = []string{
+		ResponseModeQuery,
+		ResponseModeFragment,
+		ResponseModeFormPost,
+	}
+
+	// Prompts contains the supported values of the prompt parameter.
+	Prompts = []string{
+		PromptNone,
+		PromptLogin,
+		PromptConsent,
+		PromptSelectAccount,
+	}
+
+	// CodeChallengeMethods contains the supported PKCE methods.
+	CodeChallengeMethods = []string{
+		CodeChallengeMethodPlain,
+		CodeChallengeMethodS256,
+	}
+
+	// TokenEndpointAuthMethods contains the supported client
+	// authentication methods.
+	TokenEndpointAuthMethods = []string{
+		TokenEndpointAuthMethodClientSecretBasic,
+		TokenEndpointAuthMethodClientSecretPost,
+		TokenEndpointAuthMethodNone,
+		TokenEndpointAuthMethodPrivateKeyJWT,
+		TokenEndpointAuthMethodClientSecretJWT,
+	}
+
+	// TokenEndpointAuthSigningAlgs contains the algorithms client
+	// assertions can be signed with. RS256 is used by private_key_jwt
+	// clients, and HS256 by client_secret_jwt clients, using their
+	// shared key.
+	TokenEndpointAuthSigningAlgs = []string{
+		"RS256",
+		"HS256",
+	}
+
+	// Scopes contains the scopes understood by goidc itself. Clients
+	// may be configured with additional, application-specific scopes.
+	Scopes = []string{
+		ScopeOpenID,
+		ScopeEmail,
+		ScopeProfile,
+		ScopeConnectedApps,
+	}
+
+	// Claims contains the claims which may be present in an ID
+	// token or returned from the UserInfo endpoint.
+	Claims = []string{
+		"iss",
+		"sub",
+		"aud",
+		"exp",
+		"iat",
+		"nbf",
+		"nonce",
+		"auth_time",
+		"azp",
+		"amr",
+		"at_hash",
+		"c_hash",
+		"s_hash",
+		"sid",
+		"email",
+		"email_verified",
+		"name",
+		"given_name",
+		"family_name",
+		"preferred_username",
+		"picture",
+		"locale",
+		"updated_at",
+	}
+)
+
+// NormalizeResponseType returns responseType with its values in a consistent
+// order, as the order of space-delimited response type values has no meaning,
+// e.g. "token id_token" is the same as "id_token token".
+func NormalizeResponseType(responseType string) string {
+	values := strings.Fields(responseType)
+
+	// Sorting the values alphabetically results in the same
+	// order as the response type constants.
+	sort.Strings(values)
+
+	return strings.Join(values, " ")
+}
+
+// DefaultResponseMode returns the response mode used for responseType, when
+// the client doesn't request one. Responses containing tokens default to the
+// fragment, so they aren't sent to the server or leaked in Referer headers.
+func DefaultResponseMode(responseType string) string {
+	if responseType == ResponseTypeCode {
+		return ResponseModeQuery
+	}
+
+	return ResponseModeFragment
+}
@@ -0,0 +1,69 @@
+package oauth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc"
+	"github.com/reecerussell/goidc/token/mock"
+)
+
+func TestNormalizeResponseType(t *testing.T) {
+	assert.Equal(t, ResponseTypeCode, NormalizeResponseType("code"))
+	assert.Equal(t, ResponseTypeIDTokenToken, NormalizeResponseType("token id_token"))
+	assert.Equal(t, ResponseTypeCodeIDTokenToken, NormalizeResponseType("id_token  token code"))
+}
+
+func TestDefaultResponseMode(t *testing.T) {
+	assert.Equal(t, ResponseModeQuery, DefaultResponseMode(ResponseTypeCode))
+	assert.Equal(t, ResponseModeFragment, DefaultResponseMode(ResponseTypeIDTokenToken))
+}
+
+func TestIssuer(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		Headers: map[string]string{
+			"Host": "example.com",
+		},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Stage: "prod",
+		},
+	}
+
+	t.Run("Given No Issuer Stage Variable", func(t *testing.T) {
+		ctx := goidc.NewContext(context.Background(), &req)
+		assert.Equal(t, "https://example.com/prod", Issuer(ctx, req))
+	})
+
+	t.Run("Given Issuer Stage Variable", func(t *testing.T) {
+		req := req
+		req.StageVariables = map[string]string{"ISSUER": "https://id.example.com/"}
+
+		ctx := goidc.NewContext(context.Background(), &req)
+		assert.Equal(t, "https://id.example.com", Issuer(ctx, req))
+	})
+}
+
+func TestIssuerTokens(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	req := events.APIGatewayProxyRequest{
+		Headers: map[string]string{
+			"Host": "example.com",
+		},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Stage: "prod",
+		},
+	}
+	ctx := goidc.NewContext(context.Background(), &req)
+
+	issuerTokens := mock.NewMockService(ctrl)
+	mockTokenService := mock.NewMockService(ctrl)
+	mockTokenService.EXPECT().WithIssuer("https://example.com/prod").Return(issuerTokens)
+
+	assert.Equal(t, issuerTokens, IssuerTokens(ctx, req, mockTokenService))
+}
@@ -60,4 +60,28 @@ module "users_endpoints" {
     aws_api_gateway_resource.api_proxy,
     aws_s3_bucket.ui_bucket
   ]
-}
\ No newline at end of file
+}
+
+resource "aws_api_gateway_resource" "well_known_proxy" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  parent_id   = aws_api_gateway_rest_api.api.root_resource_id
+  path_part   = ".well-known"
+
+  depends_on = [aws_api_gateway_rest_api.api]
+}
+
+module "well_known_endpoints" {
+  source = "./well-known"
+
+  api_gateway_id            = aws_api_gateway_rest_api.api.id
+  root_resource_id          = aws_api_gateway_resource.well_known_proxy.id
+  api_gateway_execution_arn = aws_api_gateway_rest_api.api.execution_arn
+  s3_bucket                 = var.s3_bucket
+  aws_region                = var.aws_region
+  aws_account_id            = var.aws_account_id
+
+  depends_on = [
+    aws_api_gateway_rest_api.api,
+    aws_api_gateway_resource.well_known_proxy
+  ]
+}
@@ -16,7 +16,7 @@ module "authorize_post" {
   s3_bucket        = var.s3_bucket
   aws_region       = var.aws_region
 
-  iam_policies = ["arn:aws:iam::aws:policy/AmazonDynamoDBReadOnlyAccess"]
+  iam_policies = ["arn:aws:iam::aws:policy/AmazonDynamoDBFullAccess"]
 
   depends_on = [
     aws_api_gateway_resource.authorize_proxy
@@ -36,7 +36,8 @@ resource "aws_iam_policy" "authorize_kms" {
       "Effect": "Allow",
       "Action": [
           "kms:GetPublicKey",
-          "kms:Sign"
+          "kms:Sign",
+          "kms:Verify"
       ],
       "Resource": "arn:aws:kms:${var.aws_region}:${var.aws_account_id}:key/*"
     }
@@ -0,0 +1,51 @@
+resource "aws_api_gateway_resource" "device_authorization_proxy" {
+  rest_api_id = var.api_gateway_id
+  parent_id   = var.root_resource_id
+  path_part   = "device_authorization"
+}
+
+module "device_authorize" {
+  source = "../../lambda/endpoint"
+
+  name        = "device-authorize"
+  http_method = "POST"
+
+  aws_account_id = var.aws_account_id
+  api_gateway_id   = var.api_gateway_id
+  root_resource_id = aws_api_gateway_resource.device_authorization_proxy.id
+  s3_bucket        = var.s3_bucket
+  aws_region       = var.aws_region
+
+  iam_policies = ["arn:aws:iam::aws:policy/AmazonDynamoDBFullAccess"]
+
+  depends_on = [
+    aws_api_gateway_resource.device_authorization_proxy
+  ]
+}
+
+module "device_authorize_dev" {
+  source = "../../lambda/alias"
+
+  name                      = "dev"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.device_authorize.function_arn
+  function_name             = module.device_authorize.function_name
+}
+
+module "device_authorize_test" {
+  source = "../../lambda/alias"
+
+  name                      = "test"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.device_authorize.function_arn
+  function_name             = module.device_authorize.function_name
+}
+
+module "device_authorize_prod" {
+  source = "../../lambda/alias"
+
+  name                      = "prod"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.device_authorize.function_arn
+  function_name             = module.device_authorize.function_name
+}
\ No newline at end of file
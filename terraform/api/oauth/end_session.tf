@@ -0,0 +1,81 @@
+resource "aws_api_gateway_resource" "end_session_proxy" {
+  rest_api_id = var.api_gateway_id
+  parent_id   = var.root_resource_id
+  path_part   = "end_session"
+}
+
+module "end_session" {
+  source = "../../lambda/endpoint"
+
+  name        = "end-session"
+  http_method = "ANY"
+
+  aws_account_id = var.aws_account_id
+  api_gateway_id   = var.api_gateway_id
+  root_resource_id = aws_api_gateway_resource.end_session_proxy.id
+  s3_bucket        = var.s3_bucket
+  aws_region       = var.aws_region
+
+  iam_policies = ["arn:aws:iam::aws:policy/AmazonDynamoDBFullAccess"]
+
+  depends_on = [
+    aws_api_gateway_resource.end_session_proxy
+  ]
+}
+
+resource "aws_iam_policy" "end_session_kms" {
+  name        = "end-session-kms"
+  path        = "/"
+  description = "IAM policy for kms for end-session"
+
+  policy = <<EOF
+{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Effect": "Allow",
+      "Action": [
+          "kms:GetPublicKey",
+          "kms:Sign",
+          "kms:Verify"
+      ],
+      "Resource": "arn:aws:kms:${var.aws_region}:${var.aws_account_id}:key/*"
+    }
+  ]
+}
+EOF
+}
+
+resource "aws_iam_role_policy_attachment" "end_session_kms_attachment" {
+  role       = module.end_session.execution_role
+  policy_arn = aws_iam_policy.end_session_kms.arn
+
+  depends_on = [aws_iam_policy.end_session_kms, module.end_session]
+}
+
+module "end_session_dev" {
+  source = "../../lambda/alias"
+
+  name                      = "dev"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.end_session.function_arn
+  function_name             = module.end_session.function_name
+}
+
+module "end_session_test" {
+  source = "../../lambda/alias"
+
+  name                      = "test"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.end_session.function_arn
+  function_name             = module.end_session.function_name
+}
+
+module "end_session_prod" {
+  source = "../../lambda/alias"
+
+  name                      = "prod"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.end_session.function_arn
+  function_name             = module.end_session.function_name
+}
\ No newline at end of file
@@ -0,0 +1,79 @@
+resource "aws_api_gateway_resource" "introspect_proxy" {
+  rest_api_id = var.api_gateway_id
+  parent_id   = var.root_resource_id
+  path_part   = "introspect"
+}
+
+module "introspect" {
+  source = "../../lambda/endpoint"
+
+  name        = "introspect"
+  http_method = "POST"
+
+  aws_account_id = var.aws_account_id
+  api_gateway_id   = var.api_gateway_id
+  root_resource_id = aws_api_gateway_resource.introspect_proxy.id
+  s3_bucket        = var.s3_bucket
+  aws_region       = var.aws_region
+
+  iam_policies = ["arn:aws:iam::aws:policy/AmazonDynamoDBFullAccess"]
+
+  depends_on = [
+    aws_api_gateway_resource.introspect_proxy
+  ]
+}
+
+resource "aws_iam_policy" "introspect_kms" {
+  name        = "introspect-kms"
+  path        = "/"
+  description = "IAM policy for kms for introspect"
+
+  policy = <<EOF
+{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Effect": "Allow",
+      "Action": [
+          "kms:Verify"
+      ],
+      "Resource": "arn:aws:kms:${var.aws_region}:${var.aws_account_id}:key/*"
+    }
+  ]
+}
+EOF
+}
+
+resource "aws_iam_role_policy_attachment" "introspect_kms_attachment" {
+  role       = module.introspect.execution_role
+  policy_arn = aws_iam_policy.introspect_kms.arn
+
+  depends_on = [aws_iam_policy.introspect_kms, module.introspect]
+}
+
+module "introspect_dev" {
+  source = "../../lambda/alias"
+
+  name                      = "dev"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.introspect.function_arn
+  function_name             = module.introspect.function_name
+}
+
+module "introspect_test" {
+  source = "../../lambda/alias"
+
+  name                      = "test"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.introspect.function_arn
+  function_name             = module.introspect.function_name
+}
+
+module "introspect_prod" {
+  source = "../../lambda/alias"
+
+  name                      = "prod"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.introspect.function_arn
+  function_name             = module.introspect.function_name
+}
\ No newline at end of file
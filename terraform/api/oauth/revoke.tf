@@ -0,0 +1,79 @@
+resource "aws_api_gateway_resource" "revoke_proxy" {
+  rest_api_id = var.api_gateway_id
+  parent_id   = var.root_resource_id
+  path_part   = "revoke"
+}
+
+module "revoke" {
+  source = "../../lambda/endpoint"
+
+  name        = "revoke"
+  http_method = "POST"
+
+  aws_account_id = var.aws_account_id
+  api_gateway_id   = var.api_gateway_id
+  root_resource_id = aws_api_gateway_resource.revoke_proxy.id
+  s3_bucket        = var.s3_bucket
+  aws_region       = var.aws_region
+
+  iam_policies = ["arn:aws:iam::aws:policy/AmazonDynamoDBFullAccess"]
+
+  depends_on = [
+    aws_api_gateway_resource.revoke_proxy
+  ]
+}
+
+resource "aws_iam_policy" "revoke_kms" {
+  name        = "revoke-kms"
+  path        = "/"
+  description = "IAM policy for kms for revoke"
+
+  policy = <<EOF
+{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Effect": "Allow",
+      "Action": [
+          "kms:Verify"
+      ],
+      "Resource": "arn:aws:kms:${var.aws_region}:${var.aws_account_id}:key/*"
+    }
+  ]
+}
+EOF
+}
+
+resource "aws_iam_role_policy_attachment" "revoke_kms_attachment" {
+  role       = module.revoke.execution_role
+  policy_arn = aws_iam_policy.revoke_kms.arn
+
+  depends_on = [aws_iam_policy.revoke_kms, module.revoke]
+}
+
+module "revoke_dev" {
+  source = "../../lambda/alias"
+
+  name                      = "dev"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.revoke.function_arn
+  function_name             = module.revoke.function_name
+}
+
+module "revoke_test" {
+  source = "../../lambda/alias"
+
+  name                      = "test"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.revoke.function_arn
+  function_name             = module.revoke.function_name
+}
+
+module "revoke_prod" {
+  source = "../../lambda/alias"
+
+  name                      = "prod"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.revoke.function_arn
+  function_name             = module.revoke.function_name
+}
\ No newline at end of file
@@ -0,0 +1,80 @@
+resource "aws_api_gateway_resource" "userinfo_proxy" {
+  rest_api_id = var.api_gateway_id
+  parent_id   = var.root_resource_id
+  path_part   = "userinfo"
+}
+
+module "userinfo" {
+  source = "../../lambda/endpoint"
+
+  name        = "userinfo"
+  http_method = "ANY"
+
+  aws_account_id = var.aws_account_id
+  api_gateway_id   = var.api_gateway_id
+  root_resource_id = aws_api_gateway_resource.userinfo_proxy.id
+  s3_bucket        = var.s3_bucket
+  aws_region       = var.aws_region
+
+  iam_policies = ["arn:aws:iam::aws:policy/AmazonDynamoDBFullAccess"]
+
+  depends_on = [
+    aws_api_gateway_resource.userinfo_proxy
+  ]
+}
+
+resource "aws_iam_policy" "userinfo_kms" {
+  name        = "userinfo-kms"
+  path        = "/"
+  description = "IAM policy for kms for userinfo"
+
+  policy = <<EOF
+{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Effect": "Allow",
+      "Action": [
+          "kms:Verify",
+          "kms:Sign"
+      ],
+      "Resource": "arn:aws:kms:${var.aws_region}:${var.aws_account_id}:key/*"
+    }
+  ]
+}
+EOF
+}
+
+resource "aws_iam_role_policy_attachment" "userinfo_kms_attachment" {
+  role       = module.userinfo.execution_role
+  policy_arn = aws_iam_policy.userinfo_kms.arn
+
+  depends_on = [aws_iam_policy.userinfo_kms, module.userinfo]
+}
+
+module "userinfo_dev" {
+  source = "../../lambda/alias"
+
+  name                      = "dev"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.userinfo.function_arn
+  function_name             = module.userinfo.function_name
+}
+
+module "userinfo_test" {
+  source = "../../lambda/alias"
+
+  name                      = "test"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.userinfo.function_arn
+  function_name             = module.userinfo.function_name
+}
+
+module "userinfo_prod" {
+  source = "../../lambda/alias"
+
+  name                      = "prod"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.userinfo.function_arn
+  function_name             = module.userinfo.function_name
+}
\ No newline at end of file
@@ -7,3 +7,8 @@ resource "aws_kms_key" "jwt" {
     stage = var.name
   }
 }
+
+# The key used to encrypt and sign session cookies.
+resource "random_id" "session" {
+  byte_length = 32
+}
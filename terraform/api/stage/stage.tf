@@ -4,11 +4,21 @@ resource "aws_api_gateway_stage" "stage" {
   stage_name    = var.name
 
   variables = {
-    ENVIRONMENT        = var.name
-    CLIENTS_TABLE_NAME = "goidc-clients-${var.name}"
-    USERS_TABLE_NAME   = "goidc-users-${var.name}"
-    JWT_KEY_ID         = aws_kms_key.jwt.key_id
-    UI_BUCKET          = var.ui_bucket
+    ENVIRONMENT                    = var.name
+    CLIENTS_TABLE_NAME             = "goidc-clients-${var.name}"
+    USERS_TABLE_NAME               = "goidc-users-${var.name}"
+    AUTHORIZATION_CODES_TABLE_NAME = "goidc-authorization-codes-${var.name}"
+    REFRESH_TOKENS_TABLE_NAME      = "goidc-refresh-tokens-${var.name}"
+    REVOKED_TOKENS_TABLE_NAME      = "goidc-revoked-tokens-${var.name}"
+    DEVICE_CODES_TABLE_NAME        = "goidc-device-codes-${var.name}"
+    USED_ASSERTIONS_TABLE_NAME     = "goidc-used-assertions-${var.name}"
+    TRUSTED_ISSUERS_TABLE_NAME     = "goidc-trusted-issuers-${var.name}"
+    SESSIONS_TABLE_NAME            = "goidc-sessions-${var.name}"
+    CONSENTS_TABLE_NAME            = "goidc-consents-${var.name}"
+    FAILED_ATTEMPTS_TABLE_NAME     = "goidc-failed-attempts-${var.name}"
+    JWT_KEY_ID                     = aws_kms_key.jwt.key_id
+    SESSION_KEY                    = random_id.session.b64_std
+    UI_BUCKET                      = var.ui_bucket
   }
 
   lifecycle {
@@ -0,0 +1,79 @@
+resource "aws_api_gateway_resource" "connected_apps_proxy" {
+  rest_api_id = var.api_gateway_id
+  parent_id   = aws_api_gateway_resource.users_proxy.id
+  path_part   = "connected-apps"
+}
+
+module "connected_apps" {
+  source = "../../lambda/endpoint"
+
+  name        = "connected-apps"
+  http_method = "ANY"
+
+  aws_account_id = var.aws_account_id
+  api_gateway_id   = var.api_gateway_id
+  root_resource_id = aws_api_gateway_resource.connected_apps_proxy.id
+  s3_bucket        = var.s3_bucket
+  aws_region       = var.aws_region
+
+  iam_policies = ["arn:aws:iam::aws:policy/AmazonDynamoDBFullAccess"]
+
+  depends_on = [
+    aws_api_gateway_resource.connected_apps_proxy
+  ]
+}
+
+resource "aws_iam_policy" "connected_apps_kms" {
+  name        = "connected-apps-kms"
+  path        = "/"
+  description = "IAM policy for kms for connected-apps"
+
+  policy = <<EOF
+{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Effect": "Allow",
+      "Action": [
+          "kms:Verify"
+      ],
+      "Resource": "arn:aws:kms:${var.aws_region}:${var.aws_account_id}:key/*"
+    }
+  ]
+}
+EOF
+}
+
+resource "aws_iam_role_policy_attachment" "connected_apps_kms_attachment" {
+  role       = module.connected_apps.execution_role
+  policy_arn = aws_iam_policy.connected_apps_kms.arn
+
+  depends_on = [aws_iam_policy.connected_apps_kms, module.connected_apps]
+}
+
+module "connected_apps_dev" {
+  source = "../../lambda/alias"
+
+  name                      = "dev"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.connected_apps.function_arn
+  function_name             = module.connected_apps.function_name
+}
+
+module "connected_apps_test" {
+  source = "../../lambda/alias"
+
+  name                      = "test"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.connected_apps.function_arn
+  function_name             = module.connected_apps.function_name
+}
+
+module "connected_apps_prod" {
+  source = "../../lambda/alias"
+
+  name                      = "prod"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.connected_apps.function_arn
+  function_name             = module.connected_apps.function_name
+}
\ No newline at end of file
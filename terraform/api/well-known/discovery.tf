@@ -0,0 +1,41 @@
+module "discovery" {
+  source = "../../lambda/endpoint"
+
+  name        = "discovery"
+  http_method = "GET"
+
+  aws_account_id = var.aws_account_id
+  api_gateway_id   = var.api_gateway_id
+  root_resource_id = aws_api_gateway_resource.openid_configuration_proxy.id
+  s3_bucket        = var.s3_bucket
+  aws_region       = var.aws_region
+
+  depends_on = [aws_api_gateway_resource.openid_configuration_proxy]
+}
+
+module "discovery_dev" {
+  source = "../../lambda/alias"
+
+  name                      = "dev"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.discovery.function_arn
+  function_name             = module.discovery.function_name
+}
+
+module "discovery_test" {
+  source = "../../lambda/alias"
+
+  name                      = "test"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.discovery.function_arn
+  function_name             = module.discovery.function_name
+}
+
+module "discovery_prod" {
+  source = "../../lambda/alias"
+
+  name                      = "prod"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.discovery.function_arn
+  function_name             = module.discovery.function_name
+}
@@ -0,0 +1,75 @@
+resource "aws_api_gateway_resource" "jwks_proxy" {
+  rest_api_id = var.api_gateway_id
+  parent_id   = var.root_resource_id
+  path_part   = "jwks.json"
+}
+
+module "jwks" {
+  source = "../../lambda/endpoint"
+
+  name        = "jwks"
+  http_method = "GET"
+
+  aws_account_id = var.aws_account_id
+  api_gateway_id   = var.api_gateway_id
+  root_resource_id = aws_api_gateway_resource.jwks_proxy.id
+  s3_bucket        = var.s3_bucket
+  aws_region       = var.aws_region
+
+  depends_on = [aws_api_gateway_resource.jwks_proxy]
+}
+
+resource "aws_iam_policy" "jwks_kms" {
+  name        = "jwks-kms"
+  path        = "/"
+  description = "IAM policy for kms for jwks"
+
+  policy = <<EOF
+{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Effect": "Allow",
+      "Action": [
+          "kms:GetPublicKey"
+      ],
+      "Resource": "arn:aws:kms:${var.aws_region}:${var.aws_account_id}:key/*"
+    }
+  ]
+}
+EOF
+}
+
+resource "aws_iam_role_policy_attachment" "jwks_kms_attachment" {
+  role       = module.jwks.execution_role
+  policy_arn = aws_iam_policy.jwks_kms.arn
+
+  depends_on = [aws_iam_policy.jwks_kms, module.jwks]
+}
+
+module "jwks_dev" {
+  source = "../../lambda/alias"
+
+  name                      = "dev"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.jwks.function_arn
+  function_name             = module.jwks.function_name
+}
+
+module "jwks_test" {
+  source = "../../lambda/alias"
+
+  name                      = "test"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.jwks.function_arn
+  function_name             = module.jwks.function_name
+}
+
+module "jwks_prod" {
+  source = "../../lambda/alias"
+
+  name                      = "prod"
+  api_gateway_execution_arn = var.api_gateway_execution_arn
+  function_arn              = module.jwks.function_arn
+  function_name             = module.jwks.function_name
+}
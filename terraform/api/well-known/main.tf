@@ -0,0 +1,5 @@
+resource "aws_api_gateway_resource" "openid_configuration_proxy" {
+  rest_api_id = var.api_gateway_id
+  parent_id   = var.root_resource_id
+  path_part   = "openid-configuration"
+}
@@ -0,0 +1,17 @@
+resource "aws_dynamodb_table" "authorization-codes-table" {
+  name           = "goidc-authorization-codes-${var.ENV}"
+  billing_mode   = "PROVISIONED"
+  read_capacity  = 20
+  write_capacity = 20
+  hash_key       = "code"
+
+  attribute {
+    name = "code"
+    type = "S"
+  }
+
+  ttl {
+    attribute_name = "expires"
+    enabled        = true
+  }
+}
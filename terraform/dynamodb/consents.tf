@@ -0,0 +1,18 @@
+resource "aws_dynamodb_table" "consents-table" {
+  name           = "goidc-consents-${var.ENV}"
+  billing_mode   = "PROVISIONED"
+  read_capacity  = 20
+  write_capacity = 20
+  hash_key       = "userId"
+  range_key      = "clientId"
+
+  attribute {
+    name = "userId"
+    type = "S"
+  }
+
+  attribute {
+    name = "clientId"
+    type = "S"
+  }
+}
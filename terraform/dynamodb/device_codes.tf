@@ -0,0 +1,30 @@
+resource "aws_dynamodb_table" "device-codes-table" {
+  name           = "goidc-device-codes-${var.ENV}"
+  billing_mode   = "PROVISIONED"
+  read_capacity  = 20
+  write_capacity = 20
+  hash_key       = "deviceCode"
+
+  attribute {
+    name = "deviceCode"
+    type = "S"
+  }
+
+  attribute {
+    name = "userCode"
+    type = "S"
+  }
+
+  global_secondary_index {
+    name            = "userCode-index"
+    hash_key        = "userCode"
+    read_capacity   = 20
+    write_capacity  = 20
+    projection_type = "ALL"
+  }
+
+  ttl {
+    attribute_name = "expires"
+    enabled        = true
+  }
+}
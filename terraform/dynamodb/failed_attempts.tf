@@ -0,0 +1,17 @@
+resource "aws_dynamodb_table" "failed-attempts-table" {
+  name           = "goidc-failed-attempts-${var.ENV}"
+  billing_mode   = "PROVISIONED"
+  read_capacity  = 20
+  write_capacity = 20
+  hash_key       = "id"
+
+  attribute {
+    name = "id"
+    type = "S"
+  }
+
+  ttl {
+    attribute_name = "expires"
+    enabled        = true
+  }
+}
@@ -0,0 +1,49 @@
+resource "aws_dynamodb_table" "refresh-tokens-table" {
+  name           = "goidc-refresh-tokens-${var.ENV}"
+  billing_mode   = "PROVISIONED"
+  read_capacity  = 20
+  write_capacity = 20
+  hash_key       = "id"
+
+  attribute {
+    name = "id"
+    type = "S"
+  }
+
+  attribute {
+    name = "familyId"
+    type = "S"
+  }
+
+  attribute {
+    name = "userId"
+    type = "S"
+  }
+
+  attribute {
+    name = "clientId"
+    type = "S"
+  }
+
+  global_secondary_index {
+    name            = "familyId-index"
+    hash_key        = "familyId"
+    read_capacity   = 20
+    write_capacity  = 20
+    projection_type = "KEYS_ONLY"
+  }
+
+  global_secondary_index {
+    name            = "userId-clientId-index"
+    hash_key        = "userId"
+    range_key       = "clientId"
+    read_capacity   = 20
+    write_capacity  = 20
+    projection_type = "KEYS_ONLY"
+  }
+
+  ttl {
+    attribute_name = "expires"
+    enabled        = true
+  }
+}
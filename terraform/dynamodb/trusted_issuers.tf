@@ -0,0 +1,12 @@
+resource "aws_dynamodb_table" "trusted-issuers-table" {
+  name           = "goidc-trusted-issuers-${var.ENV}"
+  billing_mode   = "PROVISIONED"
+  read_capacity  = 20
+  write_capacity = 20
+  hash_key       = "issuer"
+
+  attribute {
+    name = "issuer"
+    type = "S"
+  }
+}
@@ -0,0 +1,41 @@
+package token
+
+import (
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/reecerussell/gojwt"
+	"github.com/reecerussell/gojwt/kms"
+
+	"github.com/reecerussell/goidc/jwk"
+)
+
+// Algorithm wraps a gojwt.Algorithm with the id of the key it signs
+// with, so that tokens can be issued with a "kid" header, which
+// resource servers use to select the key from the JWKS.
+type Algorithm struct {
+	gojwt.Algorithm
+	kid string
+}
+
+// NewAlgorithm returns a new instance of Algorithm, where keyID is
+// the id of the key in KMS.
+func NewAlgorithm(alg gojwt.Algorithm, keyID string) *Algorithm {
+	return &Algorithm{
+		Algorithm: alg,
+		kid:       jwk.KeyID(keyID),
+	}
+}
+
+// NewKMSAlgorithm returns an Algorithm which signs using the given KMS key.
+func NewKMSAlgorithm(sess *session.Session, keyID string) (*Algorithm, error) {
+	alg, err := kms.New(sess, keyID, kms.RSA_PKCS1_S256)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAlgorithm(alg, keyID), nil
+}
+
+// KeyID returns the "kid" of the algorithm's key.
+func (a *Algorithm) KeyID() string {
+	return a.kid
+}
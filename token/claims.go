@@ -0,0 +1,19 @@
+package token
+
+// Scopes returns the "scopes" claim of a verified token. As the claims
+// are decoded from JSON, the claim will be a []interface{}.
+func Scopes(claims map[string]interface{}) []string {
+	values, ok := claims["scopes"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	scopes := make([]string, 0, len(values))
+	for _, v := range values {
+		if scope, ok := v.(string); ok {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return scopes
+}
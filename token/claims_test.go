@@ -0,0 +1,19 @@
+package token
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopes_GivenDecodedClaim_ReturnsScopes(t *testing.T) {
+	claims := map[string]interface{}{
+		"scopes": []interface{}{"openid", "email"},
+	}
+
+	assert.Equal(t, []string{"openid", "email"}, Scopes(claims))
+}
+
+func TestScopes_WhereClaimIsMissing_ReturnsNil(t *testing.T) {
+	assert.Nil(t, Scopes(map[string]interface{}{}))
+}
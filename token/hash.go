@@ -0,0 +1,44 @@
+package token
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"hash"
+	"strings"
+
+	"github.com/reecerussell/gojwt"
+)
+
+// ErrUnsupportedHashAlgorithm is returned by Hash when the hash function
+// of the signing algorithm cannot be determined.
+var ErrUnsupportedHashAlgorithm = errors.New("unsupported hash algorithm")
+
+// Hash returns the value of an ID token hash claim, such as at_hash or c_hash,
+// for value, as defined by OpenID Connect Core. This is the base64url encoding
+// of the left-most half of the hash of value, using the hash function of the
+// algorithm the ID token is signed with, e.g. SHA-256 for RS256.
+func Hash(alg gojwt.Algorithm, value string) (string, error) {
+	name, err := alg.Name()
+	if err != nil {
+		return "", err
+	}
+
+	var h hash.Hash
+	switch {
+	case strings.HasSuffix(name, "256"):
+		h = sha256.New()
+	case strings.HasSuffix(name, "384"):
+		h = sha512.New384()
+	case strings.HasSuffix(name, "512"):
+		h = sha512.New()
+	default:
+		return "", ErrUnsupportedHashAlgorithm
+	}
+
+	h.Write([]byte(value))
+	sum := h.Sum(nil)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2]), nil
+}
@@ -0,0 +1,39 @@
+package token
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHash(t *testing.T) {
+	alg := NewHMACAlgorithm([]byte("shared key"))
+
+	// Taken from OpenID Connect Core, Appendix A.
+	t.Run("Given Access Token", func(t *testing.T) {
+		hash, err := Hash(alg, "jHkWEdUXMU1BwAsC4vtUsZwnNvTIxEl0z9K3vx5KF0Y")
+		assert.NoError(t, err)
+		assert.Equal(t, "77QmUPtjPfzWtF2AnpK9RQ", hash)
+	})
+
+	t.Run("Given Code", func(t *testing.T) {
+		hash, err := Hash(alg, "Qcb0Orv1zh30vL1MPRsbm-diHiMwcLyZvn1arpZv-Jxf_11jnpEX3Tgfvk")
+		assert.NoError(t, err)
+		assert.Equal(t, "LDktKdoQak3Pk0cnXxCltA", hash)
+	})
+}
+
+func TestHash_GivenUnsupportedAlgorithm_ReturnsError(t *testing.T) {
+	hash, err := Hash(testAlgorithm{"EdDSA"}, "value")
+	assert.Equal(t, "", hash)
+	assert.Equal(t, ErrUnsupportedHashAlgorithm, err)
+}
+
+type testAlgorithm struct {
+	name string
+}
+
+func (a testAlgorithm) Name() (string, error)             { return a.name, nil }
+func (testAlgorithm) Sign([]byte) ([]byte, error)         { return nil, nil }
+func (testAlgorithm) Verify([]byte, []byte) (bool, error) { return false, nil }
+func (testAlgorithm) Size() (int, error)                  { return 0, nil }
@@ -0,0 +1,41 @@
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+
+	"github.com/reecerussell/gojwt"
+)
+
+// hmacAlgorithm is an implementation of gojwt.Algorithm, which signs
+// and verifies tokens using HMAC SHA-256 with a shared key.
+type hmacAlgorithm struct {
+	key []byte
+}
+
+// NewHMACAlgorithm returns a gojwt.Algorithm for HS256, using key. This is
+// used to verify client assertions signed with a key shared with the client.
+func NewHMACAlgorithm(key []byte) gojwt.Algorithm {
+	return &hmacAlgorithm{key: key}
+}
+
+func (*hmacAlgorithm) Name() (string, error) {
+	return "HS256", nil
+}
+
+func (a *hmacAlgorithm) Sign(data []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write(data)
+
+	return mac.Sum(nil), nil
+}
+
+func (a *hmacAlgorithm) Verify(data, signature []byte) (bool, error) {
+	expected, _ := a.Sign(data)
+
+	return hmac.Equal(expected, signature), nil
+}
+
+func (*hmacAlgorithm) Size() (int, error) {
+	return sha256.Size, nil
+}
@@ -0,0 +1,30 @@
+package token
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHMACAlgorithm_SignAndVerify(t *testing.T) {
+	alg := NewHMACAlgorithm([]byte("shared key"))
+
+	name, _ := alg.Name()
+	assert.Equal(t, "HS256", name)
+
+	size, _ := alg.Size()
+	assert.Equal(t, 32, size)
+
+	sig, err := alg.Sign([]byte("data"))
+	assert.NoError(t, err)
+
+	valid, err := alg.Verify([]byte("data"), sig)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, _ = alg.Verify([]byte("other data"), sig)
+	assert.False(t, valid)
+
+	valid, _ = NewHMACAlgorithm([]byte("other key")).Verify([]byte("data"), sig)
+	assert.False(t, valid)
+}
@@ -0,0 +1,13 @@
+package mock
+
+import gomock "github.com/golang/mock/gomock"
+
+// NewIssuerService returns a new MockService, for handlers which bind their
+// token service to the request's issuer. WithIssuer must be called with
+// issuer, which may be a gomock.Matcher, and returns the same mock, so
+// expectations set on it apply to the bound service.
+func NewIssuerService(ctrl *gomock.Controller, issuer interface{}) *MockService {
+	m := NewMockService(ctrl)
+	m.EXPECT().WithIssuer(issuer).Return(m).AnyTimes()
+	return m
+}
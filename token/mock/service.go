@@ -6,6 +6,7 @@ package mock
 
 import (
 	gomock "github.com/golang/mock/gomock"
+	jwk "github.com/reecerussell/goidc/jwk"
 	token "github.com/reecerussell/goidc/token"
 	gojwt "github.com/reecerussell/gojwt"
 	reflect "reflect"
@@ -48,3 +49,92 @@ func (mr *MockServiceMockRecorder) GenerateToken(alg, claims, expirySeconds, aud
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockService)(nil).GenerateToken), alg, claims, expirySeconds, audience)
 }
+
+// VerifyAssertion mocks base method.
+func (m *MockService) VerifyAssertion(alg gojwt.Algorithm, token, issuer, audience string) (gojwt.Claims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyAssertion", alg, token, issuer, audience)
+	ret0, _ := ret[0].(gojwt.Claims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyAssertion indicates an expected call of VerifyAssertion.
+func (mr *MockServiceMockRecorder) VerifyAssertion(alg, token, issuer, audience interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyAssertion", reflect.TypeOf((*MockService)(nil).VerifyAssertion), alg, token, issuer, audience)
+}
+
+// VerifyAssertionWithKeySet mocks base method.
+func (m *MockService) VerifyAssertionWithKeySet(set *jwk.Set, token, issuer, audience string) (gojwt.Claims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyAssertionWithKeySet", set, token, issuer, audience)
+	ret0, _ := ret[0].(gojwt.Claims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyAssertionWithKeySet indicates an expected call of VerifyAssertionWithKeySet.
+func (mr *MockServiceMockRecorder) VerifyAssertionWithKeySet(set, token, issuer, audience interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyAssertionWithKeySet", reflect.TypeOf((*MockService)(nil).VerifyAssertionWithKeySet), set, token, issuer, audience)
+}
+
+// VerifyToken mocks base method.
+func (m *MockService) VerifyToken(alg gojwt.Algorithm, token, audience string) (gojwt.Claims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyToken", alg, token, audience)
+	ret0, _ := ret[0].(gojwt.Claims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyToken indicates an expected call of VerifyToken.
+func (mr *MockServiceMockRecorder) VerifyToken(alg, token, audience interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyToken", reflect.TypeOf((*MockService)(nil).VerifyToken), alg, token, audience)
+}
+
+// VerifyTokenIgnoringExpiry mocks base method.
+func (m *MockService) VerifyTokenIgnoringExpiry(alg gojwt.Algorithm, token, audience string) (gojwt.Claims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyTokenIgnoringExpiry", alg, token, audience)
+	ret0, _ := ret[0].(gojwt.Claims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyTokenIgnoringExpiry indicates an expected call of VerifyTokenIgnoringExpiry.
+func (mr *MockServiceMockRecorder) VerifyTokenIgnoringExpiry(alg, token, audience interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyTokenIgnoringExpiry", reflect.TypeOf((*MockService)(nil).VerifyTokenIgnoringExpiry), alg, token, audience)
+}
+
+// VerifyTokenWithKeySet mocks base method.
+func (m *MockService) VerifyTokenWithKeySet(set *jwk.Set, token, audience string) (gojwt.Claims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyTokenWithKeySet", set, token, audience)
+	ret0, _ := ret[0].(gojwt.Claims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyTokenWithKeySet indicates an expected call of VerifyTokenWithKeySet.
+func (mr *MockServiceMockRecorder) VerifyTokenWithKeySet(set, token, audience interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyTokenWithKeySet", reflect.TypeOf((*MockService)(nil).VerifyTokenWithKeySet), set, token, audience)
+}
+
+// WithIssuer mocks base method.
+func (m *MockService) WithIssuer(issuer string) token.Service {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithIssuer", issuer)
+	ret0, _ := ret[0].(token.Service)
+	return ret0
+}
+
+// WithIssuer indicates an expected call of WithIssuer.
+func (mr *MockServiceMockRecorder) WithIssuer(issuer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithIssuer", reflect.TypeOf((*MockService)(nil).WithIssuer), issuer)
+}
@@ -1,26 +1,75 @@
 package token
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
 	"time"
 
+	"github.com/reecerussell/goidc/jwk"
 	"github.com/reecerussell/goidc/util"
 
 	"github.com/reecerussell/gojwt"
 )
 
+// DefaultClockSkew is the leeway given when validating the lifetime
+// of a token, to allow for clock drift between servers.
+const DefaultClockSkew = 30 * time.Second
+
 // Service is a high level interface used to generate and
 // verify JSON-Web Tokens.
 type Service interface {
 	GenerateToken(alg gojwt.Algorithm, claims map[string]interface{}, expirySeconds int64, audience string) (*Token, error)
+
+	// VerifyToken verifies the signature and lifetime of token, using alg,
+	// and ensures it was issued by this service, for the given audience.
+	VerifyToken(alg gojwt.Algorithm, token, audience string) (gojwt.Claims, error)
+
+	// VerifyTokenWithKeySet is the same as VerifyToken, but verifies the
+	// signature using the key in set, identified by the token's "kid" header.
+	VerifyTokenWithKeySet(set *jwk.Set, token, audience string) (gojwt.Claims, error)
+
+	// VerifyTokenIgnoringExpiry is the same as VerifyToken, but accepts tokens
+	// which have expired, such as an ID token given as a hint of the user.
+	VerifyTokenIgnoringExpiry(alg gojwt.Algorithm, token, audience string) (gojwt.Claims, error)
+
+	// VerifyAssertion is the same as VerifyToken, but for JWT assertions which
+	// were issued by issuer rather than this service, such as client assertions.
+	VerifyAssertion(alg gojwt.Algorithm, token, issuer, audience string) (gojwt.Claims, error)
+
+	// VerifyAssertionWithKeySet is the same as VerifyAssertion, but verifies the
+	// signature using the key in set, identified by the token's "kid" header.
+	VerifyAssertionWithKeySet(set *jwk.Set, token, issuer, audience string) (gojwt.Claims, error)
+
+	// WithIssuer returns a copy of the Service, which issues and verifies
+	// tokens as issuer. This is used as the issuer depends on the request.
+	WithIssuer(issuer string) Service
 }
 
 type service struct {
-	issuer string
+	issuer    string
+	clockSkew time.Duration
 }
 
+// New returns a new instance of Service, which issues tokens as tokenIssuer
+// and validates token lifetimes with DefaultClockSkew.
 func New(tokenIssuer string) Service {
+	return NewWithClockSkew(tokenIssuer, DefaultClockSkew)
+}
+
+// NewWithClockSkew returns a new instance of Service, which allows for the
+// given clock skew when validating the exp, nbf and iat claims.
+func NewWithClockSkew(tokenIssuer string, clockSkew time.Duration) Service {
 	return &service{
-		issuer: tokenIssuer,
+		issuer:    tokenIssuer,
+		clockSkew: clockSkew,
+	}
+}
+
+func (s *service) WithIssuer(issuer string) Service {
+	return &service{
+		issuer:    issuer,
+		clockSkew: s.clockSkew,
 	}
 }
 
@@ -28,14 +77,20 @@ func (s *service) GenerateToken(alg gojwt.Algorithm, claims map[string]interface
 	now := util.Time()
 	expiry := now.Add(time.Duration(expirySeconds) * time.Second)
 
-	builder, _ := gojwt.New(alg)
-	jwt, err := builder.AddClaims(claims).
-		AddClaim("iss", s.issuer).
-		AddClaim("aud", audience).
-		SetExpiry(expiry).
-		SetIssuedAt(now).
-		SetNotBefore(now).
-		Build()
+	payload := make(map[string]interface{}, len(claims)+6)
+	for name, value := range claims {
+		payload[name] = value
+	}
+
+	// Each token is given a unique id, so that it can be revoked.
+	payload["jti"] = util.RandomString(16)
+	payload["iss"] = s.issuer
+	payload["aud"] = audience
+	payload[gojwt.ExpiryClaim] = timestamp(expiry)
+	payload[gojwt.IssuedAtClaim] = timestamp(now)
+	payload[gojwt.NotBeforeClaim] = timestamp(now)
+
+	jwt, err := build(alg, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -46,3 +101,63 @@ func (s *service) GenerateToken(alg gojwt.Algorithm, claims map[string]interface
 		Expires:     expirySeconds,
 	}, nil
 }
+
+// header is the JOSE header of a token. This is used over gojwt's
+// header, as it has no way of setting the "kid" parameter.
+type header struct {
+	Type  string `json:"typ"`
+	Alg   string `json:"alg"`
+	KeyID string `json:"kid,omitempty"`
+}
+
+// build encodes and signs a token using alg. If alg implements
+// KeyID() string, the token will be given a "kid" header.
+func build(alg gojwt.Algorithm, claims map[string]interface{}) (string, error) {
+	name, err := alg.Name()
+	if err != nil {
+		return "", err
+	}
+
+	h := header{
+		Type: "JWT",
+		Alg:  name,
+	}
+
+	if k, ok := alg.(interface{ KeyID() string }); ok {
+		h.KeyID = k.KeyID()
+	}
+
+	headerBytes, _ := json.Marshal(h)
+	claimBytes, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	sigLen, err := alg.Size()
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.Grow(base64.RawURLEncoding.EncodedLen(len(headerBytes)) +
+		base64.RawURLEncoding.EncodedLen(len(claimBytes)) +
+		base64.RawURLEncoding.EncodedLen(sigLen) + 2)
+
+	sb.WriteString(base64.RawURLEncoding.EncodeToString(headerBytes))
+	sb.WriteByte('.')
+	sb.WriteString(base64.RawURLEncoding.EncodeToString(claimBytes))
+
+	sig, err := alg.Sign([]byte(sb.String()))
+	if err != nil {
+		return "", err
+	}
+
+	sb.WriteByte('.')
+	sb.WriteString(base64.RawURLEncoding.EncodeToString(sig))
+
+	return sb.String(), nil
+}
+
+func timestamp(t time.Time) float64 {
+	return float64(t.UnixNano() / 1e9)
+}
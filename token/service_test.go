@@ -2,7 +2,10 @@ package token
 
 import (
 	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,6 +14,7 @@ import (
 	"github.com/reecerussell/gojwt/mock"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/reecerussell/goidc/jwk"
 	"github.com/reecerussell/goidc/util"
 )
 
@@ -47,12 +51,40 @@ func TestGenerateToken(t *testing.T) {
 	assert.Equal(t, testIssuer, jwt.Claims["iss"])
 	assert.Equal(t, "bar", jwt.Claims["foo"])
 	assert.Equal(t, float64(1), jwt.Claims["one"])
+	assert.NotEmpty(t, jwt.Claims["jti"])
 
 	assert.Equal(t, float64(util.Time().UnixNano()/1e9), jwt.Claims["iat"])
 	assert.Equal(t, float64(util.Time().UnixNano()/1e9), jwt.Claims["nbf"])
 	assert.Equal(t, float64(util.Time().Add(time.Duration(testExpirySeconds)*time.Second).UnixNano()/1e9), jwt.Claims["exp"])
 }
 
+func TestGenerateToken_GivesEachTokenAUniqueId(t *testing.T) {
+	alg := newTestAlgorithm(t)
+	svc := New("test")
+
+	first, _ := svc.GenerateToken(alg, nil, 3600, "testing")
+	second, _ := svc.GenerateToken(alg, nil, 3600, "testing")
+
+	firstJwt, _ := gojwt.Token(first.AccessToken)
+	secondJwt, _ := gojwt.Token(second.AccessToken)
+	assert.NotEqual(t, firstJwt.Claims["jti"], secondJwt.Claims["jti"])
+}
+
+func TestWithIssuer_IssuesAndVerifiesTokensAsIssuer(t *testing.T) {
+	alg := newTestAlgorithm(t)
+	svc := New("test").WithIssuer("https://example.com/prod")
+
+	token, err := svc.GenerateToken(alg, nil, 3600, "testing")
+	assert.NoError(t, err)
+
+	claims, err := svc.VerifyToken(alg, token.AccessToken, "testing")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/prod", claims["iss"])
+
+	_, err = New("test").VerifyToken(alg, token.AccessToken, "testing")
+	assert.Equal(t, ErrInvalidIssuer, err)
+}
+
 func TestGenerateToken_WhereTheAlgorithmFails_ReturnsError(t *testing.T) {
 	testError := errors.New("test error")
 	testIssuer := "test"
@@ -76,3 +108,46 @@ func TestGenerateToken_WhereTheAlgorithmFails_ReturnsError(t *testing.T) {
 	assert.Nil(t, token)
 	assert.Equal(t, testError, err)
 }
+
+func TestGenerateToken_GivenAlgorithmWithKeyID_SetsKidHeader(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAlg := mock.NewMockAlgorithm(ctrl)
+	mockAlg.EXPECT().Name().Return("RS256", nil)
+	mockAlg.EXPECT().Size().Return(256, nil)
+	mockAlg.EXPECT().Sign(gomock.Any()).Return(make([]byte, 256), nil)
+
+	alg := NewAlgorithm(mockAlg, "my-key-id")
+
+	svc := New("test")
+	token, err := svc.GenerateToken(alg, nil, 3600, "testing")
+	assert.NoError(t, err)
+
+	headerPart := strings.Split(token.AccessToken, ".")[0]
+	headerBytes, _ := base64.RawURLEncoding.DecodeString(headerPart)
+
+	var h map[string]string
+	json.Unmarshal(headerBytes, &h)
+	assert.Equal(t, "JWT", h["typ"])
+	assert.Equal(t, "RS256", h["alg"])
+	assert.Equal(t, jwk.KeyID("my-key-id"), h["kid"])
+}
+
+func TestGenerateToken_GivenAlgorithmWithoutKeyID_OmitsKidHeader(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAlg := mock.NewMockAlgorithm(ctrl)
+	mockAlg.EXPECT().Name().Return("RS256", nil)
+	mockAlg.EXPECT().Size().Return(256, nil)
+	mockAlg.EXPECT().Sign(gomock.Any()).Return(make([]byte, 256), nil)
+
+	svc := New("test")
+	token, err := svc.GenerateToken(mockAlg, nil, 3600, "testing")
+	assert.NoError(t, err)
+
+	headerPart := strings.Split(token.AccessToken, ".")[0]
+	headerBytes, _ := base64.RawURLEncoding.DecodeString(headerPart)
+	assert.NotContains(t, string(headerBytes), "kid")
+}
@@ -3,7 +3,12 @@ package token
 // Token contains an access token and any relevent data
 // about the token, such as, expiry and type.
 type Token struct {
-	AccessToken string `json:"access_token"`
-	TokenType   string `json:"token_type"`
-	Expires     int64  `json:"expires"`
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	Expires      int64  `json:"expires"`
+	IDToken      string `json:"id_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+
+	// IssuedTokenType is the type of token issued by a token exchange.
+	IssuedTokenType string `json:"issued_token_type,omitempty"`
 }
@@ -0,0 +1,252 @@
+package token
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/reecerussell/gojwt"
+	"github.com/reecerussell/gojwt/rsa"
+
+	"github.com/reecerussell/goidc/jwk"
+	"github.com/reecerussell/goidc/util"
+)
+
+// Verification errors.
+var (
+	ErrMalformedToken   = errors.New("malformed token")
+	ErrInvalidSignature = errors.New("invalid token signature")
+	ErrUnknownKey       = errors.New("unknown token signing key")
+	ErrTokenExpired     = errors.New("token has expired")
+	ErrTokenNotYetValid = errors.New("token is not yet valid")
+	ErrTokenIssuedLater = errors.New("token was issued in the future")
+	ErrInvalidIssuer    = errors.New("invalid token issuer")
+	ErrInvalidAudience  = errors.New("invalid token audience")
+)
+
+// parsed is a token which has been decoded, but not verified.
+type parsed struct {
+	header    header
+	claims    gojwt.Claims
+	data      []byte
+	signature []byte
+}
+
+func (s *service) VerifyToken(alg gojwt.Algorithm, token, audience string) (gojwt.Claims, error) {
+	return s.VerifyAssertion(alg, token, s.issuer, audience)
+}
+
+func (s *service) VerifyTokenWithKeySet(set *jwk.Set, token, audience string) (gojwt.Claims, error) {
+	return s.VerifyAssertionWithKeySet(set, token, s.issuer, audience)
+}
+
+func (s *service) VerifyTokenIgnoringExpiry(alg gojwt.Algorithm, token, audience string) (gojwt.Claims, error) {
+	p, err := parse(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.verify(p, alg, s.issuer, audience, false)
+}
+
+func (s *service) VerifyAssertion(alg gojwt.Algorithm, token, issuer, audience string) (gojwt.Claims, error) {
+	p, err := parse(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.verify(p, alg, issuer, audience, true)
+}
+
+func (s *service) VerifyAssertionWithKeySet(set *jwk.Set, token, issuer, audience string) (gojwt.Claims, error) {
+	p, err := parse(token)
+	if err != nil {
+		return nil, err
+	}
+
+	key := set.Key(p.header.KeyID)
+
+	// A set with a single key doesn't need to be identified by a kid,
+	// which is common for clients which publish their own key sets.
+	if key == nil && p.header.KeyID == "" && len(set.Keys) == 1 {
+		key = set.Keys[0]
+	}
+
+	if key == nil {
+		return nil, ErrUnknownKey
+	}
+
+	alg, err := keyAlgorithm(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.verify(p, alg, issuer, audience, true)
+}
+
+// UnverifiedClaims decodes the claims of token without verifying it. This
+// must only be used to find the key needed to verify the token, such as
+// identifying the client which signed a client assertion.
+func UnverifiedClaims(token string) (gojwt.Claims, error) {
+	p, err := parse(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.claims, nil
+}
+
+// verify verifies the signature and claims of p. The token's expiry is
+// only validated if checkExpiry is true.
+func (s *service) verify(p *parsed, alg gojwt.Algorithm, issuer, audience string, checkExpiry bool) (gojwt.Claims, error) {
+	name, err := alg.Name()
+	if err != nil {
+		return nil, err
+	}
+
+	// The algorithm is chosen by the verifier, not the token, to
+	// prevent a token from downgrading the algorithm, i.e. to "none".
+	if p.header.Alg != name {
+		return nil, ErrInvalidSignature
+	}
+
+	valid, err := alg.Verify(p.data, p.signature)
+	if err != nil {
+		// KMS reports an invalid signature as an error, rather than returning false.
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == kms.ErrCodeKMSInvalidSignatureException {
+			return nil, ErrInvalidSignature
+		}
+
+		return nil, err
+	}
+
+	if !valid {
+		return nil, ErrInvalidSignature
+	}
+
+	err = s.validateClaims(p.claims, issuer, audience, checkExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.claims, nil
+}
+
+func (s *service) validateClaims(claims gojwt.Claims, issuer, audience string, checkExpiry bool) error {
+	now := util.Time()
+
+	if exp, ok := claims.Expiry(); checkExpiry && (!ok || !now.Before(exp.Add(s.clockSkew))) {
+		return ErrTokenExpired
+	}
+
+	if nbf, ok := claims.NotBefore(); ok && now.Add(s.clockSkew).Before(nbf) {
+		return ErrTokenNotYetValid
+	}
+
+	if iat, ok := claims.IssuedAt(); ok && now.Add(s.clockSkew).Before(iat) {
+		return ErrTokenIssuedLater
+	}
+
+	if iss, _ := claims.String("iss"); iss != issuer {
+		return ErrInvalidIssuer
+	}
+
+	if !hasAudience(claims, audience) {
+		return ErrInvalidAudience
+	}
+
+	return nil
+}
+
+// hasAudience determines whether the "aud" claim contains audience,
+// where the claim is either a single string, or an array of strings.
+func hasAudience(claims gojwt.Claims, audience string) bool {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, v := range aud {
+			if v == audience {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func parse(token string) (*parsed, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	p := &parsed{
+		claims: make(gojwt.Claims),
+		data:   []byte(token[:len(parts[0])+1+len(parts[1])]),
+	}
+
+	err := decode(parts[0], &p.header)
+	if err != nil {
+		return nil, err
+	}
+
+	err = decode(parts[1], &p.claims)
+	if err != nil {
+		return nil, err
+	}
+
+	p.signature, err = base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	return p, nil
+}
+
+func decode(part string, v interface{}) error {
+	bytes, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return ErrMalformedToken
+	}
+
+	err = json.Unmarshal(bytes, v)
+	if err != nil {
+		return ErrMalformedToken
+	}
+
+	return nil
+}
+
+// keyAlgorithm returns an algorithm which can verify signatures made by k.
+// Keys without an "alg" parameter are assumed to be used with RS256. Key sets
+// are public, so symmetric keys are never accepted; HS256 is only verified
+// using a client's shared key, with NewHMACAlgorithm.
+func keyAlgorithm(k *jwk.Key) (gojwt.Algorithm, error) {
+	if k.KeyType != "RSA" || (k.Algorithm != "" && k.Algorithm != "RS256") {
+		return nil, ErrUnknownKey
+	}
+
+	pub, err := k.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: der,
+	})
+
+	return rsa.New(data, crypto.SHA256)
+}
@@ -0,0 +1,333 @@
+package token
+
+import (
+	"crypto"
+	"crypto/rand"
+	rsaPkg "crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/reecerussell/gojwt"
+	"github.com/reecerussell/gojwt/rsa"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/reecerussell/goidc/jwk"
+	"github.com/reecerussell/goidc/util"
+)
+
+func newTestKey(t *testing.T) *rsaPkg.PrivateKey {
+	pk, err := rsaPkg.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pk
+}
+
+func newTestAlgorithmForKey(t *testing.T, pk *rsaPkg.PrivateKey) gojwt.Algorithm {
+	data := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(pk),
+	})
+
+	alg, err := rsa.New(data, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return alg
+}
+
+func newTestAlgorithm(t *testing.T) gojwt.Algorithm {
+	return newTestAlgorithmForKey(t, newTestKey(t))
+}
+
+// signClaims builds a token with exactly the given claims, so that
+// the lifetime claims can be set relative to the current time.
+func signClaims(t *testing.T, alg gojwt.Algorithm, claims map[string]interface{}) string {
+	token, err := build(alg, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func testClaims(now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"iss": "test",
+		"aud": "testing",
+		"sub": "user",
+		"exp": timestamp(now.Add(time.Hour)),
+		"nbf": timestamp(now),
+		"iat": timestamp(now),
+	}
+}
+
+func TestVerifyToken_GivenValidToken_ReturnsClaims(t *testing.T) {
+	alg := newTestAlgorithm(t)
+	svc := New("test")
+
+	token, _ := svc.GenerateToken(alg, map[string]interface{}{"sub": "user"}, 3600, "testing")
+
+	claims, err := svc.VerifyToken(alg, token.AccessToken, "testing")
+	assert.NoError(t, err)
+	assert.Equal(t, "user", claims["sub"])
+}
+
+func TestVerifyToken_GivenInvalidAudience_ReturnsError(t *testing.T) {
+	alg := newTestAlgorithm(t)
+	svc := New("test")
+
+	token, _ := svc.GenerateToken(alg, nil, 3600, "testing")
+
+	claims, err := svc.VerifyToken(alg, token.AccessToken, "other")
+	assert.Nil(t, claims)
+	assert.Equal(t, ErrInvalidAudience, err)
+}
+
+func TestVerifyToken_GivenAudienceArray_ReturnsClaims(t *testing.T) {
+	alg := newTestAlgorithm(t)
+	claims := testClaims(util.Time())
+	claims["aud"] = []string{"other", "testing"}
+
+	_, err := New("test").VerifyToken(alg, signClaims(t, alg, claims), "testing")
+	assert.NoError(t, err)
+}
+
+func TestVerifyToken_GivenInvalidIssuer_ReturnsError(t *testing.T) {
+	alg := newTestAlgorithm(t)
+
+	token, _ := New("other").GenerateToken(alg, nil, 3600, "testing")
+
+	claims, err := New("test").VerifyToken(alg, token.AccessToken, "testing")
+	assert.Nil(t, claims)
+	assert.Equal(t, ErrInvalidIssuer, err)
+}
+
+func TestVerifyToken_GivenTokenSignedWithAnotherKey_ReturnsError(t *testing.T) {
+	svc := New("test")
+
+	token, _ := svc.GenerateToken(newTestAlgorithm(t), nil, 3600, "testing")
+
+	claims, err := svc.VerifyToken(newTestAlgorithm(t), token.AccessToken, "testing")
+	assert.Nil(t, claims)
+	assert.Equal(t, ErrInvalidSignature, err)
+}
+
+func TestVerifyToken_GivenMalformedToken_ReturnsError(t *testing.T) {
+	svc := New("test")
+
+	for _, token := range []string{"", "not.a-token", "a.b.c", "e30.e30.!!"} {
+		claims, err := svc.VerifyToken(newTestAlgorithm(t), token, "testing")
+		assert.Nil(t, claims)
+		assert.Equal(t, ErrMalformedToken, err, token)
+	}
+}
+
+func TestVerifyToken_GivenExpiredToken_ReturnsError(t *testing.T) {
+	util.Freeze()
+	defer util.Reset()
+
+	alg := newTestAlgorithm(t)
+	claims := testClaims(util.Time().Add(-2 * time.Hour))
+
+	_, err := New("test").VerifyToken(alg, signClaims(t, alg, claims), "testing")
+	assert.Equal(t, ErrTokenExpired, err)
+}
+
+func TestVerifyToken_GivenTokenWithoutExpiry_ReturnsError(t *testing.T) {
+	alg := newTestAlgorithm(t)
+	claims := testClaims(util.Time())
+	delete(claims, "exp")
+
+	_, err := New("test").VerifyToken(alg, signClaims(t, alg, claims), "testing")
+	assert.Equal(t, ErrTokenExpired, err)
+}
+
+func TestVerifyToken_GivenTokenNotYetValid_ReturnsError(t *testing.T) {
+	util.Freeze()
+	defer util.Reset()
+
+	alg := newTestAlgorithm(t)
+	claims := testClaims(util.Time())
+	claims["nbf"] = timestamp(util.Time().Add(time.Minute))
+
+	_, err := New("test").VerifyToken(alg, signClaims(t, alg, claims), "testing")
+	assert.Equal(t, ErrTokenNotYetValid, err)
+}
+
+func TestVerifyToken_GivenTokenIssuedInTheFuture_ReturnsError(t *testing.T) {
+	util.Freeze()
+	defer util.Reset()
+
+	alg := newTestAlgorithm(t)
+	claims := testClaims(util.Time())
+	claims["iat"] = timestamp(util.Time().Add(time.Minute))
+
+	_, err := New("test").VerifyToken(alg, signClaims(t, alg, claims), "testing")
+	assert.Equal(t, ErrTokenIssuedLater, err)
+}
+
+func TestVerifyToken_WithinClockSkew_ReturnsClaims(t *testing.T) {
+	util.Freeze()
+	defer util.Reset()
+
+	alg := newTestAlgorithm(t)
+	claims := testClaims(util.Time())
+	claims["exp"] = timestamp(util.Time().Add(-10 * time.Second))
+	claims["nbf"] = timestamp(util.Time().Add(10 * time.Second))
+	claims["iat"] = timestamp(util.Time().Add(10 * time.Second))
+	token := signClaims(t, alg, claims)
+
+	_, err := NewWithClockSkew("test", 30*time.Second).VerifyToken(alg, token, "testing")
+	assert.NoError(t, err)
+
+	_, err = NewWithClockSkew("test", 0).VerifyToken(alg, token, "testing")
+	assert.Equal(t, ErrTokenExpired, err)
+}
+
+func TestVerifyTokenWithKeySet_GivenValidToken_ReturnsClaims(t *testing.T) {
+	pk := newTestKey(t)
+	alg := NewAlgorithm(newTestAlgorithmForKey(t, pk), "my-key-id")
+
+	key, _ := jwk.New(jwk.KeyID("my-key-id"), "RS256", &pk.PublicKey)
+	set := &jwk.Set{Keys: []*jwk.Key{key}}
+
+	svc := New("test")
+	token, _ := svc.GenerateToken(alg, map[string]interface{}{"sub": "user"}, 3600, "testing")
+
+	claims, err := svc.VerifyTokenWithKeySet(set, token.AccessToken, "testing")
+	assert.NoError(t, err)
+	assert.Equal(t, "user", claims["sub"])
+}
+
+func TestVerifyTokenWithKeySet_GivenUnknownKid_ReturnsError(t *testing.T) {
+	pk := newTestKey(t)
+	alg := NewAlgorithm(newTestAlgorithmForKey(t, pk), "my-key-id")
+
+	key, _ := jwk.New(jwk.KeyID("other-key-id"), "RS256", &pk.PublicKey)
+	set := &jwk.Set{Keys: []*jwk.Key{key}}
+
+	svc := New("test")
+	token, _ := svc.GenerateToken(alg, nil, 3600, "testing")
+
+	claims, err := svc.VerifyTokenWithKeySet(set, token.AccessToken, "testing")
+	assert.Nil(t, claims)
+	assert.Equal(t, ErrUnknownKey, err)
+}
+
+func TestVerifyTokenWithKeySet_GivenTokenSignedWithAnotherKey_ReturnsError(t *testing.T) {
+	alg := NewAlgorithm(newTestAlgorithm(t), "my-key-id")
+
+	key, _ := jwk.New(jwk.KeyID("my-key-id"), "RS256", &newTestKey(t).PublicKey)
+	set := &jwk.Set{Keys: []*jwk.Key{key}}
+
+	svc := New("test")
+	token, _ := svc.GenerateToken(alg, nil, 3600, "testing")
+
+	claims, err := svc.VerifyTokenWithKeySet(set, token.AccessToken, "testing")
+	assert.Nil(t, claims)
+	assert.Equal(t, ErrInvalidSignature, err)
+}
+
+func TestVerifyTokenIgnoringExpiry_GivenExpiredToken_ReturnsClaims(t *testing.T) {
+	util.Freeze()
+	defer util.Reset()
+
+	alg := newTestAlgorithm(t)
+	claims := testClaims(util.Time().Add(-2 * time.Hour))
+
+	verified, err := New("test").VerifyTokenIgnoringExpiry(alg, signClaims(t, alg, claims), "testing")
+	assert.NoError(t, err)
+	assert.Equal(t, "user", verified["sub"])
+}
+
+func TestVerifyTokenIgnoringExpiry_GivenTokenSignedWithAnotherKey_ReturnsError(t *testing.T) {
+	claims := testClaims(util.Time())
+	token := signClaims(t, newTestAlgorithm(t), claims)
+
+	_, err := New("test").VerifyTokenIgnoringExpiry(newTestAlgorithm(t), token, "testing")
+	assert.Equal(t, ErrInvalidSignature, err)
+}
+
+func TestVerifyTokenIgnoringExpiry_GivenInvalidAudience_ReturnsError(t *testing.T) {
+	alg := newTestAlgorithm(t)
+	token := signClaims(t, alg, testClaims(util.Time()))
+
+	_, err := New("test").VerifyTokenIgnoringExpiry(alg, token, "other")
+	assert.Equal(t, ErrInvalidAudience, err)
+}
+
+func TestVerifyAssertion_GivenValidAssertion_ReturnsClaims(t *testing.T) {
+	alg := NewHMACAlgorithm([]byte("shared key"))
+	claims := testClaims(util.Time())
+	claims["iss"] = "my-client"
+
+	verified, err := New("test").VerifyAssertion(alg, signClaims(t, alg, claims), "my-client", "testing")
+	assert.NoError(t, err)
+	assert.Equal(t, "my-client", verified["iss"])
+}
+
+func TestVerifyAssertion_GivenOtherIssuer_ReturnsError(t *testing.T) {
+	alg := NewHMACAlgorithm([]byte("shared key"))
+	claims := testClaims(util.Time())
+	claims["iss"] = "other-client"
+
+	verified, err := New("test").VerifyAssertion(alg, signClaims(t, alg, claims), "my-client", "testing")
+	assert.Nil(t, verified)
+	assert.Equal(t, ErrInvalidIssuer, err)
+}
+
+func TestVerifyAssertion_GivenAssertionSignedWithAnotherKey_ReturnsError(t *testing.T) {
+	claims := testClaims(util.Time())
+	token := signClaims(t, NewHMACAlgorithm([]byte("other key")), claims)
+
+	verified, err := New("test").VerifyAssertion(NewHMACAlgorithm([]byte("shared key")), token, "test", "testing")
+	assert.Nil(t, verified)
+	assert.Equal(t, ErrInvalidSignature, err)
+}
+
+func TestVerifyAssertionWithKeySet_GivenSingleKeyWithoutKid_ReturnsClaims(t *testing.T) {
+	pk := newTestKey(t)
+	alg := newTestAlgorithmForKey(t, pk)
+
+	// Client keys don't necessarily have an "alg" or "kid" parameter.
+	key, _ := jwk.New("", "", &pk.PublicKey)
+	set := &jwk.Set{Keys: []*jwk.Key{key}}
+
+	claims := testClaims(util.Time())
+	claims["iss"] = "my-client"
+
+	verified, err := New("test").VerifyAssertionWithKeySet(set, signClaims(t, alg, claims), "my-client", "testing")
+	assert.NoError(t, err)
+	assert.Equal(t, "my-client", verified["iss"])
+}
+
+func TestVerifyAssertionWithKeySet_GivenHS256Assertion_ReturnsError(t *testing.T) {
+	pk := newTestKey(t)
+
+	key, _ := jwk.New("", "", &pk.PublicKey)
+	set := &jwk.Set{Keys: []*jwk.Key{key}}
+
+	claims := testClaims(util.Time())
+	claims["iss"] = "my-client"
+	token := signClaims(t, NewHMACAlgorithm([]byte("shared key")), claims)
+
+	verified, err := New("test").VerifyAssertionWithKeySet(set, token, "my-client", "testing")
+	assert.Nil(t, verified)
+	assert.Equal(t, ErrInvalidSignature, err)
+}
+
+func TestUnverifiedClaims_GivenToken_ReturnsClaims(t *testing.T) {
+	alg := NewHMACAlgorithm([]byte("shared key"))
+
+	claims, err := UnverifiedClaims(signClaims(t, alg, testClaims(util.Time())))
+	assert.NoError(t, err)
+	assert.Equal(t, "user", claims["sub"])
+
+	_, err = UnverifiedClaims("not a token")
+	assert.Equal(t, ErrMalformedToken, err)
+}
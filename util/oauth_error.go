@@ -0,0 +1,83 @@
+package util
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// Error codes used in OAuth error responses, as defined by RFC 6749
+// and the extensions supported by the server.
+const (
+	ErrorCodeInvalidRequest          = "invalid_request"
+	ErrorCodeInvalidClient           = "invalid_client"
+	ErrorCodeInvalidGrant            = "invalid_grant"
+	ErrorCodeUnauthorizedClient      = "unauthorized_client"
+	ErrorCodeUnsupportedGrantType    = "unsupported_grant_type"
+	ErrorCodeInvalidScope            = "invalid_scope"
+	ErrorCodeAccessDenied            = "access_denied"
+	ErrorCodeUnsupportedResponseType = "unsupported_response_type"
+	ErrorCodeServerError             = "server_error"
+	ErrorCodeInvalidTarget           = "invalid_target"
+	ErrorCodeInvalidToken            = "invalid_token"
+	ErrorCodeInsufficientScope       = "insufficient_scope"
+	ErrorCodeAuthorizationPending    = "authorization_pending"
+	ErrorCodeSlowDown                = "slow_down"
+	ErrorCodeExpiredToken            = "expired_token"
+	ErrorCodeLoginRequired           = "login_required"
+	ErrorCodeConsentRequired         = "consent_required"
+)
+
+// OAuthError is an error returned to clients as an OAuth error
+// response, as defined by RFC 6749 section 5.2.
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	URI         string `json:"error_uri,omitempty"`
+
+	// StatusCode is the HTTP status code of the error response.
+	StatusCode int `json:"-"`
+}
+
+// NewOAuthError returns a new OAuthError with the given error code
+// and description, which is returned with statusCode.
+func NewOAuthError(statusCode int, code, description string) *OAuthError {
+	return &OAuthError{
+		Code:        code,
+		Description: description,
+		StatusCode:  statusCode,
+	}
+}
+
+// Error returns the error's description, or its code if it has none.
+func (e *OAuthError) Error() string {
+	if e.Description != "" {
+		return e.Description
+	}
+
+	return e.Code
+}
+
+// Params returns the error as authorization response parameters, used
+// when the error is returned to the client's redirect uri.
+func (e *OAuthError) Params() url.Values {
+	params := url.Values{"error": {e.Code}}
+	if e.Description != "" {
+		params.Set("error_description", e.Description)
+	}
+
+	if e.URI != "" {
+		params.Set("error_uri", e.URI)
+	}
+
+	return params
+}
+
+// AsOAuthError returns err as an OAuthError. Errors which are not
+// already an OAuthError are treated as an invalid request.
+func AsOAuthError(err error) *OAuthError {
+	if oerr, ok := err.(*OAuthError); ok {
+		return oerr
+	}
+
+	return NewOAuthError(http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+}
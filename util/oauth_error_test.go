@@ -0,0 +1,43 @@
+package util
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthError_Error(t *testing.T) {
+	err := NewOAuthError(http.StatusBadRequest, ErrorCodeInvalidScope, "invalid scope")
+	assert.Equal(t, "invalid scope", err.Error())
+
+	err = NewOAuthError(http.StatusBadRequest, ErrorCodeSlowDown, "")
+	assert.Equal(t, ErrorCodeSlowDown, err.Error())
+}
+
+func TestOAuthError_Params(t *testing.T) {
+	err := NewOAuthError(http.StatusBadRequest, ErrorCodeInvalidScope, "invalid scope")
+	err.URI = "https://example.com/errors/invalid_scope"
+
+	assert.Equal(t, url.Values{
+		"error":             {"invalid_scope"},
+		"error_description": {"invalid scope"},
+		"error_uri":         {"https://example.com/errors/invalid_scope"},
+	}, err.Params())
+}
+
+func TestAsOAuthError(t *testing.T) {
+	t.Run("Given OAuthError", func(t *testing.T) {
+		err := NewOAuthError(http.StatusUnauthorized, ErrorCodeInvalidClient, "invalid client")
+		assert.Equal(t, err, AsOAuthError(err))
+	})
+
+	t.Run("Given Other Error", func(t *testing.T) {
+		oerr := AsOAuthError(errors.New("invalid content type"))
+		assert.Equal(t, http.StatusBadRequest, oerr.StatusCode)
+		assert.Equal(t, ErrorCodeInvalidRequest, oerr.Code)
+		assert.Equal(t, "invalid content type", oerr.Description)
+	})
+}
@@ -0,0 +1,72 @@
+package util
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+)
+
+// userCodeCharset is the set of characters used in user codes. Vowels are
+// excluded to avoid spelling words, as recommended by RFC 8628.
+const userCodeCharset = "BCDFGHJKLMNPQRSTVWXZ"
+
+// userCodeLength is the number of characters in a user code, excluding the
+// separator, giving 20^8 possible codes.
+const userCodeLength = 8
+
+// RandomString returns a cryptographically secure random string, generated
+// from n random bytes, represented in URL-safe base64 without padding.
+func RandomString(n int) string {
+	bytes := make([]byte, n)
+	if _, err := rand.Read(bytes); err != nil {
+		panic(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
+
+// RandomUserCode returns a cryptographically secure random user code, for the
+// device authorization grant, which is easy for a user to read and type, such
+// as "WDJB-MJHT".
+func RandomUserCode() string {
+	// Bytes greater than the largest multiple of the charset size are
+	// discarded, so that each character is equally likely.
+	max := byte(256 - 256%len(userCodeCharset))
+	code := make([]byte, 0, userCodeLength)
+	bytes := make([]byte, userCodeLength)
+
+	for len(code) < userCodeLength {
+		if _, err := rand.Read(bytes); err != nil {
+			panic(err)
+		}
+
+		for _, b := range bytes {
+			if b < max && len(code) < userCodeLength {
+				code = append(code, userCodeCharset[int(b)%len(userCodeCharset)])
+			}
+		}
+	}
+
+	return formatUserCode(string(code))
+}
+
+// NormalizeUserCode converts a user code entered by a user into the format
+// returned by RandomUserCode, ignoring case and any punctuation or whitespace.
+func NormalizeUserCode(userCode string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToUpper(userCode) {
+		if strings.ContainsRune(userCodeCharset, r) {
+			sb.WriteRune(r)
+		}
+	}
+
+	return formatUserCode(sb.String())
+}
+
+func formatUserCode(code string) string {
+	if len(code) != userCodeLength {
+		return code
+	}
+
+	return code[:userCodeLength/2] + "-" + code[userCodeLength/2:]
+}
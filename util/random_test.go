@@ -0,0 +1,37 @@
+package util
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandomString_GivenLength_ReturnsEncodedBytes(t *testing.T) {
+	value := RandomString(32)
+
+	bytes, err := base64.RawURLEncoding.DecodeString(value)
+	assert.NoError(t, err)
+	assert.Equal(t, 32, len(bytes))
+}
+
+func TestRandomString_CalledTwice_ReturnsDifferentValues(t *testing.T) {
+	assert.NotEqual(t, RandomString(32), RandomString(32))
+}
+
+func TestRandomUserCode_ReturnsFormattedCode(t *testing.T) {
+	value := RandomUserCode()
+
+	assert.Regexp(t, "^[BCDFGHJKLMNPQRSTVWXZ]{4}-[BCDFGHJKLMNPQRSTVWXZ]{4}$", value)
+}
+
+func TestRandomUserCode_CalledTwice_ReturnsDifferentValues(t *testing.T) {
+	assert.NotEqual(t, RandomUserCode(), RandomUserCode())
+}
+
+func TestNormalizeUserCode(t *testing.T) {
+	assert.Equal(t, "WDJB-MJHT", NormalizeUserCode("WDJB-MJHT"))
+	assert.Equal(t, "WDJB-MJHT", NormalizeUserCode("wdjbmjht"))
+	assert.Equal(t, "WDJB-MJHT", NormalizeUserCode(" wdjb mjht "))
+	assert.Equal(t, "WDJ", NormalizeUserCode("wdj"))
+}
@@ -3,6 +3,8 @@ package util
 import (
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"net/http"
 	"net/url"
 	"strings"
 
@@ -22,6 +24,61 @@ func Header(req events.APIGatewayProxyRequest, name string) string {
 	return ""
 }
 
+// Cookie returns the value of the cookie with the given name from req's
+// Cookie header. If req has no such cookie, an empty string is returned.
+func Cookie(req events.APIGatewayProxyRequest, name string) string {
+	r := http.Request{Header: http.Header{"Cookie": {Header(req, "Cookie")}}}
+	c, err := r.Cookie(name)
+	if err != nil {
+		return ""
+	}
+
+	return c.Value
+}
+
+// BearerToken returns the token from req's Authorization header,
+// if it uses the Bearer scheme. Otherwise, an empty string is returned.
+func BearerToken(req events.APIGatewayProxyRequest) string {
+	parts := strings.SplitN(Header(req, "Authorization"), " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(parts[1])
+}
+
+// BasicAuth returns the client id and secret from req's Authorization header,
+// if it uses the Basic scheme. As defined by RFC 6749, the client id and secret
+// are form-urlencoded before being base64 encoded, so are decoded here.
+func BasicAuth(req events.APIGatewayProxyRequest) (clientId, clientSecret string, ok bool) {
+	parts := strings.SplitN(Header(req, "Authorization"), " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Basic") {
+		return "", "", false
+	}
+
+	bytes, err := base64.StdEncoding.DecodeString(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return "", "", false
+	}
+
+	credentials := strings.SplitN(string(bytes), ":", 2)
+	if len(credentials) != 2 {
+		return "", "", false
+	}
+
+	clientId, err = url.QueryUnescape(credentials[0])
+	if err != nil {
+		return "", "", false
+	}
+
+	clientSecret, err = url.QueryUnescape(credentials[1])
+	if err != nil {
+		return "", "", false
+	}
+
+	return clientId, clientSecret, true
+}
+
 // ReadJSON is used to read a JSON request body. If the request
 // body is base64 encoded, it will be decoded and the unmarshalled.
 func ReadJSON(req events.APIGatewayProxyRequest, v interface{}) {
@@ -45,3 +102,20 @@ func ReadForm(req events.APIGatewayProxyRequest) url.Values {
 	data, _ := url.ParseQuery(body)
 	return data
 }
+
+// BaseURL returns the URL the API is being served from, made up of
+// the request's Host header and the API Gateway stage, i.e.
+// https://example.com/prod. X-Forwarded-Proto is used, if present.
+func BaseURL(req events.APIGatewayProxyRequest) string {
+	scheme := Header(req, "X-Forwarded-Proto")
+	if scheme == "" {
+		scheme = "https"
+	}
+
+	baseUrl := fmt.Sprintf("%s://%s", scheme, Header(req, "Host"))
+	if req.RequestContext.Stage != "" {
+		baseUrl += "/" + req.RequestContext.Stage
+	}
+
+	return baseUrl
+}
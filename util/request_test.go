@@ -1,6 +1,7 @@
 package util
 
 import (
+	"encoding/base64"
 	"testing"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -38,6 +39,100 @@ func TestHeader_WhereHeaderIsNotPresent_ReturnsEmptyString(t *testing.T) {
 	assert.Equal(t, "", v)
 }
 
+func TestCookie_GivenCookie_ReturnsValue(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		Headers: map[string]string{
+			"cookie": "theme=dark; session=my.session.value",
+		},
+	}
+
+	assert.Equal(t, "my.session.value", Cookie(req, "session"))
+}
+
+func TestCookie_WhereCookieIsNotPresent_ReturnsEmptyString(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		Headers: map[string]string{
+			"Cookie": "theme=dark",
+		},
+	}
+
+	assert.Equal(t, "", Cookie(req, "session"))
+}
+
+func TestBearerToken_GivenBearerAuthorization_ReturnsToken(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		Headers: map[string]string{
+			"authorization": "Bearer my.jwt.token",
+		},
+	}
+
+	assert.Equal(t, "my.jwt.token", BearerToken(req))
+}
+
+func TestBearerToken_GivenOtherScheme_ReturnsEmptyString(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		Headers: map[string]string{
+			"Authorization": "Basic dXNlcjpwYXNz",
+		},
+	}
+
+	assert.Equal(t, "", BearerToken(req))
+}
+
+func TestBearerToken_WhereHeaderIsNotPresent_ReturnsEmptyString(t *testing.T) {
+	req := events.APIGatewayProxyRequest{}
+
+	assert.Equal(t, "", BearerToken(req))
+}
+
+func TestBasicAuth_GivenBasicAuthorization_ReturnsCredentials(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		Headers: map[string]string{
+			"authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("my%20client:my%3Asecret")),
+		},
+	}
+
+	clientId, clientSecret, ok := BasicAuth(req)
+	assert.True(t, ok)
+	assert.Equal(t, "my client", clientId)
+	assert.Equal(t, "my:secret", clientSecret)
+}
+
+func TestBasicAuth_GivenOtherScheme_ReturnsNotOk(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		Headers: map[string]string{
+			"Authorization": "Bearer my.jwt.token",
+		},
+	}
+
+	_, _, ok := BasicAuth(req)
+	assert.False(t, ok)
+}
+
+func TestBasicAuth_GivenInvalidCredentials_ReturnsNotOk(t *testing.T) {
+	values := []string{
+		"not base64",
+		base64.StdEncoding.EncodeToString([]byte("no separator")),
+		base64.StdEncoding.EncodeToString([]byte("bad%zzescape:secret")),
+	}
+
+	for _, value := range values {
+		req := events.APIGatewayProxyRequest{
+			Headers: map[string]string{
+				"Authorization": "Basic " + value,
+			},
+		}
+
+		_, _, ok := BasicAuth(req)
+		assert.False(t, ok, value)
+	}
+}
+
+func TestBasicAuth_WhereHeaderIsNotPresent_ReturnsNotOk(t *testing.T) {
+	_, _, ok := BasicAuth(events.APIGatewayProxyRequest{})
+	assert.False(t, ok)
+}
+
 func TestReadJSON_GivenBase64Request_UnmarshalsBody(t *testing.T) {
 	const body = "eyJmb28iOiJiYXIifQ=="
 
@@ -83,3 +178,27 @@ func TestReadForm_GivenPlainTextRequest_DecodesBody(t *testing.T) {
 
 	assert.Equal(t, "bar", data.Get("foo"))
 }
+
+func TestBaseURL_GivenRequest_ReturnsUrlWithStage(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		Headers: map[string]string{
+			"Host": "example.com",
+		},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Stage: "prod",
+		},
+	}
+
+	assert.Equal(t, "https://example.com/prod", BaseURL(req))
+}
+
+func TestBaseURL_WhereForwardedProtoIsSet_UsesScheme(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		Headers: map[string]string{
+			"Host":              "localhost:8080",
+			"X-Forwarded-Proto": "http",
+		},
+	}
+
+	assert.Equal(t, "http://localhost:8080", BaseURL(req))
+}
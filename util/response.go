@@ -2,6 +2,7 @@ package util
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -18,12 +19,48 @@ func RespondBadRequest(err error) events.APIGatewayProxyResponse {
 	return Respond(http.StatusBadRequest, Error{Error: err.Error()})
 }
 
+// RespondUnauthorized builds an API Unauthorized response with the
+// given err as the response body.
+func RespondUnauthorized(err error) events.APIGatewayProxyResponse {
+	return Respond(http.StatusUnauthorized, Error{Error: err.Error()})
+}
+
+// RespondForbidden builds an API Forbidden response with the
+// given err as the response body.
+func RespondForbidden(err error) events.APIGatewayProxyResponse {
+	return Respond(http.StatusForbidden, Error{Error: err.Error()})
+}
+
 // RespondMethodNotAllowed builds an API MethodNotAllowed response with the
 // given err as the response body.
 func RespondMethodNotAllowed(err error) events.APIGatewayProxyResponse {
 	return Respond(http.StatusMethodNotAllowed, Error{Error: err.Error()})
 }
 
+// RespondOAuthError builds an OAuth error response for err, using the error's
+// status code. Errors which are not an OAuthError are treated as an invalid
+// request. Clients which fail to authenticate are challenged to use Basic
+// authentication, as required by RFC 6749.
+func RespondOAuthError(err error) events.APIGatewayProxyResponse {
+	oerr := AsOAuthError(err)
+
+	resp := Respond(oerr.StatusCode, oerr)
+	if oerr.StatusCode == http.StatusUnauthorized && oerr.Code == ErrorCodeInvalidClient {
+		resp.Headers["WWW-Authenticate"] = "Basic"
+	}
+
+	return resp
+}
+
+// RespondOAuthServerError builds an OAuth server_error response for err, which
+// is used by OAuth endpoints for internal failures. The error is logged rather
+// than returned, as it may contain details of the server's infrastructure.
+func RespondOAuthServerError(err error) events.APIGatewayProxyResponse {
+	log.Printf("Internal error: %v\n", err)
+
+	return RespondOAuthError(NewOAuthError(http.StatusInternalServerError, ErrorCodeServerError, ""))
+}
+
 // RespondError builds an API InternalServerError response with the
 // given err as the response body.
 func RespondError(err error) events.APIGatewayProxyResponse {
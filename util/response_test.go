@@ -58,3 +58,53 @@ func TestRespondMethodNotAllowed(t *testing.T) {
 	bytes, _ := json.Marshal(Error{Error: err.Error()})
 	assert.Equal(t, string(bytes), resp.Body)
 }
+
+func TestRespondUnauthorized(t *testing.T) {
+	err := errors.New("error")
+
+	resp := RespondUnauthorized(err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.False(t, resp.IsBase64Encoded)
+	assert.Equal(t, "application/json; charset=utf-8", resp.Headers["Content-Type"])
+
+	bytes, _ := json.Marshal(Error{Error: err.Error()})
+	assert.Equal(t, string(bytes), resp.Body)
+}
+
+func TestRespondForbidden(t *testing.T) {
+	err := errors.New("error")
+
+	resp := RespondForbidden(err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.False(t, resp.IsBase64Encoded)
+	assert.Equal(t, "application/json; charset=utf-8", resp.Headers["Content-Type"])
+
+	bytes, _ := json.Marshal(Error{Error: err.Error()})
+	assert.Equal(t, string(bytes), resp.Body)
+}
+
+func TestRespondOAuthError(t *testing.T) {
+	err := NewOAuthError(http.StatusBadRequest, ErrorCodeInvalidScope, "invalid scope")
+
+	resp := RespondOAuthError(err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.False(t, resp.IsBase64Encoded)
+	assert.Equal(t, "application/json; charset=utf-8", resp.Headers["Content-Type"])
+	assert.Equal(t, `{"error":"invalid_scope","error_description":"invalid scope"}`, resp.Body)
+	assert.NotContains(t, resp.Headers, "WWW-Authenticate")
+}
+
+func TestRespondOAuthServerError(t *testing.T) {
+	resp := RespondOAuthServerError(errors.New("dynamodb: table not found"))
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, "application/json; charset=utf-8", resp.Headers["Content-Type"])
+	assert.Equal(t, `{"error":"server_error"}`, resp.Body)
+}
+
+func TestRespondOAuthError_GivenInvalidClient_ReturnsChallenge(t *testing.T) {
+	err := NewOAuthError(http.StatusUnauthorized, ErrorCodeInvalidClient, "invalid client secret")
+
+	resp := RespondOAuthError(err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "Basic", resp.Headers["WWW-Authenticate"])
+}
@@ -1,27 +1,68 @@
 package validator
 
 import (
-	"errors"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"regexp"
+
+	"github.com/reecerussell/gojwt"
 
 	"github.com/reecerussell/goidc/dal"
+	"github.com/reecerussell/goidc/oauth"
 	"github.com/reecerussell/goidc/util"
 )
 
-// Validation errors.
+// Validation errors. These are returned to clients as OAuth error responses,
+// using the error codes defined by RFC 6749 and its extensions.
 var (
-	ErrInvalidSecret      = errors.New("invalid client secret")
-	ErrInvalidGrantType   = errors.New("invalid grant type")
-	ErrMissingScope       = errors.New("missing scope")
-	ErrInvalidScope       = errors.New("invalid scope")
-	ErrMissingRedirectUri = errors.New("missing redirect uri")
-	ErrInvalidRedirectUri = errors.New("invalid redirect uri")
+	ErrInvalidSecret       = util.NewOAuthError(http.StatusUnauthorized, util.ErrorCodeInvalidClient, "invalid client secret")
+	ErrInvalidAuthMethod   = util.NewOAuthError(http.StatusUnauthorized, util.ErrorCodeInvalidClient, "invalid client authentication method")
+	ErrInvalidAssertion    = util.NewOAuthError(http.StatusUnauthorized, util.ErrorCodeInvalidClient, "invalid client assertion")
+	ErrInvalidGrantType    = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeUnauthorizedClient, "invalid grant type")
+	ErrMissingScope        = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidScope, "missing scope")
+	ErrInvalidScope        = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidScope, "invalid scope")
+	ErrMissingRedirectUri  = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidRequest, "missing redirect uri")
+	ErrInvalidRedirectUri  = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidRequest, "invalid redirect uri")
+	ErrInvalidCode         = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidGrant, "invalid authorization code")
+	ErrCodeExpired         = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidGrant, "authorization code has expired")
+	ErrInvalidRefreshToken = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidGrant, "invalid refresh token")
+	ErrInvalidDeviceCode   = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidGrant, "invalid device code")
+	ErrDeviceCodeExpired   = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeExpiredToken, "device code has expired")
+	ErrInvalidTarget       = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidTarget, "invalid target")
+	ErrInvalidResponseMode = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidRequest, "invalid response mode")
+	ErrInvalidResponseType = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeUnauthorizedClient, "invalid response type")
+
+	ErrInvalidPostLogoutRedirectUri = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidRequest, "invalid post logout redirect uri")
+
+	ErrMissingCodeChallenge       = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidRequest, "missing code challenge")
+	ErrInvalidCodeChallengeMethod = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidRequest, "invalid code challenge method")
+	ErrMissingCodeVerifier        = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidGrant, "missing code verifier")
+	ErrInvalidCodeVerifier        = util.NewOAuthError(http.StatusBadRequest, util.ErrorCodeInvalidGrant, "invalid code verifier")
 )
 
+// codeVerifierPattern matches a code verifier, as defined by RFC 7636.
+var codeVerifierPattern = regexp.MustCompile("^[A-Za-z0-9\\-._~]{43,128}$")
+
 // ClientValidator is used to centralize client validation logic, for
 // validating incoming requests.
 type ClientValidator interface {
 	ValidateTokenRequest(c *dal.Client, secret, grantType string, scopes []string) error
+	ValidateTokenEndpointAuthMethod(c *dal.Client, method string) error
+	ValidateClientAssertion(c *dal.Client, claims gojwt.Claims) error
+	ValidateGrantRequest(c *dal.Client, grantType string, scopes []string) error
 	ValidateLoginRequest(c *dal.Client, redirectUri string, scopes []string) error
+	ValidateAuthorizationCode(c *dal.Client, code *dal.AuthorizationCode, redirectUri string) error
+	ValidateResponseType(c *dal.Client, responseType string) error
+	ValidateResponseMode(responseType, responseMode string) error
+	ValidateCodeChallenge(c *dal.Client, challenge, method string) error
+	ValidateCodeVerifier(c *dal.Client, code *dal.AuthorizationCode, verifier string) error
+	ValidateRefreshToken(c *dal.Client, t *dal.RefreshToken, scopes []string) error
+	ValidateDeviceCode(c *dal.Client, code *dal.DeviceCode) error
+	ValidateTokenExchange(c *dal.Client, audience string, scopes, subjectScopes []string) error
+	ValidateClientSecret(c *dal.Client, secret string) error
+	ValidateRevocationRequest(c *dal.Client, secret string) error
+	ValidateLogoutRequest(c *dal.Client, postLogoutRedirectUri string) error
 }
 
 // clientValidator is an implementation of ClientValidator.
@@ -32,13 +73,21 @@ func NewClientValidator() ClientValidator {
 	return &clientValidator{}
 }
 
-func (*clientValidator) ValidateTokenRequest(c *dal.Client, secret, grantType string, scopes []string) error {
-	err := validateSecret(c.Secrets, secret)
-	if err != nil {
-		return err
+func (v *clientValidator) ValidateTokenRequest(c *dal.Client, secret, grantType string, scopes []string) error {
+	if !isPublic(c) || !allowsPublicClients(grantType) {
+		err := validateSecret(c.Secrets, secret)
+		if err != nil {
+			return err
+		}
 	}
 
-	err = validateGrantTypes(c.GrantTypes, grantType)
+	return v.ValidateGrantRequest(c, grantType, scopes)
+}
+
+// ValidateGrantRequest is the same as ValidateTokenRequest, but for clients
+// which have already been authenticated, using a client assertion.
+func (*clientValidator) ValidateGrantRequest(c *dal.Client, grantType string, scopes []string) error {
+	err := validateGrantTypes(c.GrantTypes, grantType)
 	if err != nil {
 		return err
 	}
@@ -51,20 +100,39 @@ func (*clientValidator) ValidateTokenRequest(c *dal.Client, secret, grantType st
 	return nil
 }
 
+// ValidateTokenEndpointAuthMethod ensures c has authenticated with the token
+// endpoint using the method it is registered with. Clients registered without
+// a method may use any method, as the secret is validated separately.
+func (*clientValidator) ValidateTokenEndpointAuthMethod(c *dal.Client, method string) error {
+	if c.TokenEndpointAuthMethod != "" && c.TokenEndpointAuthMethod != method {
+		return ErrInvalidAuthMethod
+	}
+
+	return nil
+}
+
+// ValidateClientAssertion ensures the claims of a verified client assertion,
+// as defined by RFC 7523, identify c and can be used to prevent replays.
+func (*clientValidator) ValidateClientAssertion(c *dal.Client, claims gojwt.Claims) error {
+	return validateAssertion(c.ID, claims)
+}
+
 func (*clientValidator) ValidateLoginRequest(c *dal.Client, redirectUri string, scopes []string) error {
 	if redirectUri == "" {
 		return ErrMissingRedirectUri
 	}
 
-	if len(scopes) < 1 {
-		return ErrMissingScope
-	}
-
+	// The redirect uri is validated first, as other errors are returned
+	// to the client by redirecting to it.
 	err := validateRedirectUri(c.RedirectUris, redirectUri)
 	if err != nil {
 		return err
 	}
 
+	if len(scopes) < 1 {
+		return ErrMissingScope
+	}
+
 	err = validateScopes(c.Scopes, scopes)
 	if err != nil {
 		return err
@@ -73,6 +141,211 @@ func (*clientValidator) ValidateLoginRequest(c *dal.Client, redirectUri string,
 	return nil
 }
 
+func (*clientValidator) ValidateAuthorizationCode(c *dal.Client, code *dal.AuthorizationCode, redirectUri string) error {
+	if code.ClientID != c.ID {
+		return ErrInvalidCode
+	}
+
+	if code.Expires <= util.Time().Unix() {
+		return ErrCodeExpired
+	}
+
+	// The redirect uri must be identical to the one used to obtain the code.
+	if code.RedirectUri != redirectUri {
+		return ErrInvalidRedirectUri
+	}
+
+	return nil
+}
+
+// ValidateResponseType ensures c is registered to use responseType. Clients
+// without registered response types may only use the authorization code flow.
+func (*clientValidator) ValidateResponseType(c *dal.Client, responseType string) error {
+	allowed := c.ResponseTypes
+	if len(allowed) < 1 {
+		allowed = []string{oauth.ResponseTypeCode}
+	}
+
+	for _, rt := range allowed {
+		if oauth.NormalizeResponseType(rt) == responseType {
+			return nil
+		}
+	}
+
+	return ErrInvalidResponseType
+}
+
+// ValidateResponseMode ensures responseMode is supported, and can be used with
+// responseType. Tokens must never be returned in the query, where they would
+// be logged by servers and leaked in Referer headers.
+func (*clientValidator) ValidateResponseMode(responseType, responseMode string) error {
+	switch responseMode {
+	case oauth.ResponseModeFragment, oauth.ResponseModeFormPost:
+		return nil
+	case oauth.ResponseModeQuery:
+		if responseType == oauth.ResponseTypeCode {
+			return nil
+		}
+	}
+
+	return ErrInvalidResponseMode
+}
+
+func (*clientValidator) ValidateCodeChallenge(c *dal.Client, challenge, method string) error {
+	if challenge == "" {
+		if c.RequirePkce || isPublic(c) {
+			return ErrMissingCodeChallenge
+		}
+
+		return nil
+	}
+
+	switch method {
+	case "", oauth.CodeChallengeMethodPlain, oauth.CodeChallengeMethodS256:
+		return nil
+	default:
+		return ErrInvalidCodeChallengeMethod
+	}
+}
+
+func (*clientValidator) ValidateCodeVerifier(c *dal.Client, code *dal.AuthorizationCode, verifier string) error {
+	if code.CodeChallenge == "" {
+		// Codes issued to public clients will always have a challenge,
+		// however, this guards against codes issued before the client
+		// was made public, or required PKCE.
+		if c.RequirePkce || isPublic(c) {
+			return ErrMissingCodeVerifier
+		}
+
+		return nil
+	}
+
+	if verifier == "" {
+		return ErrMissingCodeVerifier
+	}
+
+	if !codeVerifierPattern.MatchString(verifier) {
+		return ErrInvalidCodeVerifier
+	}
+
+	challenge := verifier
+	if code.CodeChallengeMethod == oauth.CodeChallengeMethodS256 {
+		hash := sha256.Sum256([]byte(verifier))
+		challenge = base64.RawURLEncoding.EncodeToString(hash[:])
+	}
+
+	if challenge != code.CodeChallenge {
+		return ErrInvalidCodeVerifier
+	}
+
+	return nil
+}
+
+// ValidateRefreshToken ensures t was issued to c and is still valid. If scopes
+// are given, they must be a subset of the scopes originally granted.
+func (*clientValidator) ValidateRefreshToken(c *dal.Client, t *dal.RefreshToken, scopes []string) error {
+	if t.ClientID != c.ID || t.Revoked {
+		return ErrInvalidRefreshToken
+	}
+
+	if t.Expires <= util.Time().Unix() {
+		return ErrInvalidRefreshToken
+	}
+
+	return validateScopes(t.Scopes, scopes)
+}
+
+// ValidateDeviceCode ensures code was issued to c and has not expired.
+func (*clientValidator) ValidateDeviceCode(c *dal.Client, code *dal.DeviceCode) error {
+	if code.ClientID != c.ID {
+		return ErrInvalidDeviceCode
+	}
+
+	if code.Expires <= util.Time().Unix() {
+		return ErrDeviceCodeExpired
+	}
+
+	return nil
+}
+
+// ValidateTokenExchange ensures c may exchange a token for the given audience,
+// and that the requested scopes are a subset of those granted to the subject
+// token, so exchanged tokens can only be narrower than the original.
+func (*clientValidator) ValidateTokenExchange(c *dal.Client, audience string, scopes, subjectScopes []string) error {
+	isAllowed := false
+	for _, allowed := range c.ExchangeAudiences {
+		if allowed == audience {
+			isAllowed = true
+			break
+		}
+	}
+
+	if !isAllowed {
+		return ErrInvalidTarget
+	}
+
+	return validateScopes(subjectScopes, scopes)
+}
+
+// ValidateClientSecret authenticates a confidential client, for endpoints which
+// are not part of a grant. Public clients have no secret, so will always fail.
+func (*clientValidator) ValidateClientSecret(c *dal.Client, secret string) error {
+	return validateSecret(c.Secrets, secret)
+}
+
+// ValidateRevocationRequest authenticates a client revoking a token. Public
+// clients cannot authenticate, so are identified by their client id alone.
+func (*clientValidator) ValidateRevocationRequest(c *dal.Client, secret string) error {
+	if isPublic(c) {
+		return nil
+	}
+
+	return validateSecret(c.Secrets, secret)
+}
+
+// ValidateLogoutRequest ensures postLogoutRedirectUri has been registered by c,
+// so users cannot be sent to an arbitrary site once they've logged out.
+func (*clientValidator) ValidateLogoutRequest(c *dal.Client, postLogoutRedirectUri string) error {
+	for _, uri := range c.PostLogoutRedirectUris {
+		if uri == postLogoutRedirectUri {
+			return nil
+		}
+	}
+
+	return ErrInvalidPostLogoutRedirectUri
+}
+
+// isPublic determines whether c is a public client, meaning it cannot
+// authenticate. This is decided by the client's registered authentication
+// method, as clients using private_key_jwt or client_secret_jwt have no
+// secrets, but are confidential. Clients registered without a method may
+// use any method, so are only public if they have no credentials at all.
+func isPublic(c *dal.Client) bool {
+	switch c.TokenEndpointAuthMethod {
+	case oauth.TokenEndpointAuthMethodNone:
+		return true
+	case "":
+		return len(c.Secrets) < 1 && c.SharedKey == "" && c.JWKS == nil && c.JWKSUri == ""
+	default:
+		return false
+	}
+}
+
+// allowsPublicClients determines whether grantType can be used by public clients.
+//
+// Public clients cannot keep a secret, so instead of authenticating they prove
+// possession of an authorization code using PKCE, or of a device code which
+// the user has approved, and rely on refresh token rotation to detect stolen
+// refresh tokens.
+func allowsPublicClients(grantType string) bool {
+	switch grantType {
+	case oauth.GrantTypeAuthorizationCode, oauth.GrantTypeRefreshToken, oauth.GrantTypeDeviceCode:
+		return true
+	default:
+		return false
+	}
+}
+
 func validateSecret(allowedSecrets []string, secret string) error {
 	for _, allowed := range allowedSecrets {
 		if allowed == util.Sha256(secret) {
@@ -83,6 +356,23 @@ func validateSecret(allowedSecrets []string, secret string) error {
 	return ErrInvalidSecret
 }
 
+// validateAssertion authenticates a client using the claims of a client
+// assertion, whose signature, audience and lifetime have been verified. Both
+// the issuer and subject must be the client, and a jti must be given.
+func validateAssertion(clientID string, claims gojwt.Claims) error {
+	iss, _ := claims.String("iss")
+	sub, _ := claims.String("sub")
+	if iss != clientID || sub != clientID {
+		return ErrInvalidAssertion
+	}
+
+	if jti, _ := claims.String("jti"); jti == "" {
+		return ErrInvalidAssertion
+	}
+
+	return nil
+}
+
 func validateGrantTypes(allowedTypes []string, grantType string) error {
 	for _, allowed := range allowedTypes {
 		if allowed == grantType {
@@ -3,9 +3,11 @@ package validator
 import (
 	"testing"
 
+	"github.com/reecerussell/gojwt"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/reecerussell/goidc/dal"
+	"github.com/reecerussell/goidc/oauth"
 	"github.com/reecerussell/goidc/util"
 )
 
@@ -48,6 +50,96 @@ func TestClientValidator_ValidateTokenRequest_ReturnsError(t *testing.T) {
 	})
 }
 
+func TestClientValidator_ValidateTokenEndpointAuthMethod(t *testing.T) {
+	cv := NewClientValidator()
+
+	t.Run("Given Registered Method", func(t *testing.T) {
+		testClient := &dal.Client{TokenEndpointAuthMethod: "client_secret_basic"}
+
+		err := cv.ValidateTokenEndpointAuthMethod(testClient, "client_secret_basic")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Given Other Method", func(t *testing.T) {
+		testClient := &dal.Client{TokenEndpointAuthMethod: "client_secret_basic"}
+
+		err := cv.ValidateTokenEndpointAuthMethod(testClient, "client_secret_post")
+		assert.Equal(t, ErrInvalidAuthMethod, err)
+	})
+
+	t.Run("Given Client Without Method", func(t *testing.T) {
+		for _, method := range []string{"client_secret_basic", "client_secret_post", "none"} {
+			err := cv.ValidateTokenEndpointAuthMethod(&dal.Client{}, method)
+			assert.NoError(t, err)
+		}
+	})
+}
+
+func TestClientValidator_ValidateGrantRequest(t *testing.T) {
+	testClient := &dal.Client{
+		GrantTypes: []string{"client_credentials"},
+		Scopes:     []string{"openid"},
+	}
+
+	cv := NewClientValidator()
+
+	t.Run("Given Valid Request", func(t *testing.T) {
+		err := cv.ValidateGrantRequest(testClient, "client_credentials", []string{"openid"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Given Invalid Grant Type", func(t *testing.T) {
+		err := cv.ValidateGrantRequest(testClient, "password", nil)
+		assert.Equal(t, ErrInvalidGrantType, err)
+	})
+
+	t.Run("Given Invalid Scope", func(t *testing.T) {
+		err := cv.ValidateGrantRequest(testClient, "client_credentials", []string{"email"})
+		assert.Equal(t, ErrInvalidScope, err)
+	})
+}
+
+func TestClientValidator_ValidateClientAssertion(t *testing.T) {
+	testClient := &dal.Client{ID: "2394u23"}
+
+	cv := NewClientValidator()
+
+	t.Run("Given Valid Claims", func(t *testing.T) {
+		err := cv.ValidateClientAssertion(testClient, gojwt.Claims{
+			"iss": "2394u23",
+			"sub": "2394u23",
+			"jti": "2l3k4j2l3k4j",
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Given Other Subject", func(t *testing.T) {
+		err := cv.ValidateClientAssertion(testClient, gojwt.Claims{
+			"iss": "2394u23",
+			"sub": "another client",
+			"jti": "2l3k4j2l3k4j",
+		})
+		assert.Equal(t, ErrInvalidAssertion, err)
+	})
+
+	t.Run("Given Other Issuer", func(t *testing.T) {
+		err := cv.ValidateClientAssertion(testClient, gojwt.Claims{
+			"iss": "another client",
+			"sub": "2394u23",
+			"jti": "2l3k4j2l3k4j",
+		})
+		assert.Equal(t, ErrInvalidAssertion, err)
+	})
+
+	t.Run("Given No Jti", func(t *testing.T) {
+		err := cv.ValidateClientAssertion(testClient, gojwt.Claims{
+			"iss": "2394u23",
+			"sub": "2394u23",
+		})
+		assert.Equal(t, ErrInvalidAssertion, err)
+	})
+}
+
 func TestClientValidator_ValidateLoginRequest_ReturnsNoError(t *testing.T) {
 	testClient := &dal.Client{
 		RedirectUris: []string{"http://localhost:8080"},
@@ -82,12 +174,512 @@ func TestClientValidator_ValidateLoginRequest_ReturnsError(t *testing.T) {
 	})
 
 	t.Run("Given No Scopes", func(t *testing.T) {
-		err := cv.ValidateLoginRequest(testClient, "http://google.com", []string{})
+		err := cv.ValidateLoginRequest(testClient, "http://localhost:8080", []string{})
 		assert.Equal(t, ErrMissingScope, err)
 	})
 
+	t.Run("Given Invalid RedirectUri And No Scopes", func(t *testing.T) {
+		err := cv.ValidateLoginRequest(testClient, "http://google.com", []string{})
+		assert.Equal(t, ErrInvalidRedirectUri, err)
+	})
+
 	t.Run("Given Invalid Scope", func(t *testing.T) {
 		err := cv.ValidateLoginRequest(testClient, "http://localhost:8080", []string{"openid", "test"})
 		assert.Equal(t, ErrInvalidScope, err)
 	})
 }
+
+func TestClientValidator_ValidateAuthorizationCode_ReturnsNoError(t *testing.T) {
+	testClient := &dal.Client{ID: "2394u23"}
+	testCode := &dal.AuthorizationCode{
+		ClientID:    "2394u23",
+		RedirectUri: "http://localhost:8080",
+		Expires:     util.Time().Unix() + 60,
+	}
+
+	cv := NewClientValidator()
+	err := cv.ValidateAuthorizationCode(testClient, testCode, "http://localhost:8080")
+	assert.NoError(t, err)
+}
+
+func TestClientValidator_ValidateAuthorizationCode_ReturnsError(t *testing.T) {
+	testClient := &dal.Client{ID: "2394u23"}
+
+	cv := NewClientValidator()
+
+	t.Run("Given Code For Another Client", func(t *testing.T) {
+		testCode := &dal.AuthorizationCode{
+			ClientID:    "another client",
+			RedirectUri: "http://localhost:8080",
+			Expires:     util.Time().Unix() + 60,
+		}
+
+		err := cv.ValidateAuthorizationCode(testClient, testCode, "http://localhost:8080")
+		assert.Equal(t, ErrInvalidCode, err)
+	})
+
+	t.Run("Given Expired Code", func(t *testing.T) {
+		testCode := &dal.AuthorizationCode{
+			ClientID:    "2394u23",
+			RedirectUri: "http://localhost:8080",
+			Expires:     util.Time().Unix() - 1,
+		}
+
+		err := cv.ValidateAuthorizationCode(testClient, testCode, "http://localhost:8080")
+		assert.Equal(t, ErrCodeExpired, err)
+	})
+
+	t.Run("Given Different RedirectUri", func(t *testing.T) {
+		testCode := &dal.AuthorizationCode{
+			ClientID:    "2394u23",
+			RedirectUri: "http://localhost:8080",
+			Expires:     util.Time().Unix() + 60,
+		}
+
+		err := cv.ValidateAuthorizationCode(testClient, testCode, "http://google.com")
+		assert.Equal(t, ErrInvalidRedirectUri, err)
+	})
+}
+
+func TestClientValidator_ValidateTokenRequest_GivenPublicClient_SkipsSecret(t *testing.T) {
+	testClient := &dal.Client{
+		GrantTypes: []string{"authorization_code", "client_credentials"},
+		Scopes:     []string{"openid"},
+	}
+
+	cv := NewClientValidator()
+
+	t.Run("Given Authorization Code Grant", func(t *testing.T) {
+		err := cv.ValidateTokenRequest(testClient, "", "authorization_code", nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Given Client Credentials Grant", func(t *testing.T) {
+		err := cv.ValidateTokenRequest(testClient, "", "client_credentials", []string{"openid"})
+		assert.Equal(t, ErrInvalidSecret, err)
+	})
+
+	t.Run("Given Device Code Grant", func(t *testing.T) {
+		testClient := &dal.Client{
+			GrantTypes: []string{"urn:ietf:params:oauth:grant-type:device_code"},
+			Scopes:     []string{"openid"},
+		}
+
+		err := cv.ValidateTokenRequest(testClient, "", "urn:ietf:params:oauth:grant-type:device_code", []string{"openid"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Given Password Grant", func(t *testing.T) {
+		testClient := &dal.Client{
+			GrantTypes: []string{"password"},
+			Scopes:     []string{"openid"},
+		}
+
+		err := cv.ValidateTokenRequest(testClient, "", "password", []string{"openid"})
+		assert.Equal(t, ErrInvalidSecret, err)
+	})
+}
+
+func TestClientValidator_ValidateResponseType_ReturnsNoError(t *testing.T) {
+	cv := NewClientValidator()
+
+	t.Run("Given Code For Unregistered Client", func(t *testing.T) {
+		err := cv.ValidateResponseType(&dal.Client{}, oauth.ResponseTypeCode)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Given Registered Type In Any Order", func(t *testing.T) {
+		testClient := &dal.Client{ResponseTypes: []string{"token id_token"}}
+
+		err := cv.ValidateResponseType(testClient, oauth.ResponseTypeIDTokenToken)
+		assert.NoError(t, err)
+	})
+}
+
+func TestClientValidator_ValidateResponseType_ReturnsError(t *testing.T) {
+	cv := NewClientValidator()
+
+	t.Run("Given Implicit For Unregistered Client", func(t *testing.T) {
+		err := cv.ValidateResponseType(&dal.Client{}, oauth.ResponseTypeIDTokenToken)
+		assert.Equal(t, ErrInvalidResponseType, err)
+	})
+
+	t.Run("Given Unregistered Type", func(t *testing.T) {
+		testClient := &dal.Client{ResponseTypes: []string{"code", "code id_token"}}
+
+		err := cv.ValidateResponseType(testClient, oauth.ResponseTypeCodeIDTokenToken)
+		assert.Equal(t, ErrInvalidResponseType, err)
+	})
+}
+
+func TestClientValidator_ValidateResponseMode_ReturnsNoError(t *testing.T) {
+	cv := NewClientValidator()
+
+	t.Run("Given Code In Query", func(t *testing.T) {
+		err := cv.ValidateResponseMode(oauth.ResponseTypeCode, oauth.ResponseModeQuery)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Given Tokens In Fragment", func(t *testing.T) {
+		err := cv.ValidateResponseMode(oauth.ResponseTypeIDTokenToken, oauth.ResponseModeFragment)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Given Tokens Using Form Post", func(t *testing.T) {
+		err := cv.ValidateResponseMode(oauth.ResponseTypeIDTokenToken, oauth.ResponseModeFormPost)
+		assert.NoError(t, err)
+	})
+}
+
+func TestClientValidator_ValidateResponseMode_ReturnsError(t *testing.T) {
+	cv := NewClientValidator()
+
+	t.Run("Given Tokens In Query", func(t *testing.T) {
+		err := cv.ValidateResponseMode(oauth.ResponseTypeIDTokenToken, oauth.ResponseModeQuery)
+		assert.Equal(t, ErrInvalidResponseMode, err)
+	})
+
+	t.Run("Given Unsupported Mode", func(t *testing.T) {
+		err := cv.ValidateResponseMode(oauth.ResponseTypeCode, "web_message")
+		assert.Equal(t, ErrInvalidResponseMode, err)
+	})
+}
+
+func TestClientValidator_ValidateCodeChallenge_ReturnsNoError(t *testing.T) {
+	cv := NewClientValidator()
+
+	t.Run("Given Confidential Client Without Challenge", func(t *testing.T) {
+		testClient := &dal.Client{Secrets: []string{"239473204"}}
+
+		err := cv.ValidateCodeChallenge(testClient, "", "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Given Private Key JWT Client Without Challenge", func(t *testing.T) {
+		testClient := &dal.Client{
+			TokenEndpointAuthMethod: oauth.TokenEndpointAuthMethodPrivateKeyJWT,
+			JWKSUri:                 "https://client.example.com/jwks",
+		}
+
+		err := cv.ValidateCodeChallenge(testClient, "", "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Given Plain Challenge", func(t *testing.T) {
+		err := cv.ValidateCodeChallenge(&dal.Client{}, "challenge", oauth.CodeChallengeMethodPlain)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Given S256 Challenge", func(t *testing.T) {
+		err := cv.ValidateCodeChallenge(&dal.Client{}, "challenge", oauth.CodeChallengeMethodS256)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Given No Challenge Method", func(t *testing.T) {
+		err := cv.ValidateCodeChallenge(&dal.Client{}, "challenge", "")
+		assert.NoError(t, err)
+	})
+}
+
+func TestClientValidator_ValidateCodeChallenge_ReturnsError(t *testing.T) {
+	cv := NewClientValidator()
+
+	t.Run("Given Public Client Without Challenge", func(t *testing.T) {
+		err := cv.ValidateCodeChallenge(&dal.Client{}, "", "")
+		assert.Equal(t, ErrMissingCodeChallenge, err)
+	})
+
+	t.Run("Given Client Requiring PKCE Without Challenge", func(t *testing.T) {
+		testClient := &dal.Client{Secrets: []string{"239473204"}, RequirePkce: true}
+
+		err := cv.ValidateCodeChallenge(testClient, "", "")
+		assert.Equal(t, ErrMissingCodeChallenge, err)
+	})
+
+	t.Run("Given Client Registered As Public Without Challenge", func(t *testing.T) {
+		testClient := &dal.Client{
+			Secrets:                 []string{"239473204"},
+			TokenEndpointAuthMethod: oauth.TokenEndpointAuthMethodNone,
+		}
+
+		err := cv.ValidateCodeChallenge(testClient, "", "")
+		assert.Equal(t, ErrMissingCodeChallenge, err)
+	})
+
+	t.Run("Given Unsupported Challenge Method", func(t *testing.T) {
+		err := cv.ValidateCodeChallenge(&dal.Client{}, "challenge", "S512")
+		assert.Equal(t, ErrInvalidCodeChallengeMethod, err)
+	})
+}
+
+func TestClientValidator_ValidateCodeVerifier_ReturnsNoError(t *testing.T) {
+	// Taken from RFC 7636, Appendix B.
+	const testVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	const testChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	cv := NewClientValidator()
+
+	t.Run("Given S256 Verifier", func(t *testing.T) {
+		testCode := &dal.AuthorizationCode{
+			CodeChallenge:       testChallenge,
+			CodeChallengeMethod: oauth.CodeChallengeMethodS256,
+		}
+
+		err := cv.ValidateCodeVerifier(&dal.Client{}, testCode, testVerifier)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Given Plain Verifier", func(t *testing.T) {
+		testCode := &dal.AuthorizationCode{
+			CodeChallenge:       testVerifier,
+			CodeChallengeMethod: oauth.CodeChallengeMethodPlain,
+		}
+
+		err := cv.ValidateCodeVerifier(&dal.Client{}, testCode, testVerifier)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Given Confidential Client Without Challenge", func(t *testing.T) {
+		testClient := &dal.Client{Secrets: []string{"239473204"}}
+
+		err := cv.ValidateCodeVerifier(testClient, &dal.AuthorizationCode{}, "")
+		assert.NoError(t, err)
+	})
+}
+
+func TestClientValidator_ValidateCodeVerifier_ReturnsError(t *testing.T) {
+	const testChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	cv := NewClientValidator()
+	testCode := &dal.AuthorizationCode{
+		CodeChallenge:       testChallenge,
+		CodeChallengeMethod: oauth.CodeChallengeMethodS256,
+	}
+
+	t.Run("Given Empty Verifier", func(t *testing.T) {
+		err := cv.ValidateCodeVerifier(&dal.Client{}, testCode, "")
+		assert.Equal(t, ErrMissingCodeVerifier, err)
+	})
+
+	t.Run("Given Incorrect Verifier", func(t *testing.T) {
+		err := cv.ValidateCodeVerifier(&dal.Client{}, testCode, "aBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+		assert.Equal(t, ErrInvalidCodeVerifier, err)
+	})
+
+	t.Run("Given Malformed Verifier", func(t *testing.T) {
+		err := cv.ValidateCodeVerifier(&dal.Client{}, testCode, "too short")
+		assert.Equal(t, ErrInvalidCodeVerifier, err)
+	})
+
+	t.Run("Given Public Client Without Challenge", func(t *testing.T) {
+		err := cv.ValidateCodeVerifier(&dal.Client{}, &dal.AuthorizationCode{}, "")
+		assert.Equal(t, ErrMissingCodeVerifier, err)
+	})
+}
+
+func TestClientValidator_ValidateRefreshToken_ReturnsNoError(t *testing.T) {
+	testClient := &dal.Client{ID: "2394u23"}
+	testToken := &dal.RefreshToken{
+		ClientID: "2394u23",
+		Scopes:   []string{"openid", "email"},
+		Expires:  util.Time().Unix() + 60,
+	}
+
+	cv := NewClientValidator()
+
+	t.Run("Given No Scopes", func(t *testing.T) {
+		err := cv.ValidateRefreshToken(testClient, testToken, nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Given Narrower Scopes", func(t *testing.T) {
+		err := cv.ValidateRefreshToken(testClient, testToken, []string{"email"})
+		assert.NoError(t, err)
+	})
+}
+
+func TestClientValidator_ValidateRefreshToken_ReturnsError(t *testing.T) {
+	testClient := &dal.Client{ID: "2394u23"}
+
+	cv := NewClientValidator()
+
+	t.Run("Given Token For Another Client", func(t *testing.T) {
+		testToken := &dal.RefreshToken{
+			ClientID: "another client",
+			Expires:  util.Time().Unix() + 60,
+		}
+
+		err := cv.ValidateRefreshToken(testClient, testToken, nil)
+		assert.Equal(t, ErrInvalidRefreshToken, err)
+	})
+
+	t.Run("Given Revoked Token", func(t *testing.T) {
+		testToken := &dal.RefreshToken{
+			ClientID: "2394u23",
+			Revoked:  true,
+			Expires:  util.Time().Unix() + 60,
+		}
+
+		err := cv.ValidateRefreshToken(testClient, testToken, nil)
+		assert.Equal(t, ErrInvalidRefreshToken, err)
+	})
+
+	t.Run("Given Expired Token", func(t *testing.T) {
+		testToken := &dal.RefreshToken{
+			ClientID: "2394u23",
+			Expires:  util.Time().Unix() - 1,
+		}
+
+		err := cv.ValidateRefreshToken(testClient, testToken, nil)
+		assert.Equal(t, ErrInvalidRefreshToken, err)
+	})
+
+	t.Run("Given Broader Scopes", func(t *testing.T) {
+		testToken := &dal.RefreshToken{
+			ClientID: "2394u23",
+			Scopes:   []string{"openid"},
+			Expires:  util.Time().Unix() + 60,
+		}
+
+		err := cv.ValidateRefreshToken(testClient, testToken, []string{"openid", "email"})
+		assert.Equal(t, ErrInvalidScope, err)
+	})
+}
+
+func TestClientValidator_ValidateDeviceCode_ReturnsNoError(t *testing.T) {
+	testClient := &dal.Client{ID: "2394u23"}
+	testCode := &dal.DeviceCode{
+		ClientID: "2394u23",
+		Expires:  util.Time().Unix() + 60,
+	}
+
+	cv := NewClientValidator()
+	err := cv.ValidateDeviceCode(testClient, testCode)
+	assert.NoError(t, err)
+}
+
+func TestClientValidator_ValidateDeviceCode_ReturnsError(t *testing.T) {
+	testClient := &dal.Client{ID: "2394u23"}
+
+	cv := NewClientValidator()
+
+	t.Run("Given Code For Another Client", func(t *testing.T) {
+		testCode := &dal.DeviceCode{
+			ClientID: "another client",
+			Expires:  util.Time().Unix() + 60,
+		}
+
+		err := cv.ValidateDeviceCode(testClient, testCode)
+		assert.Equal(t, ErrInvalidDeviceCode, err)
+	})
+
+	t.Run("Given Expired Code", func(t *testing.T) {
+		testCode := &dal.DeviceCode{
+			ClientID: "2394u23",
+			Expires:  util.Time().Unix() - 1,
+		}
+
+		err := cv.ValidateDeviceCode(testClient, testCode)
+		assert.Equal(t, ErrDeviceCodeExpired, err)
+	})
+}
+
+func TestClientValidator_ValidateTokenExchange_ReturnsNoError(t *testing.T) {
+	testClient := &dal.Client{ExchangeAudiences: []string{"orders"}}
+
+	cv := NewClientValidator()
+	err := cv.ValidateTokenExchange(testClient, "orders", []string{"orders:read"}, []string{"openid", "orders:read"})
+	assert.NoError(t, err)
+}
+
+func TestClientValidator_ValidateTokenExchange_ReturnsError(t *testing.T) {
+	testClient := &dal.Client{ExchangeAudiences: []string{"orders"}}
+
+	cv := NewClientValidator()
+
+	t.Run("Given Unlisted Audience", func(t *testing.T) {
+		err := cv.ValidateTokenExchange(testClient, "payments", nil, []string{"openid"})
+		assert.Equal(t, ErrInvalidTarget, err)
+	})
+
+	t.Run("Given Broader Scopes", func(t *testing.T) {
+		err := cv.ValidateTokenExchange(testClient, "orders", []string{"orders:write"}, []string{"orders:read"})
+		assert.Equal(t, ErrInvalidScope, err)
+	})
+}
+
+func TestClientValidator_ValidateClientSecret(t *testing.T) {
+	cv := NewClientValidator()
+
+	t.Run("Given Valid Secret", func(t *testing.T) {
+		testClient := &dal.Client{Secrets: []string{util.Sha256("test")}}
+
+		err := cv.ValidateClientSecret(testClient, "test")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Given Invalid Secret", func(t *testing.T) {
+		testClient := &dal.Client{Secrets: []string{util.Sha256("test")}}
+
+		err := cv.ValidateClientSecret(testClient, "other")
+		assert.Equal(t, ErrInvalidSecret, err)
+	})
+
+	t.Run("Given Public Client", func(t *testing.T) {
+		err := cv.ValidateClientSecret(&dal.Client{}, "")
+		assert.Equal(t, ErrInvalidSecret, err)
+	})
+}
+
+func TestClientValidator_ValidateRevocationRequest(t *testing.T) {
+	cv := NewClientValidator()
+
+	t.Run("Given Valid Secret", func(t *testing.T) {
+		testClient := &dal.Client{Secrets: []string{util.Sha256("test")}}
+
+		err := cv.ValidateRevocationRequest(testClient, "test")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Given Invalid Secret", func(t *testing.T) {
+		testClient := &dal.Client{Secrets: []string{util.Sha256("test")}}
+
+		err := cv.ValidateRevocationRequest(testClient, "")
+		assert.Equal(t, ErrInvalidSecret, err)
+	})
+
+	t.Run("Given Public Client", func(t *testing.T) {
+		err := cv.ValidateRevocationRequest(&dal.Client{}, "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Given Private Key JWT Client", func(t *testing.T) {
+		testClient := &dal.Client{TokenEndpointAuthMethod: oauth.TokenEndpointAuthMethodPrivateKeyJWT}
+
+		err := cv.ValidateRevocationRequest(testClient, "")
+		assert.Equal(t, ErrInvalidSecret, err)
+	})
+}
+
+func TestClientValidator_ValidateLogoutRequest(t *testing.T) {
+	cv := NewClientValidator()
+	testClient := &dal.Client{
+		RedirectUris:           []string{"https://client.example.com/callback"},
+		PostLogoutRedirectUris: []string{"https://client.example.com/logged-out"},
+	}
+
+	t.Run("Given Registered Uri", func(t *testing.T) {
+		err := cv.ValidateLogoutRequest(testClient, "https://client.example.com/logged-out")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Given Unregistered Uri", func(t *testing.T) {
+		err := cv.ValidateLogoutRequest(testClient, "https://evil.example.com")
+		assert.Equal(t, ErrInvalidPostLogoutRedirectUri, err)
+	})
+
+	t.Run("Given Login Redirect Uri", func(t *testing.T) {
+		err := cv.ValidateLogoutRequest(testClient, "https://client.example.com/callback")
+		assert.Equal(t, ErrInvalidPostLogoutRedirectUri, err)
+	})
+}
@@ -7,6 +7,7 @@ package mock
 import (
 	gomock "github.com/golang/mock/gomock"
 	dal "github.com/reecerussell/goidc/dal"
+	gojwt "github.com/reecerussell/gojwt"
 	reflect "reflect"
 )
 
@@ -33,18 +34,102 @@ func (m *MockClientValidator) EXPECT() *MockClientValidatorMockRecorder {
 	return m.recorder
 }
 
-// ValidateTokenRequest mocks base method.
-func (m *MockClientValidator) ValidateTokenRequest(c *dal.Client, secret, grantType string, scopes []string) error {
+// ValidateAuthorizationCode mocks base method.
+func (m *MockClientValidator) ValidateAuthorizationCode(c *dal.Client, code *dal.AuthorizationCode, redirectUri string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ValidateTokenRequest", c, secret, grantType, scopes)
+	ret := m.ctrl.Call(m, "ValidateAuthorizationCode", c, code, redirectUri)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// ValidateTokenRequest indicates an expected call of ValidateTokenRequest.
-func (mr *MockClientValidatorMockRecorder) ValidateTokenRequest(c, secret, grantType, scopes interface{}) *gomock.Call {
+// ValidateAuthorizationCode indicates an expected call of ValidateAuthorizationCode.
+func (mr *MockClientValidatorMockRecorder) ValidateAuthorizationCode(c, code, redirectUri interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateTokenRequest", reflect.TypeOf((*MockClientValidator)(nil).ValidateTokenRequest), c, secret, grantType, scopes)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateAuthorizationCode", reflect.TypeOf((*MockClientValidator)(nil).ValidateAuthorizationCode), c, code, redirectUri)
+}
+
+// ValidateClientAssertion mocks base method.
+func (m *MockClientValidator) ValidateClientAssertion(c *dal.Client, claims gojwt.Claims) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateClientAssertion", c, claims)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateClientAssertion indicates an expected call of ValidateClientAssertion.
+func (mr *MockClientValidatorMockRecorder) ValidateClientAssertion(c, claims interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateClientAssertion", reflect.TypeOf((*MockClientValidator)(nil).ValidateClientAssertion), c, claims)
+}
+
+// ValidateClientSecret mocks base method.
+func (m *MockClientValidator) ValidateClientSecret(c *dal.Client, secret string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateClientSecret", c, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateClientSecret indicates an expected call of ValidateClientSecret.
+func (mr *MockClientValidatorMockRecorder) ValidateClientSecret(c, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateClientSecret", reflect.TypeOf((*MockClientValidator)(nil).ValidateClientSecret), c, secret)
+}
+
+// ValidateCodeChallenge mocks base method.
+func (m *MockClientValidator) ValidateCodeChallenge(c *dal.Client, challenge, method string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateCodeChallenge", c, challenge, method)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateCodeChallenge indicates an expected call of ValidateCodeChallenge.
+func (mr *MockClientValidatorMockRecorder) ValidateCodeChallenge(c, challenge, method interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateCodeChallenge", reflect.TypeOf((*MockClientValidator)(nil).ValidateCodeChallenge), c, challenge, method)
+}
+
+// ValidateCodeVerifier mocks base method.
+func (m *MockClientValidator) ValidateCodeVerifier(c *dal.Client, code *dal.AuthorizationCode, verifier string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateCodeVerifier", c, code, verifier)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateCodeVerifier indicates an expected call of ValidateCodeVerifier.
+func (mr *MockClientValidatorMockRecorder) ValidateCodeVerifier(c, code, verifier interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateCodeVerifier", reflect.TypeOf((*MockClientValidator)(nil).ValidateCodeVerifier), c, code, verifier)
+}
+
+// ValidateDeviceCode mocks base method.
+func (m *MockClientValidator) ValidateDeviceCode(c *dal.Client, code *dal.DeviceCode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateDeviceCode", c, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateDeviceCode indicates an expected call of ValidateDeviceCode.
+func (mr *MockClientValidatorMockRecorder) ValidateDeviceCode(c, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateDeviceCode", reflect.TypeOf((*MockClientValidator)(nil).ValidateDeviceCode), c, code)
+}
+
+// ValidateGrantRequest mocks base method.
+func (m *MockClientValidator) ValidateGrantRequest(c *dal.Client, grantType string, scopes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateGrantRequest", c, grantType, scopes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateGrantRequest indicates an expected call of ValidateGrantRequest.
+func (mr *MockClientValidatorMockRecorder) ValidateGrantRequest(c, grantType, scopes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateGrantRequest", reflect.TypeOf((*MockClientValidator)(nil).ValidateGrantRequest), c, grantType, scopes)
 }
 
 // ValidateLoginRequest mocks base method.
@@ -60,3 +145,115 @@ func (mr *MockClientValidatorMockRecorder) ValidateLoginRequest(c, redirectUri,
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateLoginRequest", reflect.TypeOf((*MockClientValidator)(nil).ValidateLoginRequest), c, redirectUri, scopes)
 }
+
+// ValidateLogoutRequest mocks base method.
+func (m *MockClientValidator) ValidateLogoutRequest(c *dal.Client, postLogoutRedirectUri string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateLogoutRequest", c, postLogoutRedirectUri)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateLogoutRequest indicates an expected call of ValidateLogoutRequest.
+func (mr *MockClientValidatorMockRecorder) ValidateLogoutRequest(c, postLogoutRedirectUri interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateLogoutRequest", reflect.TypeOf((*MockClientValidator)(nil).ValidateLogoutRequest), c, postLogoutRedirectUri)
+}
+
+// ValidateRefreshToken mocks base method.
+func (m *MockClientValidator) ValidateRefreshToken(c *dal.Client, t *dal.RefreshToken, scopes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateRefreshToken", c, t, scopes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateRefreshToken indicates an expected call of ValidateRefreshToken.
+func (mr *MockClientValidatorMockRecorder) ValidateRefreshToken(c, t, scopes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateRefreshToken", reflect.TypeOf((*MockClientValidator)(nil).ValidateRefreshToken), c, t, scopes)
+}
+
+// ValidateResponseMode mocks base method.
+func (m *MockClientValidator) ValidateResponseMode(responseType, responseMode string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateResponseMode", responseType, responseMode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateResponseMode indicates an expected call of ValidateResponseMode.
+func (mr *MockClientValidatorMockRecorder) ValidateResponseMode(responseType, responseMode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateResponseMode", reflect.TypeOf((*MockClientValidator)(nil).ValidateResponseMode), responseType, responseMode)
+}
+
+// ValidateResponseType mocks base method.
+func (m *MockClientValidator) ValidateResponseType(c *dal.Client, responseType string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateResponseType", c, responseType)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateResponseType indicates an expected call of ValidateResponseType.
+func (mr *MockClientValidatorMockRecorder) ValidateResponseType(c, responseType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateResponseType", reflect.TypeOf((*MockClientValidator)(nil).ValidateResponseType), c, responseType)
+}
+
+// ValidateRevocationRequest mocks base method.
+func (m *MockClientValidator) ValidateRevocationRequest(c *dal.Client, secret string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateRevocationRequest", c, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateRevocationRequest indicates an expected call of ValidateRevocationRequest.
+func (mr *MockClientValidatorMockRecorder) ValidateRevocationRequest(c, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateRevocationRequest", reflect.TypeOf((*MockClientValidator)(nil).ValidateRevocationRequest), c, secret)
+}
+
+// ValidateTokenEndpointAuthMethod mocks base method.
+func (m *MockClientValidator) ValidateTokenEndpointAuthMethod(c *dal.Client, method string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateTokenEndpointAuthMethod", c, method)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateTokenEndpointAuthMethod indicates an expected call of ValidateTokenEndpointAuthMethod.
+func (mr *MockClientValidatorMockRecorder) ValidateTokenEndpointAuthMethod(c, method interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateTokenEndpointAuthMethod", reflect.TypeOf((*MockClientValidator)(nil).ValidateTokenEndpointAuthMethod), c, method)
+}
+
+// ValidateTokenExchange mocks base method.
+func (m *MockClientValidator) ValidateTokenExchange(c *dal.Client, audience string, scopes, subjectScopes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateTokenExchange", c, audience, scopes, subjectScopes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateTokenExchange indicates an expected call of ValidateTokenExchange.
+func (mr *MockClientValidatorMockRecorder) ValidateTokenExchange(c, audience, scopes, subjectScopes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateTokenExchange", reflect.TypeOf((*MockClientValidator)(nil).ValidateTokenExchange), c, audience, scopes, subjectScopes)
+}
+
+// ValidateTokenRequest mocks base method.
+func (m *MockClientValidator) ValidateTokenRequest(c *dal.Client, secret, grantType string, scopes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateTokenRequest", c, secret, grantType, scopes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateTokenRequest indicates an expected call of ValidateTokenRequest.
+func (mr *MockClientValidatorMockRecorder) ValidateTokenRequest(c, secret, grantType, scopes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateTokenRequest", reflect.TypeOf((*MockClientValidator)(nil).ValidateTokenRequest), c, secret, grantType, scopes)
+}